@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"lcode/pkg/digit"
+	"net"
 	"os"
 	"time"
 )
@@ -12,6 +13,7 @@ import (
 const (
 	defaultHTTPRWTimeout          = 10 * time.Second
 	defaultHTTPMaxHeaderMegabytes = 1
+	defaultShutdownTimeout        = 30 * time.Second
 
 	defaultPage       = 1
 	defaultLimitCount = 30
@@ -21,6 +23,56 @@ const (
 	defaultAccessTokenExpTime  = time.Second * 300
 	defaultRefreshTokenExpTime = time.Hour * 24 * 30
 	defaultSecretKey           = "secret"
+
+	defaultJudgeDriver            = "judge0"
+	defaultJudgeMaxMemoryLimitKB  = 512000
+	defaultJudgeMaxTimeLimitSec   = 15.0
+	defaultJudgeWorkersCount      = 8
+	defaultJudgeQueueMaxSize      = 1000
+	defaultJudgeUserQueueMaxSize  = 5
+	defaultJudgeSubmissionMode    = "wait"
+	defaultJudgeBatchPollInterval = time.Millisecond * 500
+	defaultJudgeRunWorkersCount   = 2
+	defaultJudgeRunRateLimit      = 10
+	defaultJudgeRunRateInterval   = time.Minute
+
+	defaultJudgeRequestTimeout   = time.Second * 60
+	defaultJudgeRetryMaxAttempts = 5
+	defaultJudgeRetryBaseDelay   = time.Millisecond * 100
+	defaultJudgeRetryMaxDelay    = time.Second * 5
+	defaultJudgeBreakerThreshold = 5
+	defaultJudgeBreakerCooldown  = time.Second * 30
+
+	defaultJudgeCapabilitiesRefreshInterval = time.Minute * 5
+
+	defaultJudgeStuckDeadline    = time.Minute * 10
+	defaultJudgeWatchdogInterval = time.Minute
+
+	defaultJudgeEndpointScheme = "http"
+	defaultJudgeEndpointWeight = 1
+
+	defaultJudgeFakeStdout = "echo"
+
+	defaultRankingRefreshInterval  = time.Minute * 10
+	defaultRankingHistogramBuckets = 20
+
+	defaultPlagiarismKGramSize  = 12
+	defaultPlagiarismWindowSize = 8
+	defaultPlagiarismThreshold  = 50
+
+	defaultOutputMaxBytes      = 64 * 1024
+	defaultOutputFullTTL       = time.Hour * 24
+	defaultOutputRetention     = time.Hour * 24 * 30
+	defaultOutputPruneInterval = time.Hour
+
+	defaultTestArchiveMaxSize = "512MB"
+	defaultTestCaseMaxSize    = "256MB"
+	defaultSampleMaxSize      = "64KB"
+	defaultJudgeBatchMaxSize  = "64MB"
+	defaultJudgeInputMaxSize  = "64MB"
+
+	defaultTestArchiveMaxUnpackedSize = "2GB"
+	defaultTestArchiveMaxEntries      = 10000
 )
 
 type (
@@ -35,6 +87,13 @@ type (
 		DBConfig          DBConfig
 		QueryParams       QueryParams
 		JudgeConfig       JudgeConfig
+		RankingConfig     RankingConfig
+		PlagiarismConfig  PlagiarismConfig
+		OutputConfig      OutputConfig
+
+		// ShutdownTimeout bounds graceful shutdown: finishing of HTTP
+		// requests and of solutions which are being judged
+		ShutdownTimeout time.Duration
 	}
 
 	HTTPConfig struct {
@@ -64,13 +123,157 @@ type (
 	Files struct {
 		MainFolder        string
 		UserAvatarMaxSize int64
+		// TestArchiveMaxSize limits uploaded archive of test cases,
+		// TestCaseMaxSize limits every input and output file in it.
+		// TestArchiveMaxUnpackedSize limits all files of the archive
+		// together and TestArchiveMaxEntries limits their number
+		TestArchiveMaxSize         int64
+		TestCaseMaxSize            int64
+		TestArchiveMaxUnpackedSize int64
+		TestArchiveMaxEntries      int
+		// JudgeInputMaxSize limits input of every test case: inputs are
+		// read into memory to be sent to the judge, larger ones are
+		// rejected on upload
+		JudgeInputMaxSize int64
+		// SampleMaxSize limits input and output of file backed samples
+		// shown on the problem page, larger files are cut
+		SampleMaxSize int64
+		// JudgeBatchMaxSize limits total input of test cases sent to the
+		// judge in one batch, a larger test case is sent alone
+		JudgeBatchMaxSize int64
 	}
 
 	JudgeConfig struct {
+		// Driver is either "judge0" (real Judge0 servers of Endpoints),
+		// "fake" (in-memory judge) or "emulator" (in-process Judge0 stand-in server)
+		Driver string
+		// Host and Port are the single judge endpoint used when Endpoints are
+		// not set
 		Host                 string
 		Port                 string
 		DefaultMemoryLimitKB int     `mapstructure:"defaultMemoryLimitKB"`
 		DefaultTimeLimitSec  float64 `mapstructure:"defaultTimeLimitSec"`
+		// MaxMemoryLimitKB and MaxTimeLimitSec are MAX_MEMORY_LIMIT and
+		// MAX_CPU_TIME_LIMIT of the judge, greater limits are rejected by it
+		MaxMemoryLimitKB int     `mapstructure:"maxMemoryLimitKB"`
+		MaxTimeLimitSec  float64 `mapstructure:"maxTimeLimitSec"`
+		WorkersCount     int     `mapstructure:"workersCount"`
+		// InstanceID marks queue items claimed by this server, it must differ
+		// between servers sharing the database and stay the same across
+		// restarts of one server. Hostname by default
+		InstanceID   string `mapstructure:"instanceID"`
+		QueueMaxSize int    `mapstructure:"queueMaxSize"`
+		// UserQueueMaxSize limits solutions of one user waiting for judging
+		UserQueueMaxSize int `mapstructure:"userQueueMaxSize"`
+		// SubmissionMode is either "wait" (one blocking request per test case),
+		// "batch" (all test cases at once with token polling) or "callback"
+		// (the judge sends results to CallbackURL, workers do not wait for them)
+		SubmissionMode    string        `mapstructure:"submissionMode"`
+		BatchPollInterval time.Duration `mapstructure:"batchPollInterval"`
+		// CallbackURL is the address of this server reachable from the judge,
+		// callbacks of the judge are signed with CallbackSecret
+		CallbackURL    string `mapstructure:"callbackURL"`
+		CallbackSecret string `mapstructure:"callbackSecret"`
+		// Run* values configure "run code" requests which are judged apart
+		// from solutions: RunRateLimit runs per user during RunRateInterval
+		RunWorkersCount int           `mapstructure:"runWorkersCount"`
+		RunRateLimit    int           `mapstructure:"runRateLimit"`
+		RunRateInterval time.Duration `mapstructure:"runRateInterval"`
+
+		// LanguageLimits are keyed by judge language id, task limits are
+		// multiplied by them unless the task template overrides the limits
+		LanguageLimits map[string]LanguageLimitsConfig `mapstructure:"languageLimits"`
+
+		// RequestTimeout bounds a single request to the judge, failed requests
+		// are retried up to RetryMaxAttempts times with exponential backoff
+		// from RetryBaseDelay to RetryMaxDelay. After BreakerThreshold failures
+		// in a row requests fail fast during BreakerCooldown
+		RequestTimeout   time.Duration `mapstructure:"requestTimeout"`
+		RetryMaxAttempts int           `mapstructure:"retryMaxAttempts"`
+		RetryBaseDelay   time.Duration `mapstructure:"retryBaseDelay"`
+		RetryMaxDelay    time.Duration `mapstructure:"retryMaxDelay"`
+		BreakerThreshold int           `mapstructure:"breakerThreshold"`
+		BreakerCooldown  time.Duration `mapstructure:"breakerCooldown"`
+
+		// CapabilitiesRefreshInterval is how long languages and statuses
+		// discovered from the judge are cached
+		CapabilitiesRefreshInterval time.Duration `mapstructure:"capabilitiesRefreshInterval"`
+
+		// StuckDeadline is how long a solution can be judged, the watchdog
+		// checks solutions every WatchdogInterval and judges stuck ones again
+		// or fails them when their attempts are exhausted
+		StuckDeadline    time.Duration `mapstructure:"stuckDeadline"`
+		WatchdogInterval time.Duration `mapstructure:"watchdogInterval"`
+
+		// Endpoints are Judge0 servers, submissions are sent to healthy ones
+		// with the least load per unit of weight
+		Endpoints []JudgeEndpointConfig `mapstructure:"endpoints"`
+
+		// Fake scripts the "fake" and "emulator" drivers
+		Fake JudgeFakeConfig `mapstructure:"fake"`
+	}
+
+	// JudgeFakeConfig scripts answers of the fake judge. Stdout is either
+	// "echo" (stdin is printed back) or "empty", Verdicts override it for
+	// submissions with the same stdin, e.g. test inputs of local tasks
+	JudgeFakeConfig struct {
+		Stdout   string
+		Verdicts []JudgeFakeVerdictConfig
+	}
+
+	// JudgeFakeVerdictConfig is the answer for submissions with Stdin, Status
+	// is a judge status id and is computed by the fake judge when omitted
+	JudgeFakeVerdictConfig struct {
+		Stdin  string
+		Stdout string
+		Status int
+	}
+
+	// JudgeEndpointConfig is a Judge0 server, AuthToken and AuthUser are sent
+	// in X-Auth-Token and X-Auth-User headers when they are set. Name is
+	// encoded in tokens of submissions, so it must be unique and kept when
+	// endpoints are changed. Weight is defaulted only when it is not set.
+	JudgeEndpointConfig struct {
+		Name      string
+		Scheme    string
+		Host      string
+		Port      string
+		Weight    *int
+		AuthToken string `mapstructure:"authToken"`
+		AuthUser  string `mapstructure:"authUser"`
+	}
+
+	// RankingConfig configures periodically refreshed stats of accepted
+	// solutions, percentiles and histograms are computed from them
+	RankingConfig struct {
+		RefreshInterval  time.Duration `mapstructure:"refreshInterval"`
+		HistogramBuckets int           `mapstructure:"histogramBuckets"`
+	}
+
+	// PlagiarismConfig configures fingerprinting of solutions: hashes of
+	// KGramSize tokens are winnowed with window of WindowSize hashes. Pairs of
+	// solutions with similarity of Threshold percent or more are reported
+	PlagiarismConfig struct {
+		KGramSize  int     `mapstructure:"kgramSize"`
+		WindowSize int     `mapstructure:"windowSize"`
+		Threshold  float64 `mapstructure:"threshold"`
+	}
+
+	// OutputConfig bounds stdout and stderr stored with solution results:
+	// outputs longer than MaxBytes are truncated, the full output is kept
+	// for download during FullTTL, compressed when Compress is set. Outputs
+	// of results older than Retention are pruned every PruneInterval
+	OutputConfig struct {
+		MaxBytes      int           `mapstructure:"maxBytes"`
+		Compress      bool          `mapstructure:"compress"`
+		FullTTL       time.Duration `mapstructure:"fullTTL"`
+		Retention     time.Duration `mapstructure:"retention"`
+		PruneInterval time.Duration `mapstructure:"pruneInterval"`
+	}
+
+	LanguageLimitsConfig struct {
+		TimeMultiplier   float64 `mapstructure:"timeMultiplier"`
+		MemoryMultiplier float64 `mapstructure:"memoryMultiplier"`
 	}
 
 	QueryParams struct {
@@ -100,8 +303,15 @@ func Init(configsDir string) (*Config, error) {
 
 func parseFiles(cfg *Config) error {
 	var f struct {
-		MainFolder        string
-		UserAvatarMaxSize string
+		MainFolder         string
+		UserAvatarMaxSize  string
+		TestArchiveMaxSize string
+		TestCaseMaxSize    string
+		SampleMaxSize      string
+		JudgeBatchMaxSize  string
+		JudgeInputMaxSize  string
+
+		TestArchiveMaxUnpackedSize string
 	}
 
 	if err := viper.UnmarshalKey("files.mainFolder", &f.MainFolder); err != nil {
@@ -121,6 +331,92 @@ func parseFiles(cfg *Config) error {
 
 	cfg.Files.UserAvatarMaxSize = size
 
+	if err := viper.UnmarshalKey("files.testArchiveMaxSize", &f.TestArchiveMaxSize); err != nil {
+		return err
+	}
+
+	if f.TestArchiveMaxSize == "" {
+		f.TestArchiveMaxSize = defaultTestArchiveMaxSize
+	}
+
+	cfg.Files.TestArchiveMaxSize, err = digit.ParseSize(f.TestArchiveMaxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.testCaseMaxSize", &f.TestCaseMaxSize); err != nil {
+		return err
+	}
+
+	if f.TestCaseMaxSize == "" {
+		f.TestCaseMaxSize = defaultTestCaseMaxSize
+	}
+
+	cfg.Files.TestCaseMaxSize, err = digit.ParseSize(f.TestCaseMaxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.sampleMaxSize", &f.SampleMaxSize); err != nil {
+		return err
+	}
+
+	if f.SampleMaxSize == "" {
+		f.SampleMaxSize = defaultSampleMaxSize
+	}
+
+	cfg.Files.SampleMaxSize, err = digit.ParseSize(f.SampleMaxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.judgeBatchMaxSize", &f.JudgeBatchMaxSize); err != nil {
+		return err
+	}
+
+	if f.JudgeBatchMaxSize == "" {
+		f.JudgeBatchMaxSize = defaultJudgeBatchMaxSize
+	}
+
+	cfg.Files.JudgeBatchMaxSize, err = digit.ParseSize(f.JudgeBatchMaxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.judgeInputMaxSize", &f.JudgeInputMaxSize); err != nil {
+		return err
+	}
+
+	if f.JudgeInputMaxSize == "" {
+		f.JudgeInputMaxSize = defaultJudgeInputMaxSize
+	}
+
+	cfg.Files.JudgeInputMaxSize, err = digit.ParseSize(f.JudgeInputMaxSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.testArchiveMaxUnpackedSize", &f.TestArchiveMaxUnpackedSize); err != nil {
+		return err
+	}
+
+	if f.TestArchiveMaxUnpackedSize == "" {
+		f.TestArchiveMaxUnpackedSize = defaultTestArchiveMaxUnpackedSize
+	}
+
+	cfg.Files.TestArchiveMaxUnpackedSize, err = digit.ParseSize(f.TestArchiveMaxUnpackedSize)
+	if err != nil {
+		return err
+	}
+
+	if err := viper.UnmarshalKey("files.testArchiveMaxEntries", &cfg.Files.TestArchiveMaxEntries); err != nil {
+		return err
+	}
+
+	if cfg.Files.TestArchiveMaxEntries == 0 {
+		cfg.Files.TestArchiveMaxEntries = defaultTestArchiveMaxEntries
+	}
+
 	return nil
 }
 
@@ -143,6 +439,10 @@ func parseYml(configDir string, cfg *Config) error {
 		return err
 	}
 
+	if err := viper.UnmarshalKey("shutdownTimeout", &cfg.ShutdownTimeout); err != nil {
+		return err
+	}
+
 	if err := viper.UnmarshalKey("http", &cfg.HTTP); err != nil {
 		return err
 	}
@@ -167,9 +467,186 @@ func parseYml(configDir string, cfg *Config) error {
 		return err
 	}
 
+	cfg.JudgeConfig.setDefaults()
+
+	if err := viper.UnmarshalKey("ranking", &cfg.RankingConfig); err != nil {
+		return err
+	}
+
+	cfg.RankingConfig.setDefaults()
+
+	if err := viper.UnmarshalKey("plagiarism", &cfg.PlagiarismConfig); err != nil {
+		return err
+	}
+
+	cfg.PlagiarismConfig.setDefaults()
+
+	if err := viper.UnmarshalKey("output", &cfg.OutputConfig); err != nil {
+		return err
+	}
+
+	cfg.OutputConfig.setDefaults()
+
 	return nil
 }
 
+// setDefaults fills judge values missing in config file, viper does not merge
+// defaults of nested keys when the whole "judge" section is unmarshalled.
+func (c *JudgeConfig) setDefaults() {
+	if c.Driver == "" {
+		c.Driver = defaultJudgeDriver
+	}
+
+	if c.MaxMemoryLimitKB == 0 {
+		c.MaxMemoryLimitKB = defaultJudgeMaxMemoryLimitKB
+	}
+
+	if c.MaxTimeLimitSec == 0 {
+		c.MaxTimeLimitSec = defaultJudgeMaxTimeLimitSec
+	}
+
+	if c.WorkersCount == 0 {
+		c.WorkersCount = defaultJudgeWorkersCount
+	}
+
+	if c.InstanceID == "" {
+		c.InstanceID, _ = os.Hostname()
+	}
+
+	if c.QueueMaxSize == 0 {
+		c.QueueMaxSize = defaultJudgeQueueMaxSize
+	}
+
+	if c.UserQueueMaxSize == 0 {
+		c.UserQueueMaxSize = defaultJudgeUserQueueMaxSize
+	}
+
+	if c.SubmissionMode == "" {
+		c.SubmissionMode = defaultJudgeSubmissionMode
+	}
+
+	if c.BatchPollInterval == 0 {
+		c.BatchPollInterval = defaultJudgeBatchPollInterval
+	}
+
+	if c.RunWorkersCount == 0 {
+		c.RunWorkersCount = defaultJudgeRunWorkersCount
+	}
+
+	if c.RunRateLimit == 0 {
+		c.RunRateLimit = defaultJudgeRunRateLimit
+	}
+
+	if c.RunRateInterval == 0 {
+		c.RunRateInterval = defaultJudgeRunRateInterval
+	}
+
+	if c.RequestTimeout == 0 {
+		c.RequestTimeout = defaultJudgeRequestTimeout
+	}
+
+	if c.RetryMaxAttempts == 0 {
+		c.RetryMaxAttempts = defaultJudgeRetryMaxAttempts
+	}
+
+	if c.RetryBaseDelay == 0 {
+		c.RetryBaseDelay = defaultJudgeRetryBaseDelay
+	}
+
+	if c.RetryMaxDelay == 0 {
+		c.RetryMaxDelay = defaultJudgeRetryMaxDelay
+	}
+
+	if c.BreakerThreshold == 0 {
+		c.BreakerThreshold = defaultJudgeBreakerThreshold
+	}
+
+	if c.BreakerCooldown == 0 {
+		c.BreakerCooldown = defaultJudgeBreakerCooldown
+	}
+
+	if c.CapabilitiesRefreshInterval == 0 {
+		c.CapabilitiesRefreshInterval = defaultJudgeCapabilitiesRefreshInterval
+	}
+
+	if c.StuckDeadline == 0 {
+		c.StuckDeadline = defaultJudgeStuckDeadline
+	}
+
+	if c.WatchdogInterval == 0 {
+		c.WatchdogInterval = defaultJudgeWatchdogInterval
+	}
+
+	if len(c.Endpoints) == 0 {
+		c.Endpoints = []JudgeEndpointConfig{{Host: c.Host, Port: c.Port}}
+	}
+
+	for i := range c.Endpoints {
+		c.Endpoints[i].setDefaults()
+	}
+
+	if c.Fake.Stdout == "" {
+		c.Fake.Stdout = defaultJudgeFakeStdout
+	}
+}
+
+func (c *JudgeEndpointConfig) setDefaults() {
+	if c.Scheme == "" {
+		c.Scheme = defaultJudgeEndpointScheme
+	}
+
+	if c.Weight == nil {
+		weight := defaultJudgeEndpointWeight
+		c.Weight = &weight
+	}
+
+	if c.Name == "" {
+		c.Name = net.JoinHostPort(c.Host, c.Port)
+	}
+}
+
+func (c *RankingConfig) setDefaults() {
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = defaultRankingRefreshInterval
+	}
+
+	if c.HistogramBuckets == 0 {
+		c.HistogramBuckets = defaultRankingHistogramBuckets
+	}
+}
+
+func (c *PlagiarismConfig) setDefaults() {
+	if c.KGramSize == 0 {
+		c.KGramSize = defaultPlagiarismKGramSize
+	}
+
+	if c.WindowSize == 0 {
+		c.WindowSize = defaultPlagiarismWindowSize
+	}
+
+	if c.Threshold == 0 {
+		c.Threshold = defaultPlagiarismThreshold
+	}
+}
+
+func (c *OutputConfig) setDefaults() {
+	if c.MaxBytes == 0 {
+		c.MaxBytes = defaultOutputMaxBytes
+	}
+
+	if c.FullTTL == 0 {
+		c.FullTTL = defaultOutputFullTTL
+	}
+
+	if c.Retention == 0 {
+		c.Retention = defaultOutputRetention
+	}
+
+	if c.PruneInterval == 0 {
+		c.PruneInterval = defaultOutputPruneInterval
+	}
+}
+
 func parseEnv(configDir string, cfg *Config) error {
 	path_db, ok := os.LookupEnv("PATH_DB")
 	if ok {
@@ -209,6 +686,7 @@ func parseConfigFile(folder string, fileType string) error {
 }
 
 func InitDefault() {
+	viper.SetDefault("shutdownTimeout", defaultShutdownTimeout)
 	viper.SetDefault("http.max_header_megabytes", defaultHTTPMaxHeaderMegabytes)
 	viper.SetDefault("http.timeouts.read", defaultHTTPRWTimeout)
 	viper.SetDefault("http.timeouts.write", defaultHTTPRWTimeout)
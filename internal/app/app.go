@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
 	"lcode/config"
 	"lcode/internal/domain"
 	"lcode/internal/handler"
@@ -19,7 +20,11 @@ import (
 	"lcode/pkg/struct_errors"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -30,13 +35,16 @@ const (
 )
 
 type App struct {
-	Server *server.Server
-	l      *slog.Logger
-	cfg    *config.Config
+	Server    *server.Server
+	l         *slog.Logger
+	logCloser io.Closer
+	cfg       *config.Config
+	db        *postgres.DbManager
+	managers  *manager.Managers
 }
 
 func Init(cfg *config.Config) *App {
-	_, l, err := logger.New(&logger.Options{
+	logCloser, l, err := logger.New(&logger.Options{
 		LogFilePath:        logFilePath,
 		BufferSize:         logBufferSize,
 		BufferFlushTimeout: logBufferTimeout,
@@ -62,7 +70,10 @@ func Init(cfg *config.Config) *App {
 	// init infrastructure
 	repos := repository.New(&repository.InitParams{Config: cfg, DB: db})
 
-	apis := webapi.New(&webapi.InitParams{Config: cfg})
+	apis, err := webapi.New(&webapi.InitParams{Config: cfg})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	services := service.New(
 		&service.InitParams{
@@ -78,6 +89,7 @@ func Init(cfg *config.Config) *App {
 			Config:             cfg,
 			Logger:             l,
 			TransactionManager: transactionProvider,
+			DB:                 db,
 		},
 		services,
 		apis,
@@ -102,28 +114,92 @@ func Init(cfg *config.Config) *App {
 
 	s := server.NewServer(cfg, l, handlers, middlewares)
 
-	return &App{Server: s, l: l, cfg: cfg}
+	return &App{
+		Server:    s,
+		l:         l,
+		logCloser: logCloser,
+		cfg:       cfg,
+		db:        db,
+		managers:  managers,
+	}
 }
 
+// Run serves HTTP until SIGINT or SIGTERM is received and then shuts the app
+// down gracefully.
 func (a *App) Run() {
-	srvAddr := fmt.Sprintf("%s:%s", a.cfg.HTTP.Host, a.cfg.HTTP.Port)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", a.cfg.HTTP.Host, a.cfg.HTTP.Port),
+		Handler: a.Server.GinRouter,
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		if !a.cfg.TLS.Enabled {
+			a.l.Info(fmt.Sprintf("server starting on http://%s", srv.Addr))
 
-	if !a.cfg.TLS.Enabled {
-		a.l.Info(fmt.Sprintf("server starting on http://%s", srvAddr))
+			errCh <- srv.ListenAndServe()
 
-		if err := a.Server.GinRouter.Run(srvAddr); err != nil {
-			a.l.Error("failed run app: ", slog.String("err", err.Error()))
+			return
 		}
 
-		return
-	}
+		a.l.Info(fmt.Sprintf("server starting on https://%s", srv.Addr))
 
-	a.l.Info(fmt.Sprintf("server starting on https://%s", srvAddr))
+		errCh <- srv.ListenAndServeTLS(a.cfg.TLS.CertFile, a.cfg.TLS.KeyFile)
+	}()
 
-	err := a.Server.GinRouter.RunTLS(srvAddr, a.cfg.TLS.CertFile, a.cfg.TLS.KeyFile)
-	if err != nil {
+	select {
+	case <-ctx.Done():
+		a.l.Info("shutdown signal received")
+	case err := <-errCh:
 		a.l.Error("failed run app: ", slog.String("err", err.Error()))
 	}
+
+	a.shutdown(srv)
+}
+
+// shutdown stops accepting requests and lets in-flight requests and judging
+// finish within ShutdownTimeout, then closes database and flushes logs.
+func (a *App) shutdown(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			a.l.Error("can not shutdown http server", slog.String("err", err.Error()))
+		}
+	}()
+
+	if err := a.managers.SolutionManager.Shutdown(ctx); err != nil {
+		a.l.Error("can not shutdown solution workers", slog.String("err", err.Error()))
+	}
+
+	if err := a.managers.RankingManager.Shutdown(ctx); err != nil {
+		a.l.Error("can not stop solution stats refresh", slog.String("err", err.Error()))
+	}
+
+	if err := a.managers.PlagiarismManager.Shutdown(ctx); err != nil {
+		a.l.Error("can not finish plagiarism checks", slog.String("err", err.Error()))
+	}
+
+	wg.Wait()
+
+	a.db.GetDb().Close()
+
+	a.l.Info("server stopped")
+
+	if err := a.logCloser.Close(); err != nil {
+		log.Println(err)
+	}
 }
 
 func setDefaultData(s *service.Services, m *manager.Managers) error {
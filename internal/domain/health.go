@@ -0,0 +1,28 @@
+package domain
+
+const (
+	ComponentStatusUp   = "up"
+	ComponentStatusDown = "down"
+)
+
+const (
+	// ReadinessStatusDegraded means the judge is unreachable: problems can be
+	// browsed, but submissions are refused
+	ReadinessStatusReady    = "ready"
+	ReadinessStatusDegraded = "degraded"
+	ReadinessStatusNotReady = "not_ready"
+)
+
+type (
+	ComponentHealth struct {
+		Status    string `json:"status"`
+		LatencyMs int64  `json:"latency_ms"`
+		Error     string `json:"error,omitempty"`
+	}
+
+	Readiness struct {
+		Status   string          `json:"status"`
+		Database ComponentHealth `json:"database"`
+		Judge    ComponentHealth `json:"judge"`
+	}
+)
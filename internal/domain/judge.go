@@ -1,6 +1,13 @@
 package domain
 
-import "lcode/pkg/struct_errors"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"lcode/pkg/struct_errors"
+	"net/url"
+	"path"
+)
 
 type JudgeStatus int
 
@@ -21,6 +28,68 @@ const (
 	ExecFormatError
 )
 
+// IsFinished reports whether the judge has completed the submission.
+func (s JudgeStatus) IsFinished() bool {
+	return s != InQueue && s != Processing
+}
+
+type JudgeSubmissionMode string
+
+const (
+	JudgeSubmissionModeWait  JudgeSubmissionMode = "wait"
+	JudgeSubmissionModeBatch JudgeSubmissionMode = "batch"
+	// JudgeSubmissionModeCallback submissions are not waited for, the judge
+	// sends their results to JudgeCallbackRoute
+	JudgeSubmissionModeCallback JudgeSubmissionMode = "callback"
+)
+
+const (
+	// JudgeCallbackRoute receives results of submissions by solution id, the
+	// judge is authenticated with JudgeCallbackSignatureQuery parameter
+	JudgeCallbackRoute          = "/internal/judge/callbacks"
+	JudgeCallbackSignatureQuery = "sig"
+	// JudgeCallbackKeyQuery identifies the result of the submission before
+	// its token is stored
+	JudgeCallbackKeyQuery = "key"
+	// JudgeCallbackNodeQuery is set to the judge node which runs the
+	// submission, the token of the callback is local to the node
+	JudgeCallbackNodeQuery = "node"
+)
+
+// JudgeCallbackSignature signs the callback of the submission with the secret.
+// Judge0 can not set headers of callbacks nor sign their bodies, so the URL
+// carries the signature of its solution, key and node instead of the secret
+// itself: a leaked URL is good only for the callback of one submission run by
+// one node.
+func JudgeCallbackSignature(secret string, solutionID string, key string, node string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(solutionID + "." + key + "." + node))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// JudgeCallbackURLWithNode sets the node to the callback URL of the solution
+// and signs the URL again, so the node can not be replaced.
+func JudgeCallbackURLWithNode(secret string, callbackURL string, node string) (string, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set(JudgeCallbackNodeQuery, node)
+	q.Set(JudgeCallbackSignatureQuery, JudgeCallbackSignature(secret, path.Base(u.Path), q.Get(JudgeCallbackKeyQuery), node))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// JudgeNodeToken returns token of the submission created on the judge node as
+// it is known outside of the judge.
+func JudgeNodeToken(node string, token string) string {
+	return node + "." + token
+}
+
 type LanguageType int
 
 const (
@@ -28,24 +97,31 @@ const (
 	TypeScript LanguageType = 2
 )
 
-var AvailableLanguageIds = []LanguageType{TypeScript, NodeJS}
-
 type CreateJudgeSubmission struct {
 	SourceCode     string       `json:"source_code"`
 	LanguageID     LanguageType `json:"language_id"`
 	Stdin          string       `json:"stdin"`
-	ExpectedOutput string       `json:"expected_output"`
+	ExpectedOutput string       `json:"expected_output,omitempty"`
 	CpuTimeLimit   float64      `json:"cpu_time_limit"`
-	MemoryLimit    int          `json:"memory_limit"`
+	// WallTimeLimit is the default of the judge when zero
+	WallTimeLimit float64 `json:"wall_time_limit,omitempty"`
+	MemoryLimit   int     `json:"memory_limit"`
+	// CompilerOptions and CommandLineArguments are taken from the language
+	// registry
+	CompilerOptions      string `json:"compiler_options,omitempty"`
+	CommandLineArguments string `json:"command_line_arguments,omitempty"`
+	// CallbackURL is called by the judge with the finished submission
+	CallbackURL string `json:"callback_url,omitempty"`
 }
 
 type JudgeSubmissionInfo struct {
-	Token  string      `json:"token"`
-	Stdout *string     `json:"stdout"`
-	Stderr *string     `json:"stderr"`
-	Time   float64     `json:"time"`
-	Memory int         `json:"memory"`
-	Status JudgeStatus `json:"status"`
+	Token         string      `json:"token"`
+	Stdout        *string     `json:"stdout"`
+	Stderr        *string     `json:"stderr"`
+	CompileOutput *string     `json:"compile_output"`
+	Time          float64     `json:"time"`
+	Memory        int         `json:"memory"`
+	Status        JudgeStatus `json:"status"`
 }
 
 type (
@@ -60,6 +136,31 @@ type (
 	}
 )
 
+// JudgeCallbackDTO is the finished submission sent by the judge node to the
+// callback of the solution.
+type JudgeCallbackDTO struct {
+	SolutionID string
+	Key        string
+	Node       string
+	Info       JudgeSubmissionInfo
+}
+
+// JudgeNodeStats describes one of judge servers: State of its circuit breaker,
+// requests being sent to it and submissions created on it and not finished
+// yet, counters of requests and failures since start.
+type JudgeNodeStats struct {
+	Name        string   `json:"name"`
+	Address     string   `json:"address"`
+	Weight      int      `json:"weight"`
+	State       string   `json:"state"`
+	InFlight    int      `json:"in_flight"`
+	Pending     int      `json:"pending"`
+	Requests    int64    `json:"requests"`
+	Failures    int64    `json:"failures"`
+	LastError   *string  `json:"last_error"`
+	LastErrorAt *IntTime `json:"last_error_at"`
+}
+
 // errors
 type JudgeQueueIsFullError struct {
 	struct_errors.BaseError
@@ -73,3 +174,17 @@ func NewJudgeQueueIsFullError() *JudgeQueueIsFullError {
 
 	return e
 }
+
+// JudgeUnavailableError is returned when the judge can not be reached, fails
+// with server errors after every retry or is skipped by the circuit breaker.
+type JudgeUnavailableError struct {
+	struct_errors.BaseError
+}
+
+func NewJudgeUnavailableError(err error) *JudgeUnavailableError {
+	e := &JudgeUnavailableError{}
+	e.SetCode("judge.unavailable")
+	e.SetErr("Code solving system is unavailable", err)
+
+	return e
+}
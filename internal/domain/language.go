@@ -0,0 +1,74 @@
+package domain
+
+type (
+	// Language is a judge language registered by admins. Only enabled
+	// languages are accepted in solutions, compiler options and command line
+	// arguments are passed to the judge with every submission. Code of
+	// compiled languages is compiled once before test cases are run.
+	Language struct {
+		ID                   LanguageType `json:"id" db:"id"`
+		Name                 string       `json:"name" db:"name"`
+		JudgeName            string       `json:"judge_name" db:"judge_name"`
+		EditorMode           string       `json:"editor_mode" db:"editor_mode"`
+		DefaultTemplate      string       `json:"default_template" db:"default_template"`
+		CompilerOptions      string       `json:"compiler_options" db:"compiler_options"`
+		CommandLineArguments string       `json:"command_line_arguments" db:"command_line_arguments"`
+		Enabled              bool         `json:"enabled" db:"enabled"`
+		Compiled             bool         `json:"compiled" db:"compiled"`
+		CreatedAt            IntTime      `json:"created_at" db:"created_at"`
+		UpdatedAt            IntTime      `json:"updated_at" db:"updated_at"`
+	}
+
+	// DiscoveredLanguage is a language reported by the judge with its state in
+	// the registry.
+	DiscoveredLanguage struct {
+		JudgeLanguageInfo
+		Registered bool `json:"registered"`
+		Enabled    bool `json:"enabled"`
+	}
+)
+
+type (
+	LanguageCreateInput struct {
+		ID                   LanguageType `json:"id"`
+		Name                 string       `json:"name"`
+		EditorMode           string       `json:"editor_mode"`
+		DefaultTemplate      string       `json:"default_template"`
+		CompilerOptions      string       `json:"compiler_options"`
+		CommandLineArguments string       `json:"command_line_arguments"`
+		Enabled              bool         `json:"enabled"`
+		Compiled             bool         `json:"compiled"`
+	}
+
+	LanguageUpdateInput struct {
+		Name                 *string `json:"name"`
+		EditorMode           *string `json:"editor_mode"`
+		DefaultTemplate      *string `json:"default_template"`
+		CompilerOptions      *string `json:"compiler_options"`
+		CommandLineArguments *string `json:"command_line_arguments"`
+		Enabled              *bool   `json:"enabled"`
+		Compiled             *bool   `json:"compiled"`
+	}
+)
+
+type (
+	CreateLanguageEntity struct {
+		LanguageCreateInput
+		JudgeName string
+	}
+)
+
+type (
+	LanguageCreateDTO struct {
+		Input LanguageCreateInput
+	}
+
+	LanguageUpdateDTO struct {
+		ID    LanguageType
+		Input LanguageUpdateInput
+	}
+
+	LanguagesDTO struct {
+		OnlyEnabled bool
+	}
+)
@@ -0,0 +1,83 @@
+package domain
+
+type PlagiarismCheckStatus string
+
+const (
+	PlagiarismCheckStatusRunning   PlagiarismCheckStatus = "running"
+	PlagiarismCheckStatusCompleted PlagiarismCheckStatus = "completed"
+	PlagiarismCheckStatusFailed    PlagiarismCheckStatus = "failed"
+)
+
+type (
+	// PlagiarismCheck compares the latest accepted solutions of every user of
+	// the task, pairs of solutions with similarity not less than Threshold
+	// percent are reported.
+	PlagiarismCheck struct {
+		ID             string                `json:"id" db:"id"`
+		TaskID         string                `json:"task_id" db:"task_id"`
+		AuthorID       string                `json:"author_id" db:"author_id"`
+		Status         PlagiarismCheckStatus `json:"status" db:"status"`
+		Threshold      float64               `json:"threshold" db:"threshold"`
+		SolutionsCount int                   `json:"solutions_count" db:"solutions_count"`
+		PairsCount     int                   `json:"pairs_count" db:"pairs_count"`
+		Error          *string               `json:"error" db:"error"`
+		CreatedAt      IntTime               `json:"created_at" db:"created_at"`
+		FinishedAt     *IntTime              `json:"finished_at" db:"finished_at"`
+		Pairs          []PlagiarismPair      `json:"pairs" db:"-"`
+	}
+
+	// PlagiarismPair is a suspicious pair of solutions, SimilarityA is a
+	// percentage of fingerprints of the solution A found in the solution B
+	// and vice versa, Similarity is the greater of them.
+	PlagiarismPair struct {
+		SolutionAID string            `json:"solution_a_id" db:"solution_a_id"`
+		UserAID     string            `json:"user_a_id" db:"user_a_id"`
+		SolutionBID string            `json:"solution_b_id" db:"solution_b_id"`
+		UserBID     string            `json:"user_b_id" db:"user_b_id"`
+		SimilarityA float64           `json:"similarity_a" db:"similarity_a"`
+		SimilarityB float64           `json:"similarity_b" db:"similarity_b"`
+		Similarity  float64           `json:"similarity" db:"similarity"`
+		Matches     []PlagiarismMatch `json:"matches" db:"matches"`
+	}
+
+	// PlagiarismMatch is a matched region, lines are counted from 1.
+	PlagiarismMatch struct {
+		AStartLine int `json:"a_start_line"`
+		AEndLine   int `json:"a_end_line"`
+		BStartLine int `json:"b_start_line"`
+		BEndLine   int `json:"b_end_line"`
+	}
+)
+
+type (
+	CreatePlagiarismCheckEntity struct {
+		TaskID    string
+		AuthorID  string
+		Threshold float64
+	}
+
+	FinishPlagiarismCheckEntity struct {
+		ID             string
+		Status         PlagiarismCheckStatus
+		SolutionsCount int
+		Error          *string
+	}
+)
+
+type (
+	PlagiarismCheckInput struct {
+		// Threshold is taken from config when it is not set
+		Threshold *float64 `json:"threshold"`
+	}
+
+	CheckPlagiarismDTO struct {
+		TaskID string
+		User   User
+		Input  PlagiarismCheckInput
+	}
+
+	GetPlagiarismCheckDTO struct {
+		TaskID  string
+		CheckID string
+	}
+)
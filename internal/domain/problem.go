@@ -27,5 +27,6 @@ type (
 
 	GetProblemDTO struct {
 		TaskID string
+		User   User
 	}
 )
@@ -0,0 +1,76 @@
+package domain
+
+// RejudgeStatuses are statuses of solutions which can be judged again,
+// solutions in testing status are already waiting for a verdict.
+var RejudgeStatuses = []SolutionStatus{
+	SolutionStatusCompleted,
+	SolutionStatusError,
+	SolutionStatusCancelled,
+	SolutionStatusCompilationError,
+}
+
+// DefaultRejudgeStatuses are used when the filter by status is not set.
+var DefaultRejudgeStatuses = []SolutionStatus{
+	SolutionStatusCompleted,
+	SolutionStatusError,
+	SolutionStatusCompilationError,
+}
+
+type (
+	RejudgeFilter struct {
+		Statuses    []SolutionStatus `json:"statuses" db:"statuses"`
+		LanguageID  *LanguageType    `json:"language_id" db:"language_id"`
+		CreatedFrom *IntTime         `json:"created_from" db:"created_from"`
+		CreatedTo   *IntTime         `json:"created_to" db:"created_to"`
+	}
+
+	Rejudge struct {
+		ID        string  `json:"id" db:"id"`
+		TaskID    string  `json:"task_id" db:"task_id"`
+		AuthorID  string  `json:"author_id" db:"author_id"`
+		CreatedAt IntTime `json:"created_at" db:"created_at"`
+		RejudgeFilter
+		Summary RejudgeSummary `json:"summary" db:"-"`
+	}
+
+	// RejudgeSummary reports progress of the rejudge and how verdicts of
+	// finished solutions have changed.
+	RejudgeSummary struct {
+		Total    int  `json:"total" db:"total"`
+		Finished int  `json:"finished" db:"finished"`
+		Done     bool `json:"done" db:"-"`
+		// Changed solutions got another status or score, Improved and
+		// Worsened are counted by score
+		Changed     int                 `json:"changed" db:"changed"`
+		Improved    int                 `json:"improved" db:"improved"`
+		Worsened    int                 `json:"worsened" db:"worsened"`
+		Transitions []RejudgeTransition `json:"transitions" db:"-"`
+	}
+
+	RejudgeTransition struct {
+		From  SolutionStatus `json:"from" db:"old_status"`
+		To    SolutionStatus `json:"to" db:"new_status"`
+		Count int            `json:"count" db:"count"`
+	}
+)
+
+type (
+	CreateRejudgeEntity struct {
+		TaskID   string
+		AuthorID string
+		Filter   RejudgeFilter
+	}
+)
+
+type (
+	RejudgeTaskDTO struct {
+		TaskID string
+		User   User
+		Filter RejudgeFilter
+	}
+
+	GetRejudgeDTO struct {
+		TaskID    string
+		RejudgeID string
+	}
+)
@@ -1,11 +1,41 @@
 package domain
 
+import "lcode/pkg/db"
+
 type SolutionStatus string
 
 const (
 	SolutionStatusTesting   SolutionStatus = "testing"
 	SolutionStatusCompleted SolutionStatus = "completed"
 	SolutionStatusError     SolutionStatus = "error"
+	SolutionStatusCancelled SolutionStatus = "cancelled"
+	// SolutionStatusCompilationError is set when the code does not compile,
+	// test cases are not run then
+	SolutionStatusCompilationError SolutionStatus = "compilation_error"
+)
+
+var SolutionStatuses = []SolutionStatus{
+	SolutionStatusTesting,
+	SolutionStatusCompleted,
+	SolutionStatusError,
+	SolutionStatusCancelled,
+	SolutionStatusCompilationError,
+}
+
+// SolutionErrorReason explains why the solution got error status without a
+// verdict of the judge.
+type SolutionErrorReason string
+
+const (
+	SolutionErrorReasonJudgeUnavailable SolutionErrorReason = "judge_unavailable"
+	SolutionErrorReasonJudgeOverloaded  SolutionErrorReason = "judge_overloaded"
+	SolutionErrorReasonAttemptsExceeded SolutionErrorReason = "attempts_exceeded"
+	SolutionErrorReasonNoTemplate       SolutionErrorReason = "no_template"
+	SolutionErrorReasonInternal         SolutionErrorReason = "internal"
+	// SolutionErrorReasonWorkerPanic is set when judging of the solution
+	// crashed, SolutionErrorReasonJudgingTimeout when it got stuck every attempt
+	SolutionErrorReasonWorkerPanic    SolutionErrorReason = "worker_panic"
+	SolutionErrorReasonJudgingTimeout SolutionErrorReason = "judging_timeout"
 )
 
 type Solution struct {
@@ -17,6 +47,45 @@ type Solution struct {
 	Status     SolutionStatus `json:"status" db:"status"`
 	Runtime    float64        `json:"runtime" db:"runtime"`
 	Memory     int            `json:"memory" db:"memory"`
+	// PassedCount of TotalCount test cases were accepted, Score is their
+	// percentage
+	PassedCount int     `json:"passed_count" db:"passed_count"`
+	TotalCount  int     `json:"total_count" db:"total_count"`
+	Score       float64 `json:"score" db:"score"`
+	CreatedAt   IntTime `json:"created_at" db:"created_at"`
+	// ErrorReason is set when judging failed for reasons other than the code
+	ErrorReason *SolutionErrorReason `json:"error_reason" db:"error_reason"`
+	// CompileOutput is the compiler output when the code does not compile
+	CompileOutput *string `json:"compile_output" db:"compile_output"`
+	// RuntimePercentile and MemoryPercentile are percentages of other accepted
+	// solutions of the task in the same language which are slower and use
+	// more memory, they are stored on refresh of solution stats
+	RuntimePercentile *float64 `json:"runtime_percentile" db:"runtime_percentile"`
+	MemoryPercentile  *float64 `json:"memory_percentile" db:"memory_percentile"`
+}
+
+type SolutionList struct {
+	Solutions  []Solution   `json:"solutions"`
+	Pagination IdPagination `json:"pagination"`
+}
+
+// SolutionFilter narrows the submission history, empty fields match every
+// solution. CreatedTo is exclusive.
+type SolutionFilter struct {
+	UserID      *string
+	TaskID      *string
+	LanguageID  *LanguageType
+	Statuses    []SolutionStatus
+	CreatedFrom *IntTime
+	CreatedTo   *IntTime
+}
+
+// SolutionParams orders solutions by creation time, newest first unless Sort
+// is ascending.
+type SolutionParams struct {
+	Filter     SolutionFilter
+	Sort       db.SortType
+	Pagination IdPaginationParams
 }
 
 // entity
@@ -25,6 +94,7 @@ type CreateSolutionEntity struct {
 	LanguageID LanguageType
 	Code       string
 	Status     SolutionStatus
+	Validation bool
 	User       User
 }
 
@@ -38,6 +108,9 @@ type CreateSolutionDTO struct {
 	TaskID     string
 	LanguageID LanguageType
 	Code       string
+	// Validation marks a check of the task by admin, e.g. of the reference
+	// solution or the checker
+	Validation bool
 	User       User
 }
 
@@ -46,6 +119,11 @@ type GetSolutionsDTO struct {
 	User   User
 }
 
+type GetSolutionListDTO struct {
+	Params SolutionParams
+	User   User
+}
+
 type GetSolutionCodeDTO struct {
 	SolutionID string
 	User       User
@@ -60,8 +138,14 @@ func (d GetSolutionCodeDTO) GetUser() User {
 }
 
 type UpdateSolutionDTO struct {
-	ID      string
-	Status  *SolutionStatus
-	Runtime *float64
-	Memory  *int
+	ID          string
+	Status      *SolutionStatus
+	Runtime     *float64
+	Memory      *int
+	PassedCount *int
+	TotalCount  *int
+	Score       *float64
+	// ErrorReason and CompileOutput equal to "" are cleared
+	ErrorReason   *SolutionErrorReason
+	CompileOutput *string
 }
@@ -0,0 +1,46 @@
+package domain
+
+type SolutionEventType string
+
+const (
+	SolutionEventTestCase SolutionEventType = "test_case"
+	SolutionEventFinished SolutionEventType = "finished"
+)
+
+type (
+	SolutionEvent struct {
+		Type     SolutionEventType      `json:"type"`
+		TestCase *SolutionTestCaseEvent `json:"test_case,omitempty"`
+		Solution *Solution              `json:"solution,omitempty"`
+	}
+
+	SolutionTestCaseEvent struct {
+		Number     int         `json:"number"`
+		TestCaseID string      `json:"test_case_id"`
+		Status     JudgeStatus `json:"status"`
+		Runtime    float64     `json:"runtime"`
+		Memory     int         `json:"memory"`
+	}
+)
+
+type GetSolutionEventsDTO struct {
+	SolutionID string
+	User       User
+}
+
+func (d GetSolutionEventsDTO) GetSolutionID() string {
+	return d.SolutionID
+}
+
+func (d GetSolutionEventsDTO) GetUser() User {
+	return d.User
+}
+
+// SolutionEventNotification carries the event of the solution to every
+// instance, subscribers of the solution may be connected to any of them. The
+// solution of the finished event is not sent, notifications are limited in
+// size, subscribers read it themselves.
+type SolutionEventNotification struct {
+	SolutionID string        `json:"solution_id"`
+	Event      SolutionEvent `json:"event"`
+}
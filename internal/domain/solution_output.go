@@ -0,0 +1,42 @@
+package domain
+
+type OutputStream string
+
+const (
+	OutputStreamStdout OutputStream = "stdout"
+	OutputStreamStderr OutputStream = "stderr"
+)
+
+// SolutionOutput is the full output of the test case which is truncated in
+// its result, it is kept for download for a while.
+type SolutionOutput struct {
+	SolutionID string
+	TestCaseID string
+	Stdout     *string
+	Stderr     *string
+}
+
+// entity
+type SolutionOutputEntity struct {
+	SolutionID string `db:"solution_id"`
+	TestCaseID string `db:"test_case_id"`
+	Stdout     []byte `db:"stdout"`
+	Stderr     []byte `db:"stderr"`
+	Compressed bool   `db:"compressed"`
+}
+
+// dto
+type GetSolutionOutputDTO struct {
+	SolutionID string
+	TestCaseID string
+	Stream     OutputStream
+	User       User
+}
+
+func (d GetSolutionOutputDTO) GetSolutionID() string {
+	return d.SolutionID
+}
+
+func (d GetSolutionOutputDTO) GetUser() User {
+	return d.User
+}
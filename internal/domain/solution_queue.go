@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"fmt"
+	"lcode/pkg/struct_errors"
+)
+
+// SolutionQueueLane separates solutions of different origin in the queue.
+// Lanes are served by strict priority, inside a lane users are served in
+// round-robin order.
+type SolutionQueueLane string
+
+const (
+	// SolutionQueueLaneValidation is for validation runs of admins checking
+	// reference solutions and checkers of tasks
+	SolutionQueueLaneValidation SolutionQueueLane = "validation"
+	SolutionQueueLaneUser       SolutionQueueLane = "user"
+	// SolutionQueueLaneRejudge is served only when other lanes are empty
+	SolutionQueueLaneRejudge SolutionQueueLane = "rejudge"
+)
+
+// SolutionQueueLanes lists lanes from the highest priority to the lowest.
+var SolutionQueueLanes = []SolutionQueueLane{
+	SolutionQueueLaneValidation,
+	SolutionQueueLaneUser,
+	SolutionQueueLaneRejudge,
+}
+
+type (
+	SolutionQueueItem struct {
+		SolutionID string            `json:"solution_id" db:"solution_id"`
+		UserID     string            `json:"user_id" db:"user_id"`
+		Lane       SolutionQueueLane `json:"lane" db:"lane"`
+		Attempts   int               `json:"attempts" db:"attempts"`
+		ClaimedAt  *IntTime          `json:"claimed_at" db:"claimed_at"`
+		CreatedAt  IntTime           `json:"created_at" db:"created_at"`
+		// TestCaseIDs are test cases of the task pinned when the solution was
+		// enqueued, nil for items enqueued before pinning
+		TestCaseIDs []string `json:"-" db:"test_case_ids"`
+	}
+
+	QueuedSolution struct {
+		SolutionQueueItem
+		TaskID     string       `json:"task_id" db:"task_id"`
+		LanguageID LanguageType `json:"language_id" db:"language_id"`
+	}
+
+	QueuedSolutionList struct {
+		Solutions  []QueuedSolution `json:"solutions"`
+		Pagination IdPagination     `json:"pagination"`
+	}
+
+	SolutionQueueCounts struct {
+		Waiting int `json:"waiting" db:"waiting"`
+		Claimed int `json:"claimed" db:"claimed"`
+	}
+
+	JudgeWorkerState struct {
+		ID         int      `json:"id"`
+		State      string   `json:"state"`
+		SolutionID *string  `json:"solution_id"`
+		StartedAt  *IntTime `json:"started_at"`
+	}
+
+	SolutionQueueStats struct {
+		Depth        int                `json:"depth"`
+		Claimed      int                `json:"claimed"`
+		InFlight     []string           `json:"in_flight"`
+		WorkersCount int                `json:"workers_count"`
+		Workers      []JudgeWorkerState `json:"workers"`
+		Watchdog     WatchdogStats      `json:"watchdog"`
+		Judges       []JudgeNodeStats   `json:"judges"`
+	}
+
+	// WatchdogStats count events since start: panics of workers, stuck
+	// solutions judged again or failed and orphaned solutions in testing
+	// status pushed back to the queue.
+	WatchdogStats struct {
+		Panics   int64 `json:"panics"`
+		Retried  int64 `json:"retried"`
+		TimedOut int64 `json:"timed_out"`
+		Orphaned int64 `json:"orphaned"`
+	}
+)
+
+type (
+	PushSolutionQueueEntity struct {
+		SolutionID string
+		UserID     string
+		Lane       SolutionQueueLane
+	}
+)
+
+type (
+	GetQueuedSolutionsDTO struct {
+		Pagination IdPaginationParams
+	}
+
+	CancelQueuedSolutionDTO struct {
+		SolutionID string
+	}
+
+	ResizeWorkerPoolDTO struct {
+		Count int `json:"count"`
+	}
+)
+
+// errors
+type SolutionQueueIsFullError struct {
+	struct_errors.BaseError
+}
+
+func NewSolutionQueueIsFullError() *SolutionQueueIsFullError {
+	e := &SolutionQueueIsFullError{}
+	e.SetCode("solution.queue_is_full")
+	e.SetErr("Solution queue is full", nil)
+
+	return e
+}
+
+type SolutionUserQueueLimitError struct {
+	struct_errors.BaseError
+	Limit int `json:"limit"`
+}
+
+func NewSolutionUserQueueLimitError(limit int) *SolutionUserQueueLimitError {
+	e := &SolutionUserQueueLimitError{Limit: limit}
+	e.SetCode("solution.user_queue_limit")
+	e.SetErr(fmt.Sprintf("You can not have more than %d solutions waiting for judging", limit), nil)
+
+	return e
+}
@@ -9,6 +9,27 @@ type SolutionResult struct {
 	Memory          int         `json:"memory" db:"memory"`
 	Stdout          *string     `json:"stdout" db:"stdout"`
 	Stderr          *string     `json:"stderr" db:"stderr"`
+	// StdoutTruncated and StderrTruncated are set when the output is longer
+	// than the stored one, the full output is downloaded apart for a while
+	StdoutTruncated bool `json:"stdout_truncated" db:"stdout_truncated"`
+	StderrTruncated bool `json:"stderr_truncated" db:"stderr_truncated"`
+	// Input and Visibility are taken from the test case, input of file backed
+	// test cases is read from the file
+	Input      *string            `json:"input" db:"input"`
+	Visibility TestCaseVisibility `json:"visibility" db:"visibility"`
+	TaskID     string             `json:"-" db:"task_id"`
+	FileBacked bool               `json:"-" db:"file_backed"`
+}
+
+// Redact hides data which reveals the hidden test case.
+func (r *SolutionResult) Redact() {
+	if r.Visibility == TestCaseVisibilitySample {
+		return
+	}
+
+	r.Input = nil
+	r.Stdout = nil
+	r.Stderr = nil
 }
 
 type GetSolutionResultsDTO struct {
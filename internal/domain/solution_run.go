@@ -0,0 +1,34 @@
+package domain
+
+import "lcode/pkg/struct_errors"
+
+type RunResult struct {
+	Stdin         string      `json:"stdin"`
+	Stdout        *string     `json:"stdout"`
+	Stderr        *string     `json:"stderr"`
+	CompileOutput *string     `json:"compile_output"`
+	Time          float64     `json:"time"`
+	Memory        int         `json:"memory"`
+	Status        JudgeStatus `json:"status"`
+}
+
+type RunSolutionDTO struct {
+	TaskID     string
+	LanguageID LanguageType
+	Code       string
+	Inputs     []string
+	User       User
+}
+
+// errors
+type RunRateLimitError struct {
+	struct_errors.BaseError
+}
+
+func NewRunRateLimitError() *RunRateLimitError {
+	e := &RunRateLimitError{}
+	e.SetCode("solution.run_rate_limit")
+	e.SetErr("Too many runs, try again later", nil)
+
+	return e
+}
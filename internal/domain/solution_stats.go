@@ -0,0 +1,70 @@
+package domain
+
+type SolutionMetric string
+
+const (
+	SolutionMetricRuntime SolutionMetric = "runtime"
+	SolutionMetricMemory  SolutionMetric = "memory"
+)
+
+// SolutionStatsQuantiles is a number of quantiles stored by metric: values at
+// 0%, 1%, ..., 100% of accepted solutions.
+const SolutionStatsQuantiles = 101
+
+type (
+	// SolutionStats aggregate a metric of accepted solutions of the task in
+	// one language, they are refreshed periodically.
+	SolutionStats struct {
+		TaskID        string         `db:"task_id"`
+		LanguageID    LanguageType   `db:"language_id"`
+		Metric        SolutionMetric `db:"metric"`
+		AcceptedCount int            `db:"accepted_count"`
+		MinValue      float64        `db:"min_value"`
+		MaxValue      float64        `db:"max_value"`
+		Quantiles     []float64      `db:"quantiles"`
+		// Histogram counts solutions in buckets of equal width from MinValue
+		// to MaxValue
+		Histogram   []int   `db:"histogram"`
+		RefreshedAt IntTime `db:"refreshed_at"`
+	}
+
+	// SolutionRanking compares the accepted solution with other accepted
+	// solutions of the task in the same language.
+	SolutionRanking struct {
+		SolutionID    string        `json:"solution_id"`
+		TaskID        string        `json:"task_id"`
+		LanguageID    LanguageType  `json:"language_id"`
+		AcceptedCount int           `json:"accepted_count"`
+		Runtime       MetricRanking `json:"runtime"`
+		Memory        MetricRanking `json:"memory"`
+		// RefreshedAt is nil when stats were not computed yet
+		RefreshedAt *IntTime `json:"refreshed_at"`
+	}
+
+	MetricRanking struct {
+		Value float64 `json:"value"`
+		// Percentile is a percentage of other solutions with greater value,
+		// nil when there are no stats yet
+		Percentile *float64          `json:"percentile"`
+		Histogram  []HistogramBucket `json:"histogram"`
+	}
+
+	HistogramBucket struct {
+		From  float64 `json:"from"`
+		To    float64 `json:"to"`
+		Count int     `json:"count"`
+	}
+)
+
+type GetSolutionRankingDTO struct {
+	SolutionID string
+	User       User
+}
+
+func (d GetSolutionRankingDTO) GetSolutionID() string {
+	return d.SolutionID
+}
+
+func (d GetSolutionRankingDTO) GetUser() User {
+	return d.User
+}
@@ -2,6 +2,37 @@ package domain
 
 import "lcode/pkg/db"
 
+// CheckerType defines how output of a solution is compared with the expected
+// output of a test case.
+type CheckerType string
+
+const (
+	// CheckerExact compares outputs ignoring trailing whitespace
+	CheckerExact CheckerType = "exact"
+	// CheckerWhitespace compares outputs line by line ignoring amount of
+	// whitespace between tokens and empty lines at the end
+	CheckerWhitespace CheckerType = "whitespace"
+	// CheckerTokens compares sequences of whitespace separated tokens
+	CheckerTokens CheckerType = "tokens"
+	// CheckerFloat compares tokens, numbers are equal within CheckerEpsilon
+	CheckerFloat CheckerType = "float"
+	// CheckerUnorderedLines compares outputs as sets of lines
+	CheckerUnorderedLines CheckerType = "unordered_lines"
+	// CheckerProgram runs CheckerCode in the judge. The program gets JSON
+	// object with "input", "expected" and "output" on stdin and must exit
+	// with code 0 if the output is correct and with non-zero code otherwise.
+	CheckerProgram CheckerType = "program"
+)
+
+var AvailableCheckerTypes = []CheckerType{
+	CheckerExact,
+	CheckerWhitespace,
+	CheckerTokens,
+	CheckerFloat,
+	CheckerUnorderedLines,
+	CheckerProgram,
+}
+
 type (
 	Task struct {
 		ID           string  `json:"id" db:"id"`
@@ -12,6 +43,13 @@ type (
 		Difficulty   string  `json:"difficulty" db:"difficulty"`
 		RuntimeLimit float64 `json:"runtime_limit" db:"runtime_limit"`
 		MemoryLimit  int     `json:"memory_limit" db:"memory_limit"`
+		// FullReport makes solutions run on every test case instead of
+		// stopping at the first failed one
+		FullReport        bool         `json:"full_report" db:"full_report"`
+		CheckerType       CheckerType  `json:"checker_type" db:"checker_type"`
+		CheckerEpsilon    float64      `json:"checker_epsilon" db:"checker_epsilon"`
+		CheckerCode       string       `json:"checker_code,omitempty" db:"checker_code"`
+		CheckerLanguageID LanguageType `json:"checker_language_id,omitempty" db:"checker_language_id"`
 	}
 
 	TaskList struct {
@@ -52,6 +90,12 @@ type (
 		Difficulty   string  `json:"difficulty" db:"difficulty"`
 		RuntimeLimit float64 `json:"runtime_limit" db:"runtime_limit"`
 		MemoryLimit  int     `json:"memory_limit" db:"memory_limit"`
+		FullReport   bool    `json:"full_report" db:"full_report"`
+
+		CheckerType       CheckerType  `json:"checker_type" db:"checker_type"`
+		CheckerEpsilon    float64      `json:"checker_epsilon" db:"checker_epsilon"`
+		CheckerCode       string       `json:"checker_code" db:"checker_code"`
+		CheckerLanguageID LanguageType `json:"checker_language_id" db:"checker_language_id"`
 	}
 
 	TaskUpdateInput struct {
@@ -61,6 +105,12 @@ type (
 		Difficulty   *string `json:"difficulty" db:"difficulty"`
 		RuntimeLimit *string `json:"runtime_limit" db:"runtime_limit"`
 		MemoryLimit  *string `json:"memory_limit" db:"memory_limit"`
+		FullReport   *bool   `json:"full_report" db:"full_report"`
+
+		CheckerType       *CheckerType  `json:"checker_type" db:"checker_type"`
+		CheckerEpsilon    *float64      `json:"checker_epsilon" db:"checker_epsilon"`
+		CheckerCode       *string       `json:"checker_code" db:"checker_code"`
+		CheckerLanguageID *LanguageType `json:"checker_language_id" db:"checker_language_id"`
 	}
 
 	TaskParamsInput struct {
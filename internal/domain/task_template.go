@@ -7,19 +7,36 @@ type (
 		LanguageID LanguageType `json:"language_id" db:"language_id"`
 		Template   string       `json:"template" db:"template"`
 		Wrapper    string       `json:"wrapper" db:"wrapper"`
+		// RuntimeLimit and MemoryLimit override limits of the task for the
+		// language, without them limits of the task are multiplied by the
+		// language multipliers from config
+		RuntimeLimit *float64 `json:"runtime_limit" db:"runtime_limit"`
+		MemoryLimit  *int     `json:"memory_limit" db:"memory_limit"`
+		// Limits are applied to solutions in the language
+		Limits TaskTemplateLimits `json:"limits" db:"-"`
+	}
+
+	TaskTemplateLimits struct {
+		RuntimeLimit float64 `json:"runtime_limit"`
+		MemoryLimit  int     `json:"memory_limit"`
 	}
 )
 
 type (
 	TaskTemplateCreateInput struct {
-		LanguageID LanguageType `json:"language_id" db:"language_id"`
-		Template   string       `json:"template" db:"template"`
-		Wrapper    string       `json:"wrapper" db:"wrapper"`
+		LanguageID   LanguageType `json:"language_id" db:"language_id"`
+		Template     string       `json:"template" db:"template"`
+		Wrapper      string       `json:"wrapper" db:"wrapper"`
+		RuntimeLimit *float64     `json:"runtime_limit" db:"runtime_limit"`
+		MemoryLimit  *int         `json:"memory_limit" db:"memory_limit"`
 	}
 
+	// TaskTemplateUpdateInput limits equal to 0 remove the override.
 	TaskTemplateUpdateInput struct {
-		Template *string `json:"template" db:"template"`
-		Wrapper  *string `json:"wrapper" db:"wrapper"`
+		Template     *string  `json:"template" db:"template"`
+		Wrapper      *string  `json:"wrapper" db:"wrapper"`
+		RuntimeLimit *float64 `json:"runtime_limit" db:"runtime_limit"`
+		MemoryLimit  *int     `json:"memory_limit" db:"memory_limit"`
 	}
 )
 
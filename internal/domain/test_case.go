@@ -1,24 +1,56 @@
 package domain
 
+import "io"
+
+const (
+	TasksFolder     = "tasks"
+	TestCasesFolder = "test_cases"
+
+	TestCaseInputExtension  = "in"
+	TestCaseOutputExtension = "out"
+)
+
+// TestCaseVisibility controls whether users can see the test case. Sample
+// test cases are shown with the problem, input and output of hidden test
+// cases are known only to admins.
+type TestCaseVisibility string
+
+const (
+	TestCaseVisibilitySample TestCaseVisibility = "sample"
+	TestCaseVisibilityHidden TestCaseVisibility = "hidden"
+)
+
+func (v TestCaseVisibility) IsValid() bool {
+	return v == TestCaseVisibilitySample || v == TestCaseVisibilityHidden
+}
+
 type (
 	TestCase struct {
-		ID     string `json:"id" db:"id"`
-		Number string `json:"number" db:"number"`
-		TaskID string `json:"task_id" db:"task_id"`
-		Input  string `json:"input" db:"input"`
-		Output string `json:"output" db:"output"`
+		ID         string             `json:"id" db:"id"`
+		Number     string             `json:"number" db:"number"`
+		TaskID     string             `json:"task_id" db:"task_id"`
+		Input      string             `json:"input" db:"input"`
+		Output     string             `json:"output" db:"output"`
+		Visibility TestCaseVisibility `json:"visibility" db:"visibility"`
+		// FileBacked test cases keep input and output in files of the task,
+		// Input and Output are empty till they are loaded for judging
+		FileBacked bool  `json:"file_backed" db:"file_backed"`
+		InputSize  int64 `json:"input_size" db:"input_size"`
+		OutputSize int64 `json:"output_size" db:"output_size"`
 	}
 )
 
 type (
 	TestCaseCreateInput struct {
-		Input  string `json:"input" db:"input"`
-		Output string `json:"output" db:"output"`
+		Input      string             `json:"input" db:"input"`
+		Output     string             `json:"output" db:"output"`
+		Visibility TestCaseVisibility `json:"visibility" db:"visibility"`
 	}
 
 	TestCaseUpdateInput struct {
-		Input  *string `json:"input" db:"input"`
-		Output *string `json:"output" db:"output"`
+		Input      *string             `json:"input" db:"input"`
+		Output     *string             `json:"output" db:"output"`
+		Visibility *TestCaseVisibility `json:"visibility" db:"visibility"`
 	}
 )
 
@@ -38,3 +70,32 @@ type (
 		CaseID string
 	}
 )
+
+type (
+	// TestCaseArchiveInput lists numbers of test cases of the archive which
+	// are samples, the others are hidden
+	TestCaseArchiveInput struct {
+		Samples []int `json:"samples"`
+	}
+
+	// TestCaseArchiveUploadDTO replaces test cases of the task with pairs
+	// of 1.in and 1.out files of the zip archive
+	TestCaseArchiveUploadDTO struct {
+		TaskID  string
+		Input   TestCaseArchiveInput
+		Archive io.Reader
+	}
+
+	TestCaseFileCreateInput struct {
+		Visibility TestCaseVisibility
+		InputSize  int64
+		OutputSize int64
+	}
+
+	TestCaseFiles struct {
+		TaskID string
+		CaseID string
+		Input  io.Reader
+		Output io.Reader
+	}
+)
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"lcode/config"
+	"lcode/internal/domain"
+	healthManager "lcode/internal/manager/health_manager"
+	"log/slog"
+	"net/http"
+)
+
+type (
+	Managers struct {
+		Health healthManager.HealthManager
+	}
+
+	Handler struct {
+		config   *config.Config
+		logger   *slog.Logger
+		managers *Managers
+	}
+)
+
+func New(cfg *config.Config, logger *slog.Logger, managers *Managers) *Handler {
+	return &Handler{
+		config:   cfg,
+		logger:   logger,
+		managers: managers,
+	}
+}
+
+func (h *Handler) Register(httpServer *gin.Engine) {
+	healthGroup := httpServer.Group("/health")
+	{
+		healthGroup.GET(
+			"/live",
+			h.live,
+		)
+
+		healthGroup.GET(
+			"/ready",
+			h.ready,
+		)
+	}
+}
+
+func (h *Handler) live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": domain.ComponentStatusUp})
+}
+
+// ready answers 200 in degraded mode too: the API still serves problems while
+// the judge is down, only the database is required.
+func (h *Handler) ready(c *gin.Context) {
+	r := h.managers.Health.Readiness(c.Request.Context())
+
+	if r.Status == domain.ReadinessStatusNotReady {
+		c.JSON(http.StatusServiceUnavailable, r)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
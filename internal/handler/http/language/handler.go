@@ -0,0 +1,157 @@
+package language
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	accessMiddleware "lcode/internal/handler/middleware/access"
+	authMiddleware "lcode/internal/handler/middleware/auth"
+	languageMiddleware "lcode/internal/handler/middleware/language"
+	languageManager "lcode/internal/manager/language_manager"
+	"lcode/pkg/gin_helpers"
+	"lcode/pkg/http_lib/http_helper"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"net/http"
+)
+
+type (
+	Middlewares struct {
+		Auth     *authMiddleware.Middleware
+		Access   *accessMiddleware.Middleware
+		Language *languageMiddleware.Middleware
+	}
+
+	Managers struct {
+		Language languageManager.LanguageManager
+	}
+
+	Handler struct {
+		config   *config.Config
+		logger   *slog.Logger
+		managers *Managers
+	}
+)
+
+func New(cfg *config.Config, logger *slog.Logger, managers *Managers) *Handler {
+	return &Handler{
+		config:   cfg,
+		logger:   logger,
+		managers: managers,
+	}
+}
+
+func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
+	languageGroup := httpServer.Group(
+		"/languages",
+		middlewares.Access.UserIdentity,
+		middlewares.Auth.CheckAdminAccess,
+	)
+	{
+		languageGroup.GET(
+			"",
+			h.getLanguages,
+		)
+		languageGroup.GET(
+			"/discovered",
+			h.getDiscoveredLanguages,
+		)
+		languageGroup.POST(
+			"",
+			middlewares.Language.ValidateCreateLanguageInput,
+			h.createLanguage,
+		)
+		languageGroup.PATCH(
+			"/:language_id",
+			middlewares.Language.ValidateUpdateLanguageInput,
+			h.updateLanguage,
+		)
+	}
+}
+
+func (h *Handler) getLanguages(c *gin.Context) {
+	ls, err := h.managers.Language.Languages(c.Request.Context(), domain.LanguagesDTO{})
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, ls)
+}
+
+func (h *Handler) getDiscoveredLanguages(c *gin.Context) {
+	ls, err := h.managers.Language.DiscoveredLanguages(c.Request.Context())
+	if err != nil {
+		var judgeUnavailableErr *domain.JudgeUnavailableError
+		if errors.As(err, &judgeUnavailableErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeUnavailableErr.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, ls)
+}
+
+func (h *Handler) createLanguage(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.LanguageCreateDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	l, err := h.managers.Language.CreateLanguage(c.Request.Context(), dto)
+	if err != nil {
+		var errExist *struct_errors.ErrExist
+		if errors.As(err, &errExist) {
+			http_helper.NewErrorResponse(c, http.StatusConflict, errExist.Msg)
+
+			return
+		}
+
+		var judgeUnavailableErr *domain.JudgeUnavailableError
+		if errors.As(err, &judgeUnavailableErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeUnavailableErr.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, l)
+}
+
+func (h *Handler) updateLanguage(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.LanguageUpdateDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	l, err := h.managers.Language.UpdateLanguage(c.Request.Context(), dto)
+	if err != nil {
+		var errNotFound *struct_errors.ErrNotFound
+		if errors.As(err, &errNotFound) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, errNotFound.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, l)
+}
@@ -8,7 +8,9 @@ import (
 	accessMiddleware "lcode/internal/handler/middleware/access"
 	authMiddleware "lcode/internal/handler/middleware/auth"
 	problemMiddleware "lcode/internal/handler/middleware/problem"
+	plagiarismManager "lcode/internal/manager/plagiarism_manager"
 	problemManager "lcode/internal/manager/problem_manager"
+	solutionManager "lcode/internal/manager/solution_manager"
 	"lcode/pkg/gin_helpers"
 	"lcode/pkg/http_lib/http_helper"
 	"lcode/pkg/struct_errors"
@@ -24,7 +26,9 @@ type (
 	}
 
 	Managers struct {
-		Problem problemManager.ProblemManager
+		Problem    problemManager.ProblemManager
+		Solution   solutionManager.SolutionManager
+		Plagiarism plagiarismManager.PlagiarismManager
 	}
 
 	Handler struct {
@@ -109,6 +113,11 @@ func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
 				middlewares.Problem.ValidateCreateProblemTestCaseInput,
 				h.createProblemTestCase,
 			)
+			testCaseGroup.PUT(
+				"/archive",
+				middlewares.Problem.ValidateReplaceProblemTestCasesInput,
+				h.replaceProblemTestCases,
+			)
 			testCaseGroup.PATCH(
 				"/:case_id",
 				middlewares.Problem.ValidateUpdateProblemTestCaseInput,
@@ -120,6 +129,34 @@ func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
 				h.deleteProblemTestCase,
 			)
 		}
+
+		rejudgeGroup := problemGroup.Group("/:task_id/rejudge", middlewares.Auth.CheckAdminAccess)
+		{
+			rejudgeGroup.POST(
+				"",
+				middlewares.Problem.ValidateRejudgeTaskInput,
+				h.rejudgeTask,
+			)
+			rejudgeGroup.GET(
+				"/:rejudge_id",
+				middlewares.Problem.ValidateGetRejudgeInput,
+				h.rejudge,
+			)
+		}
+
+		plagiarismGroup := problemGroup.Group("/:task_id/plagiarism", middlewares.Auth.CheckAdminAccess)
+		{
+			plagiarismGroup.POST(
+				"",
+				middlewares.Problem.ValidateCheckPlagiarismInput,
+				h.checkPlagiarism,
+			)
+			plagiarismGroup.GET(
+				"/:check_id",
+				middlewares.Problem.ValidateGetPlagiarismCheckInput,
+				h.plagiarismCheck,
+			)
+		}
 	}
 }
 
@@ -257,6 +294,24 @@ func (h *Handler) createProblemTestCase(c *gin.Context) {
 	c.JSON(http.StatusCreated, problem)
 }
 
+func (h *Handler) replaceProblemTestCases(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.TestCaseArchiveUploadDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	problem, err := h.managers.Problem.ReplaceProblemTestCases(c.Request.Context(), dto)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
 func (h *Handler) updateProblemTestCase(c *gin.Context) {
 	dto, err := gin_helpers.GetValueFromGinCtx[domain.TestCaseUpdateDTO](c, domain.DtoCtxKey)
 	if err != nil {
@@ -301,7 +356,7 @@ func (h *Handler) getProblem(c *gin.Context) {
 		return
 	}
 
-	problem, err := h.managers.Problem.FullProblemByTaskID(c.Request.Context(), dto.TaskID)
+	problem, err := h.managers.Problem.ProblemByTaskID(c.Request.Context(), dto)
 	if err != nil {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
 
@@ -341,7 +396,7 @@ func (h *Handler) getAvailableTaskAttributes(c *gin.Context) {
 }
 
 func (h *Handler) getAvailableLanguages(c *gin.Context) {
-	ls, err := h.managers.Problem.GetAvailableTaskLanguages()
+	ls, err := h.managers.Problem.GetAvailableTaskLanguages(c.Request.Context())
 	if err != nil {
 		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
 
@@ -350,3 +405,103 @@ func (h *Handler) getAvailableLanguages(c *gin.Context) {
 
 	c.JSON(http.StatusOK, ls)
 }
+
+func (h *Handler) rejudgeTask(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.RejudgeTaskDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rej, err := h.managers.Solution.RejudgeTask(c.Request.Context(), dto)
+	if err != nil {
+		var errNotFound *struct_errors.ErrNotFound
+		if errors.As(err, &errNotFound) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, errNotFound.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, rej)
+}
+
+func (h *Handler) rejudge(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetRejudgeDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	rej, err := h.managers.Solution.Rejudge(c.Request.Context(), dto)
+	if err != nil {
+		var errNotFound *struct_errors.ErrNotFound
+		if errors.As(err, &errNotFound) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, errNotFound.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, rej)
+}
+
+func (h *Handler) checkPlagiarism(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.CheckPlagiarismDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	check, err := h.managers.Plagiarism.CheckTask(c.Request.Context(), dto)
+	if err != nil {
+		var errNotFound *struct_errors.ErrNotFound
+		if errors.As(err, &errNotFound) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, errNotFound.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, check)
+}
+
+func (h *Handler) plagiarismCheck(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetPlagiarismCheckDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	check, err := h.managers.Plagiarism.PlagiarismCheck(c.Request.Context(), dto)
+	if err != nil {
+		var errNotFound *struct_errors.ErrNotFound
+		if errors.As(err, &errNotFound) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, errNotFound.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
@@ -1,28 +1,41 @@
 package solution
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"io"
 	"lcode/config"
 	"lcode/internal/domain"
 	accessMiddleware "lcode/internal/handler/middleware/access"
+	authMiddleware "lcode/internal/handler/middleware/auth"
 	solutionMiddleware "lcode/internal/handler/middleware/solution"
+	"lcode/internal/manager/ranking_manager"
 	"lcode/internal/manager/solution_manager"
 	"lcode/internal/service/solution"
 	"lcode/internal/service/solution_result"
 	"lcode/pkg/gin_helpers"
 	"lcode/pkg/http_lib/http_helper"
+	"lcode/pkg/struct_errors"
 	"log/slog"
 	"net/http"
+	"time"
+)
+
+const (
+	eventsKeepAliveInterval = time.Second * 15
 )
 
 type (
 	Middlewares struct {
 		Access   *accessMiddleware.Middleware
+		Auth     *authMiddleware.Middleware
 		Solution *solutionMiddleware.Middleware
 	}
 
 	Services struct {
 		SolutionManager       solution_manager.SolutionManager
+		RankingManager        ranking_manager.RankingManager
 		SolutionService       solution.Solution
 		SolutionResultService solution_result.SolutionResult
 	}
@@ -43,8 +56,19 @@ func New(cfg *config.Config, logger *slog.Logger, services *Services) *Handler {
 }
 
 func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
+	httpServer.PUT(
+		domain.JudgeCallbackRoute+"/:solution_id",
+		middlewares.Solution.ValidateJudgeCallbackInput,
+		h.judgeCallback,
+	)
+
 	solutionsGroup := httpServer.Group("/solutions", middlewares.Access.UserIdentity)
 	{
+		solutionsGroup.GET(
+			"",
+			middlewares.Solution.ValidateGetSolutionListInput,
+			h.solutionList,
+		)
 		solutionsGroup.GET(
 			"/available_statuses",
 			h.getAvailableSolutionStatuses,
@@ -54,11 +78,39 @@ func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
 			middlewares.Solution.ValidateCreateSolutionInput,
 			h.createSolution,
 		)
+		solutionsGroup.POST(
+			"/run",
+			middlewares.Solution.ValidateRunSolutionInput,
+			h.runSolution,
+		)
 		solutionsGroup.GET("/task/:task_id",
 			middlewares.Solution.ValidateGetSolutionsInput,
 			h.solutions,
 		)
 
+		queueGroup := solutionsGroup.Group("/queue", middlewares.Auth.CheckAdminAccess)
+		{
+			queueGroup.GET(
+				"",
+				h.queueStats,
+			)
+			queueGroup.GET(
+				"/solutions",
+				middlewares.Solution.ValidateGetQueuedSolutionsInput,
+				h.queuedSolutions,
+			)
+			queueGroup.DELETE(
+				"/:solution_id",
+				middlewares.Solution.ValidateCancelQueuedSolutionInput,
+				h.cancelQueuedSolution,
+			)
+			queueGroup.PATCH(
+				"/workers",
+				middlewares.Solution.ValidateResizeWorkerPoolInput,
+				h.resizeWorkerPool,
+			)
+		}
+
 		solGroup := solutionsGroup.Group("/:id")
 		{
 			solGroup.GET(
@@ -75,6 +127,27 @@ func (h *Handler) Register(middlewares *Middlewares, httpServer *gin.Engine) {
 				middlewares.Solution.CheckSolutionAccess,
 				h.solutionResults,
 			)
+
+			solGroup.GET(
+				"/results/:test_case_id/output",
+				middlewares.Solution.ValidateGetSolutionOutputInput,
+				middlewares.Solution.CheckSolutionAccess,
+				h.solutionOutput,
+			)
+
+			solGroup.GET(
+				"/ranking",
+				middlewares.Solution.ValidateGetSolutionRankingInput,
+				middlewares.Solution.CheckSolutionAccess,
+				h.solutionRanking,
+			)
+
+			solGroup.GET(
+				"/events",
+				middlewares.Solution.ValidateGetSolutionEventsInput,
+				middlewares.Solution.CheckSolutionAccess,
+				h.solutionEvents,
+			)
 		}
 
 	}
@@ -90,6 +163,27 @@ func (h *Handler) createSolution(c *gin.Context) {
 
 	sol, err := h.services.SolutionManager.CreateSolution(c.Request.Context(), dto)
 	if err != nil {
+		var userLimitErr *domain.SolutionUserQueueLimitError
+		if errors.As(err, &userLimitErr) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, userLimitErr)
+
+			return
+		}
+
+		var queueIsFullErr *domain.SolutionQueueIsFullError
+		if errors.As(err, &queueIsFullErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, queueIsFullErr.Msg)
+
+			return
+		}
+
+		var judgeUnavailableErr *domain.JudgeUnavailableError
+		if errors.As(err, &judgeUnavailableErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeUnavailableErr.Msg)
+
+			return
+		}
+
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
 
 		return
@@ -98,6 +192,45 @@ func (h *Handler) createSolution(c *gin.Context) {
 	c.JSON(http.StatusOK, sol)
 }
 
+func (h *Handler) runSolution(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.RunSolutionDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	results, err := h.services.SolutionManager.RunSolution(c.Request.Context(), dto)
+	if err != nil {
+		var rateLimitErr *domain.RunRateLimitError
+		if errors.As(err, &rateLimitErr) {
+			http_helper.NewErrorResponse(c, http.StatusTooManyRequests, rateLimitErr.Msg)
+
+			return
+		}
+
+		var judgeUnavailableErr *domain.JudgeUnavailableError
+		if errors.As(err, &judgeUnavailableErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeUnavailableErr.Msg)
+
+			return
+		}
+
+		var judgeQueueIsFullErr *domain.JudgeQueueIsFullError
+		if errors.As(err, &judgeQueueIsFullErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeQueueIsFullErr.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
 func (h *Handler) solutions(c *gin.Context) {
 	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionsDTO](c, domain.DtoCtxKey)
 	if err != nil {
@@ -116,6 +249,24 @@ func (h *Handler) solutions(c *gin.Context) {
 	c.JSON(http.StatusOK, solutions)
 }
 
+func (h *Handler) solutionList(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionListDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	list, err := h.services.SolutionService.SolutionList(c.Request.Context(), dto.Params)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
 func (h *Handler) solutionResults(c *gin.Context) {
 	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionResultsDTO](c, domain.DtoCtxKey)
 	if err != nil {
@@ -124,7 +275,7 @@ func (h *Handler) solutionResults(c *gin.Context) {
 		return
 	}
 
-	results, err := h.services.SolutionResultService.ResultsBySolutionID(c.Request.Context(), dto.SolutionID)
+	results, err := h.services.SolutionManager.SolutionResults(c.Request.Context(), dto)
 	if err != nil {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
 
@@ -134,6 +285,145 @@ func (h *Handler) solutionResults(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
+func (h *Handler) solutionRanking(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionRankingDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	ranking, err := h.services.RankingManager.SolutionRanking(c.Request.Context(), dto)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, ranking)
+}
+
+// solutionEvents streams judging progress of the solution as Server-Sent Events
+// until the final event with the overall status is sent. Events come from the
+// instance which judges the solution, the status is read again on every
+// keepalive and when the subscription is ended, so the final event is sent
+// even if it was not delivered to the subscription.
+func (h *Handler) solutionEvents(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionEventsDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	// subscribe before reading the status, so the final event can not be missed
+	events, unsubscribe := h.services.SolutionManager.SubscribeSolutionEvents(dto.SolutionID)
+	defer unsubscribe()
+
+	sol, err := h.services.SolutionService.SolutionByID(c.Request.Context(), dto.SolutionID)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if sol.Status != domain.SolutionStatusTesting {
+		sendFinishedEvent(c, sol)
+
+		return
+	}
+
+	// finished reads the solution and sends the final event when it is judged
+	finished := func() bool {
+		sol, err := h.services.SolutionService.SolutionByID(c.Request.Context(), dto.SolutionID)
+		if err != nil {
+			h.logger.Error("can not read solution status", slog.String("err", err.Error()))
+
+			return false
+		}
+
+		if sol.Status == domain.SolutionStatusTesting {
+			return false
+		}
+
+		sendFinishedEvent(c, sol)
+
+		return true
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-keepAlive.C:
+			if finished() {
+				return false
+			}
+
+			c.SSEvent("keepalive", "")
+
+			return true
+		case e, ok := <-events:
+			if !ok {
+				// the subscription is ended by shutdown or the final event
+				// did not fit into it
+				finished()
+
+				return false
+			}
+
+			// the finished event does not carry the solution
+			if e.Type == domain.SolutionEventFinished {
+				if !finished() {
+					c.SSEvent(string(e.Type), e)
+				}
+
+				return false
+			}
+
+			c.SSEvent(string(e.Type), e)
+
+			return true
+		}
+	})
+}
+
+func sendFinishedEvent(c *gin.Context, sol domain.Solution) {
+	c.SSEvent(string(domain.SolutionEventFinished), domain.SolutionEvent{
+		Type:     domain.SolutionEventFinished,
+		Solution: &sol,
+	})
+}
+
+func (h *Handler) solutionOutput(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionOutputDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	output, err := h.services.SolutionManager.SolutionOutput(c.Request.Context(), dto)
+	if err != nil {
+		var notFoundErr *struct_errors.ErrNotFound
+		if errors.As(err, &notFoundErr) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, notFoundErr.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", dto.TestCaseID+"."+string(dto.Stream)))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(output))
+}
+
 func (h *Handler) solutionCode(c *gin.Context) {
 	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetSolutionCodeDTO](c, domain.DtoCtxKey)
 	if err != nil {
@@ -152,9 +442,108 @@ func (h *Handler) solutionCode(c *gin.Context) {
 	c.JSON(http.StatusOK, sol.Code)
 }
 
+func (h *Handler) judgeCallback(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.JudgeCallbackDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	err = h.services.SolutionManager.HandleJudgeCallback(c.Request.Context(), dto)
+	if err != nil {
+		h.logger.Error("can not handle judge callback", slog.String("err", err.Error()))
+
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (h *Handler) queueStats(c *gin.Context) {
+	stats, err := h.services.SolutionManager.QueueStats(c.Request.Context())
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *Handler) queuedSolutions(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.GetQueuedSolutionsDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	list, err := h.services.SolutionManager.QueuedSolutions(c.Request.Context(), dto)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *Handler) cancelQueuedSolution(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.CancelQueuedSolutionDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	sol, err := h.services.SolutionManager.CancelQueuedSolution(c.Request.Context(), dto)
+	if err != nil {
+		var notFoundErr *struct_errors.ErrNotFound
+		if errors.As(err, &notFoundErr) {
+			http_helper.NewErrorResponse(c, http.StatusNotFound, notFoundErr.Msg)
+
+			return
+		}
+
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, sol)
+}
+
+func (h *Handler) resizeWorkerPool(c *gin.Context) {
+	dto, err := gin_helpers.GetValueFromGinCtx[domain.ResizeWorkerPoolDTO](c, domain.DtoCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	stats, err := h.services.SolutionManager.ResizeWorkerPool(c.Request.Context(), dto)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 func (h *Handler) getAvailableSolutionStatuses(c *gin.Context) {
-	ss, err := h.services.SolutionManager.GetAvailableSolutionStatuses()
+	ss, err := h.services.SolutionManager.GetAvailableSolutionStatuses(c.Request.Context())
 	if err != nil {
+		var judgeUnavailableErr *domain.JudgeUnavailableError
+		if errors.As(err, &judgeUnavailableErr) {
+			http_helper.NewErrorResponse(c, http.StatusServiceUnavailable, judgeUnavailableErr.Msg)
+
+			return
+		}
+
 		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
 
 		return
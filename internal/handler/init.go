@@ -5,6 +5,8 @@ import (
 	articleH "lcode/internal/handler/http/article"
 	authH "lcode/internal/handler/http/auth"
 	commentH "lcode/internal/handler/http/comment"
+	healthH "lcode/internal/handler/http/health"
+	languageH "lcode/internal/handler/http/language"
 	problemH "lcode/internal/handler/http/problem"
 	solutionH "lcode/internal/handler/http/solution"
 	userProgressH "lcode/internal/handler/http/user_progress"
@@ -28,6 +30,8 @@ type (
 		Article      *articleH.Handler
 		Solution     *solutionH.Handler
 		Comment      *commentH.Handler
+		Health       *healthH.Handler
+		Language     *languageH.Handler
 	}
 
 	Handlers struct {
@@ -49,7 +53,9 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		p.Config,
 		p.Logger,
 		&problemH.Managers{
-			Problem: managers.ProblemManager,
+			Problem:    managers.ProblemManager,
+			Solution:   managers.SolutionManager,
+			Plagiarism: managers.PlagiarismManager,
 		},
 	)
 
@@ -74,6 +80,7 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		p.Logger,
 		&solutionH.Services{
 			SolutionManager:       managers.SolutionManager,
+			RankingManager:        managers.RankingManager,
 			SolutionService:       services.Solution,
 			SolutionResultService: services.SolutionResult,
 		},
@@ -87,6 +94,22 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		},
 	)
 
+	healthHandler := healthH.New(
+		p.Config,
+		p.Logger,
+		&healthH.Managers{
+			Health: managers.HealthManager,
+		},
+	)
+
+	languageHandler := languageH.New(
+		p.Config,
+		p.Logger,
+		&languageH.Managers{
+			Language: managers.LanguageManager,
+		},
+	)
+
 	return &Handlers{
 		&HTTPHandlers{
 			Auth:         authHandler,
@@ -95,6 +118,8 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 			Article:      articleHandler,
 			Solution:     solutionHandler,
 			Comment:      commentHandler,
+			Health:       healthHandler,
+			Language:     languageHandler,
 		},
 	}
 }
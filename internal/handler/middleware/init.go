@@ -6,6 +6,7 @@ import (
 	"lcode/internal/handler/middleware/article"
 	"lcode/internal/handler/middleware/auth"
 	"lcode/internal/handler/middleware/comment"
+	"lcode/internal/handler/middleware/language"
 	"lcode/internal/handler/middleware/problem"
 	"lcode/internal/handler/middleware/solution"
 	userProgress "lcode/internal/handler/middleware/user_progress"
@@ -28,6 +29,7 @@ type (
 		Article      *article.Middleware
 		Solution     *solution.Middleware
 		Comment      *comment.Middleware
+		Language     *language.Middleware
 	}
 )
 
@@ -50,7 +52,8 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		p.Config,
 		p.Logger,
 		&problem.Managers{
-			Problem: managers.ProblemManager,
+			Problem:  managers.ProblemManager,
+			Language: managers.LanguageManager,
 		},
 	)
 
@@ -69,6 +72,7 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		p.Logger,
 		&solution.Services{
 			Solution: services.Solution,
+			Language: managers.LanguageManager,
 		},
 	)
 
@@ -77,6 +81,11 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		p.Logger,
 	)
 
+	languageMiddleware := language.New(
+		p.Config,
+		p.Logger,
+	)
+
 	return &Middlewares{
 		Access:       accessMiddleware,
 		Auth:         authMiddleware,
@@ -85,5 +94,6 @@ func New(p *InitParams, services *service.Services, managers *manager.Managers)
 		Article:      articleMiddleware,
 		Solution:     solutionMiddleware,
 		Comment:      commentMiddleware,
+		Language:     languageMiddleware,
 	}
 }
@@ -0,0 +1,79 @@
+package language
+
+import (
+	"github.com/gin-gonic/gin"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/pkg/http_lib/http_helper"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type (
+	Middleware struct {
+		cfg    *config.Config
+		logger *slog.Logger
+	}
+)
+
+func New(cfg *config.Config, logger *slog.Logger) *Middleware {
+	return &Middleware{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (m *Middleware) ValidateCreateLanguageInput(c *gin.Context) {
+	var dto domain.LanguageCreateDTO
+
+	if err := c.ShouldBindJSON(&dto.Input); err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if dto.Input.ID <= 0 {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Judge language ID is required")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateUpdateLanguageInput(c *gin.Context) {
+	var dto domain.LanguageUpdateDTO
+
+	id, err := strconv.Atoi(c.Param("language_id"))
+	if err != nil || id <= 0 {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid language ID")
+
+		return
+	}
+
+	dto.ID = domain.LanguageType(id)
+
+	if err = c.ShouldBindJSON(&dto.Input); err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if dto.Input.Name == nil && dto.Input.EditorMode == nil &&
+		dto.Input.DefaultTemplate == nil && dto.Input.CompilerOptions == nil &&
+		dto.Input.CommandLineArguments == nil && dto.Input.Enabled == nil &&
+		dto.Input.Compiled == nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "No update data provided")
+
+		return
+	}
+
+	if dto.Input.Name != nil && *dto.Input.Name == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Name must not be empty")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
@@ -2,19 +2,34 @@ package problem
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"io"
 	"lcode/config"
 	"lcode/internal/domain"
+	"lcode/internal/manager/language_manager"
 	"lcode/internal/manager/problem_manager"
 	"lcode/pkg/db"
+	"lcode/pkg/gin_helpers"
 	"lcode/pkg/http_lib/http_helper"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCheckerEpsilon = 1e-6
+
+	testCaseArchiveInputPart = "input"
+	testCaseArchivePart      = "archive"
 )
 
 type (
 	Managers struct {
-		Problem *problem_manager.Manager
+		Problem  *problem_manager.Manager
+		Language language_manager.LanguageManager
 	}
 
 	Middleware struct {
@@ -57,6 +72,37 @@ func (m *Middleware) ValidateCreateProblemInput(c *gin.Context) {
 		dto.Input.Task.RuntimeLimit = m.cfg.JudgeConfig.DefaultTimeLimitSec
 	}
 
+	if msg := validateChecker(&dto.Input.Task); msg != "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, msg)
+
+		return
+	}
+
+	if dto.Input.Task.CheckerType == domain.CheckerProgram &&
+		!m.checkLanguage(c, dto.Input.Task.CheckerLanguageID, "not supported checker language") {
+		return
+	}
+
+	for i := range dto.Input.TaskTemplates {
+		if !validTemplateLimits(&dto.Input.TaskTemplates[i]) {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Limits must be positive")
+
+			return
+		}
+
+		if !m.checkLanguage(c, dto.Input.TaskTemplates[i].LanguageID, "not supported language") {
+			return
+		}
+	}
+
+	for i := range dto.Input.TestCases {
+		if !setTestCaseVisibility(&dto.Input.TestCases[i]) {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid test case visibility")
+
+			return
+		}
+	}
+
 	c.Set(domain.DtoCtxKey, dto)
 }
 
@@ -72,12 +118,32 @@ func (m *Middleware) ValidateUpdateProblemTaskInput(c *gin.Context) {
 
 	if dto.Input.Name == nil && dto.Input.Category == nil &&
 		dto.Input.Description == nil && dto.Input.Difficulty == nil &&
-		dto.Input.MemoryLimit == nil && dto.Input.RuntimeLimit == nil {
+		dto.Input.MemoryLimit == nil && dto.Input.RuntimeLimit == nil &&
+		dto.Input.FullReport == nil && dto.Input.CheckerType == nil &&
+		dto.Input.CheckerEpsilon == nil && dto.Input.CheckerCode == nil &&
+		dto.Input.CheckerLanguageID == nil {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "No update data provided")
 
 		return
 	}
 
+	if dto.Input.CheckerType != nil && !slices.Contains(domain.AvailableCheckerTypes, *dto.Input.CheckerType) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid checker type")
+
+		return
+	}
+
+	if dto.Input.CheckerEpsilon != nil && *dto.Input.CheckerEpsilon < 0 {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Checker epsilon must not be negative")
+
+		return
+	}
+
+	if dto.Input.CheckerLanguageID != nil &&
+		!m.checkLanguage(c, *dto.Input.CheckerLanguageID, "not supported checker language") {
+		return
+	}
+
 	dto.TaskID = c.Param("task_id")
 	if dto.TaskID == "" {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
@@ -117,6 +183,16 @@ func (m *Middleware) ValidateCreateProblemTaskTemplateInput(c *gin.Context) {
 		return
 	}
 
+	if !validTemplateLimits(&dto.Input) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Limits must be positive")
+
+		return
+	}
+
+	if !m.checkLanguage(c, dto.Input.LanguageID, "not supported language") {
+		return
+	}
+
 	dto.TaskID = c.Param("task_id")
 	if dto.TaskID == "" {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
@@ -136,12 +212,20 @@ func (m *Middleware) ValidateUpdateProblemTaskTemplateInput(c *gin.Context) {
 		return
 	}
 
-	if dto.Input.Wrapper == nil && dto.Input.Template == nil {
+	if dto.Input.Wrapper == nil && dto.Input.Template == nil &&
+		dto.Input.RuntimeLimit == nil && dto.Input.MemoryLimit == nil {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "No update data provided")
 
 		return
 	}
 
+	if (dto.Input.RuntimeLimit != nil && *dto.Input.RuntimeLimit < 0) ||
+		(dto.Input.MemoryLimit != nil && *dto.Input.MemoryLimit < 0) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Limits must not be negative")
+
+		return
+	}
+
 	dto.TemplateID = c.Param("template_id")
 	dto.TaskID = c.Param("task_id")
 	if dto.TemplateID == "" || dto.TaskID == "" {
@@ -182,6 +266,12 @@ func (m *Middleware) ValidateCreateProblemTestCaseInput(c *gin.Context) {
 		return
 	}
 
+	if !setTestCaseVisibility(&dto.Input) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid test case visibility")
+
+		return
+	}
+
 	dto.TaskID = c.Param("task_id")
 	if dto.TaskID == "" {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
@@ -192,6 +282,134 @@ func (m *Middleware) ValidateCreateProblemTestCaseInput(c *gin.Context) {
 	c.Set(domain.DtoCtxKey, dto)
 }
 
+// ValidateReplaceProblemTestCasesInput reads multipart form of the test case
+// archive upload: optional JSON part "input" followed by part "archive" with
+// the zip archive. The archive part is streamed, so it must be the last one.
+func (m *Middleware) ValidateReplaceProblemTestCasesInput(c *gin.Context) {
+	dto := domain.TestCaseArchiveUploadDTO{
+		TaskID: c.Param("task_id"),
+	}
+
+	if dto.TaskID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
+
+		return
+	}
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Multipart form is required")
+
+		return
+	}
+
+	reader, err := http_helper.NewMultipartReader(c.GetHeader("Content-Type"), c.Request.Body)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	for dto.Archive == nil {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Archive is required")
+
+			return
+		}
+
+		if err != nil {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		switch part.FormName() {
+		case testCaseArchiveInputPart:
+			if err = reader.DecodeLast(&dto.Input); err != nil {
+				http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+				return
+			}
+		case testCaseArchivePart:
+			dto.Archive = part
+		default:
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Unexpected form part "+part.FormName())
+
+			return
+		}
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+// validateChecker sets default checker values and returns error message when
+// checker settings of the task are invalid.
+func validateChecker(t *domain.TaskCreateInput) string {
+	if t.CheckerType == "" {
+		t.CheckerType = domain.CheckerExact
+	}
+
+	if !slices.Contains(domain.AvailableCheckerTypes, t.CheckerType) {
+		return "Invalid checker type"
+	}
+
+	if t.CheckerEpsilon < 0 {
+		return "Checker epsilon must not be negative"
+	}
+
+	if t.CheckerType == domain.CheckerFloat && t.CheckerEpsilon == 0 {
+		t.CheckerEpsilon = defaultCheckerEpsilon
+	}
+
+	if t.CheckerType == domain.CheckerProgram {
+		if t.CheckerCode == "" {
+			return "Checker code is required"
+		}
+
+		if t.CheckerLanguageID == 0 {
+			return "Checker language is required"
+		}
+	}
+
+	return ""
+}
+
+// checkLanguage responds with bad request when the language is not in the
+// registry and reports whether the request can go on. Disabled languages are
+// accepted, so tasks can be prepared before the language is enabled.
+func (m *Middleware) checkLanguage(c *gin.Context, id domain.LanguageType, msg string) bool {
+	_, ok, err := m.managers.Language.RegisteredLanguage(c.Request.Context(), id)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return false
+	}
+
+	if !ok {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, msg)
+
+		return false
+	}
+
+	return true
+}
+
+// setTestCaseVisibility makes test case hidden when visibility is not set and
+// reports whether the visibility is valid.
+func setTestCaseVisibility(inp *domain.TestCaseCreateInput) bool {
+	if inp.Visibility == "" {
+		inp.Visibility = domain.TestCaseVisibilityHidden
+	}
+
+	return inp.Visibility.IsValid()
+}
+
+// validTemplateLimits checks limit overrides of the template if they are set.
+func validTemplateLimits(inp *domain.TaskTemplateCreateInput) bool {
+	return (inp.RuntimeLimit == nil || *inp.RuntimeLimit > 0) &&
+		(inp.MemoryLimit == nil || *inp.MemoryLimit > 0)
+}
+
 func (m *Middleware) ValidateUpdateProblemTestCaseInput(c *gin.Context) {
 	var dto domain.TestCaseUpdateDTO
 
@@ -201,12 +419,18 @@ func (m *Middleware) ValidateUpdateProblemTestCaseInput(c *gin.Context) {
 		return
 	}
 
-	if dto.Input.Input == nil && dto.Input.Output == nil {
+	if dto.Input.Input == nil && dto.Input.Output == nil && dto.Input.Visibility == nil {
 		http_helper.NewErrorResponse(c, http.StatusBadRequest, "No update data provided")
 
 		return
 	}
 
+	if dto.Input.Visibility != nil && !dto.Input.Visibility.IsValid() {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid test case visibility")
+
+		return
+	}
+
 	dto.CaseID = c.Param("case_id")
 	dto.TaskID = c.Param("task_id")
 	if dto.CaseID == "" || dto.TaskID == "" {
@@ -233,8 +457,16 @@ func (m *Middleware) ValidateDeleteProblemTestCaseInput(c *gin.Context) {
 }
 
 func (m *Middleware) ValidateFullProblemByTaskIDInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
 	dto := domain.GetProblemDTO{
 		TaskID: c.Param("task_id"),
+		User:   user,
 	}
 
 	if dto.TaskID == "" {
@@ -292,3 +524,120 @@ func (m *Middleware) ValidateTaskListByParamsInput(c *gin.Context) {
 
 	c.Set(domain.DtoCtxKey, domain.TaskParamsDTO{Input: data})
 }
+
+func (m *Middleware) ValidateRejudgeTaskInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	dto := domain.RejudgeTaskDTO{
+		TaskID: c.Param("task_id"),
+		User:   user,
+	}
+
+	if dto.TaskID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
+
+		return
+	}
+
+	// every filter is optional, so is the body
+	if err = c.ShouldBindJSON(&dto.Filter); err != nil && !errors.Is(err, io.EOF) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if len(dto.Filter.Statuses) == 0 {
+		dto.Filter.Statuses = domain.DefaultRejudgeStatuses
+	}
+
+	for i := range dto.Filter.Statuses {
+		if !slices.Contains(domain.RejudgeStatuses, dto.Filter.Statuses[i]) {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid solution status")
+
+			return
+		}
+	}
+
+	if dto.Filter.LanguageID != nil && !m.checkLanguage(c, *dto.Filter.LanguageID, "not supported language") {
+		return
+	}
+
+	if dto.Filter.CreatedFrom != nil && dto.Filter.CreatedTo != nil &&
+		!time.Time(*dto.Filter.CreatedFrom).Before(time.Time(*dto.Filter.CreatedTo)) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "created_from must be before created_to")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateGetRejudgeInput(c *gin.Context) {
+	dto := domain.GetRejudgeDTO{
+		TaskID:    c.Param("task_id"),
+		RejudgeID: c.Param("rejudge_id"),
+	}
+
+	if dto.TaskID == "" || dto.RejudgeID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID and rejudge ID are required")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateCheckPlagiarismInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	dto := domain.CheckPlagiarismDTO{
+		TaskID: c.Param("task_id"),
+		User:   user,
+	}
+
+	if dto.TaskID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
+
+		return
+	}
+
+	// threshold is optional, so is the body
+	if err = c.ShouldBindJSON(&dto.Input); err != nil && !errors.Is(err, io.EOF) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if dto.Input.Threshold != nil && (*dto.Input.Threshold <= 0 || *dto.Input.Threshold > 100) {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "threshold must be greater than 0 and not greater than 100")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateGetPlagiarismCheckInput(c *gin.Context) {
+	dto := domain.GetPlagiarismCheckDTO{
+		TaskID:  c.Param("task_id"),
+		CheckID: c.Param("check_id"),
+	}
+
+	if dto.TaskID == "" || dto.CheckID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID and check ID are required")
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
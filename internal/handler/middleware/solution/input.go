@@ -2,15 +2,21 @@ package solution
 
 import (
 	"context"
+	"crypto/hmac"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"lcode/config"
 	"lcode/internal/domain"
+	"lcode/internal/manager/language_manager"
 	"lcode/internal/service/solution"
+	"lcode/pkg/db"
 	"lcode/pkg/gin_helpers"
 	"lcode/pkg/http_lib/http_helper"
 	"log/slog"
 	"net/http"
 	"slices"
+	"strconv"
+	"time"
 )
 
 type (
@@ -22,6 +28,7 @@ type (
 type (
 	Services struct {
 		Solution solution.Solution
+		Language language_manager.LanguageManager
 	}
 
 	Middleware struct {
@@ -39,10 +46,32 @@ func New(cfg *config.Config, logger *slog.Logger, services *Services) *Middlewar
 	}
 }
 
+const maxRunInputs = 10
+
+// checkLanguage responds with bad request unless the language is enabled in
+// the registry and reports whether the request can go on.
+func (m *Middleware) checkLanguage(c *gin.Context, id domain.LanguageType) bool {
+	l, ok, err := m.services.Language.RegisteredLanguage(c.Request.Context(), id)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return false
+	}
+
+	if !ok || !l.Enabled {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "not supported language")
+
+		return false
+	}
+
+	return true
+}
+
 type createSolutionInput struct {
 	TaskID     string              `json:"task_id"`
 	LanguageID domain.LanguageType `json:"language_id"`
 	Code       string              `json:"code"`
+	Validation bool                `json:"validation"`
 }
 
 func (m *Middleware) ValidateCreateSolutionInput(c *gin.Context) {
@@ -62,14 +91,76 @@ func (m *Middleware) ValidateCreateSolutionInput(c *gin.Context) {
 		return
 	}
 
-	if !slices.Contains(domain.AvailableLanguageIds, inp.LanguageID) {
-		http_helper.NewErrorResponse(c, http.StatusBadRequest, "not supported language")
+	if !m.checkLanguage(c, inp.LanguageID) {
+		return
+	}
+
+	if inp.Validation && !user.IsAdmin {
+		http_helper.NewErrorResponse(c, http.StatusForbidden, "Validation runs are available only to admins")
+
+		return
 	}
 
 	dto := domain.CreateSolutionDTO{
 		TaskID:     inp.TaskID,
 		LanguageID: inp.LanguageID,
 		Code:       inp.Code,
+		Validation: inp.Validation,
+		User:       user,
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+type runSolutionInput struct {
+	TaskID     string              `json:"task_id"`
+	LanguageID domain.LanguageType `json:"language_id"`
+	Code       string              `json:"code"`
+	Inputs     []string            `json:"inputs"`
+}
+
+func (m *Middleware) ValidateRunSolutionInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	var inp runSolutionInput
+
+	err = c.ShouldBindJSON(&inp)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if !m.checkLanguage(c, inp.LanguageID) {
+		return
+	}
+
+	if inp.TaskID == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Task ID is required")
+
+		return
+	}
+
+	if len(inp.Inputs) == 0 || len(inp.Inputs) > maxRunInputs {
+		http_helper.NewErrorResponse(
+			c,
+			http.StatusBadRequest,
+			fmt.Sprintf("from 1 to %d inputs are required", maxRunInputs),
+		)
+
+		return
+	}
+
+	dto := domain.RunSolutionDTO{
+		TaskID:     inp.TaskID,
+		LanguageID: inp.LanguageID,
+		Code:       inp.Code,
+		Inputs:     inp.Inputs,
 		User:       user,
 	}
 
@@ -91,6 +182,117 @@ func (m *Middleware) ValidateGetSolutionsInput(c *gin.Context) {
 	c.Set(domain.DtoCtxKey, dto)
 }
 
+// ValidateGetSolutionListInput reads filters of the submission history. Users
+// other than admins see only their own solutions.
+func (m *Middleware) ValidateGetSolutionListInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	dto := domain.GetSolutionListDTO{
+		User: user,
+	}
+
+	params := &dto.Params
+
+	if userID, ok := c.GetQuery("user_id"); ok && userID != "" {
+		if !user.IsAdmin && userID != user.ID {
+			http_helper.NewErrorResponse(c, http.StatusForbidden, "Solutions of other users are available only to admins")
+
+			return
+		}
+
+		params.Filter.UserID = &userID
+	}
+
+	if !user.IsAdmin {
+		params.Filter.UserID = &user.ID
+	}
+
+	if taskID, ok := c.GetQuery("task_id"); ok && taskID != "" {
+		params.Filter.TaskID = &taskID
+	}
+
+	if languageIDStr, ok := c.GetQuery("language_id"); ok {
+		languageID, err := strconv.Atoi(languageIDStr)
+		if err != nil {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid language_id")
+
+			return
+		}
+
+		lid := domain.LanguageType(languageID)
+		params.Filter.LanguageID = &lid
+	}
+
+	var ok bool
+
+	statuses, _ := c.GetQueryArray("status")
+	for i := range statuses {
+		status := domain.SolutionStatus(statuses[i])
+		if !slices.Contains(domain.SolutionStatuses, status) {
+			http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid solution status")
+
+			return
+		}
+
+		params.Filter.Statuses = append(params.Filter.Statuses, status)
+	}
+
+	if params.Filter.CreatedFrom, ok = queryTime(c, "created_from"); !ok {
+		return
+	}
+
+	if params.Filter.CreatedTo, ok = queryTime(c, "created_to"); !ok {
+		return
+	}
+
+	params.Sort = db.SortType(c.DefaultQuery("sort", string(db.DESC)))
+	if params.Sort != db.ASC && params.Sort != db.DESC {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid sort")
+
+		return
+	}
+
+	if pAfterID, ok := c.GetQuery("after_id"); ok {
+		params.Pagination.AfterID = &pAfterID
+	}
+
+	params.Pagination.Limit = m.cfg.QueryParams.Limit
+
+	if pLimitStr, ok := c.GetQuery("limit"); ok {
+		pLimit, err := strconv.Atoi(pLimitStr)
+		if err == nil {
+			params.Pagination.Limit = pLimit
+		}
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+// queryTime parses optional unix time in milliseconds of the query parameter,
+// it responds with bad request and returns false when the value is invalid.
+func queryTime(c *gin.Context, name string) (*domain.IntTime, bool) {
+	str, ok := c.GetQuery(name)
+	if !ok {
+		return nil, true
+	}
+
+	ms, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Invalid "+name)
+
+		return nil, false
+	}
+
+	t := domain.IntTime(time.UnixMilli(ms))
+
+	return &t, true
+}
+
 func (m *Middleware) ValidateGetSolutionResultsInput(c *gin.Context) {
 	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
 	if err != nil {
@@ -107,6 +309,31 @@ func (m *Middleware) ValidateGetSolutionResultsInput(c *gin.Context) {
 	c.Set(domain.DtoCtxKey, dto)
 }
 
+func (m *Middleware) ValidateGetSolutionOutputInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	stream := domain.OutputStream(c.DefaultQuery("stream", string(domain.OutputStreamStdout)))
+	if stream != domain.OutputStreamStdout && stream != domain.OutputStreamStderr {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "stream must be stdout or stderr")
+
+		return
+	}
+
+	dto := domain.GetSolutionOutputDTO{
+		SolutionID: c.Param("id"),
+		TestCaseID: c.Param("test_case_id"),
+		Stream:     stream,
+		User:       user,
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
 func (m *Middleware) ValidateGetSolutionCodeInput(c *gin.Context) {
 	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
 	if err != nil {
@@ -122,3 +349,149 @@ func (m *Middleware) ValidateGetSolutionCodeInput(c *gin.Context) {
 
 	c.Set(domain.DtoCtxKey, dto)
 }
+
+func (m *Middleware) ValidateGetSolutionEventsInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	dto := domain.GetSolutionEventsDTO{
+		SolutionID: c.Param("id"),
+		User:       user,
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateGetSolutionRankingInput(c *gin.Context) {
+	user, err := gin_helpers.GetValueFromGinCtx[domain.User](c, domain.UserCtxKey)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	dto := domain.GetSolutionRankingDTO{
+		SolutionID: c.Param("id"),
+		User:       user,
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateGetQueuedSolutionsInput(c *gin.Context) {
+	var dto domain.GetQueuedSolutionsDTO
+
+	pAfterID, ok := c.GetQuery("after_id")
+	if ok {
+		dto.Pagination.AfterID = &pAfterID
+	}
+
+	dto.Pagination.Limit = m.cfg.QueryParams.Limit
+
+	pLimitStr, ok := c.GetQuery("limit")
+	if ok {
+		pLimit, err := strconv.Atoi(pLimitStr)
+		if err == nil {
+			dto.Pagination.Limit = pLimit
+		}
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateCancelQueuedSolutionInput(c *gin.Context) {
+	dto := domain.CancelQueuedSolutionDTO{
+		SolutionID: c.Param("solution_id"),
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+func (m *Middleware) ValidateResizeWorkerPoolInput(c *gin.Context) {
+	var dto domain.ResizeWorkerPoolDTO
+
+	err := c.ShouldBindJSON(&dto)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
+
+// judgeCallbackInput is the finished submission as Judge0 sends it
+type judgeCallbackInput struct {
+	Token         string  `json:"token"`
+	Stdout        *string `json:"stdout"`
+	Stderr        *string `json:"stderr"`
+	CompileOutput *string `json:"compile_output"`
+	Time          float64 `json:"time,string"`
+	Memory        int     `json:"memory"`
+	Status        struct {
+		ID domain.JudgeStatus `json:"id"`
+	} `json:"status"`
+}
+
+// ValidateJudgeCallbackInput lets through callbacks signed with the configured
+// secret only, the signature covers the node which runs the submission.
+// Callbacks are not accepted unless the callback mode is on.
+func (m *Middleware) ValidateJudgeCallbackInput(c *gin.Context) {
+	secret := m.cfg.JudgeConfig.CallbackSecret
+	sig := domain.JudgeCallbackSignature(
+		secret,
+		c.Param("solution_id"),
+		c.Query(domain.JudgeCallbackKeyQuery),
+		c.Query(domain.JudgeCallbackNodeQuery),
+	)
+
+	if domain.JudgeSubmissionMode(m.cfg.JudgeConfig.SubmissionMode) != domain.JudgeSubmissionModeCallback ||
+		secret == "" ||
+		!hmac.Equal([]byte(c.Query(domain.JudgeCallbackSignatureQuery)), []byte(sig)) {
+		http_helper.NewErrorResponse(c, http.StatusForbidden, "invalid callback signature")
+
+		return
+	}
+
+	var inp judgeCallbackInput
+
+	err := c.ShouldBindJSON(&inp)
+	if err != nil {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if inp.Token == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Submission token is required")
+
+		return
+	}
+
+	if c.Query(domain.JudgeCallbackKeyQuery) == "" {
+		http_helper.NewErrorResponse(c, http.StatusBadRequest, "Callback key is required")
+
+		return
+	}
+
+	dto := domain.JudgeCallbackDTO{
+		SolutionID: c.Param("solution_id"),
+		Key:        c.Query(domain.JudgeCallbackKeyQuery),
+		Node:       c.Query(domain.JudgeCallbackNodeQuery),
+		Info: domain.JudgeSubmissionInfo{
+			Token:         inp.Token,
+			Stdout:        inp.Stdout,
+			Stderr:        inp.Stderr,
+			CompileOutput: inp.CompileOutput,
+			Time:          inp.Time,
+			Memory:        inp.Memory,
+			Status:        inp.Status.ID,
+		},
+	}
+
+	c.Set(domain.DtoCtxKey, dto)
+}
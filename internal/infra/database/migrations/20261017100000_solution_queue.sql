@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table solution_queue
+(
+    solution_id uuid                                           not null
+        constraint solution_queue_pk
+            primary key
+        constraint solution_queue_solution_id_fk
+            references solution
+            on delete cascade,
+    attempts    integer   default 0                            not null,
+    claimed_at  timestamp,
+    created_at  timestamp default timezone('utc'::text, now()) not null
+);
+
+create index solution_queue_created_at_index
+    on solution_queue (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table solution_queue;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution_queue
+    add column user_id uuid,
+    add column lane    text default 'user' not null
+        constraint solution_queue_lane_check
+            check (lane in ('validation', 'user', 'rejudge'));
+
+update solution_queue q
+set user_id = s.user_id
+from solution s
+where s.id = q.solution_id;
+
+alter table solution_queue
+    alter column user_id set not null;
+
+create index solution_queue_user_id_index
+    on solution_queue (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index solution_queue_user_id_index;
+
+alter table solution_queue
+    drop column lane,
+    drop column user_id;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table task
+    add column full_report boolean default false not null;
+
+alter table solution
+    add column passed_count integer          default 0 not null,
+    add column total_count  integer          default 0 not null,
+    add column score        double precision default 0 not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution
+    drop column score,
+    drop column total_count,
+    drop column passed_count;
+
+alter table task
+    drop column full_report;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table test_case
+    add column visibility text default 'hidden' not null
+        constraint test_case_visibility_check
+            check (visibility in ('sample', 'hidden'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table test_case
+    drop column visibility;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table task
+    add column checker_type        text             default 'exact' not null
+        constraint task_checker_type_check
+            check (checker_type in ('exact', 'whitespace', 'tokens', 'float', 'unordered_lines', 'program')),
+    add column checker_epsilon     double precision default 0       not null,
+    add column checker_code        text             default ''      not null,
+    add column checker_language_id integer          default 0       not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table task
+    drop column checker_language_id,
+    drop column checker_code,
+    drop column checker_epsilon,
+    drop column checker_type;
+-- +goose StatementEnd
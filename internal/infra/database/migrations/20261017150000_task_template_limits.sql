@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table task_template
+    add column runtime_limit double precision,
+    add column memory_limit  integer;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table task_template
+    drop column memory_limit,
+    drop column runtime_limit;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+create table rejudge
+(
+    id           uuid      default gen_random_uuid()            not null
+        constraint rejudge_pk
+            primary key,
+    task_id      uuid                                           not null
+        constraint rejudge_task_id_fk
+            references task
+            on delete cascade,
+    author_id    uuid                                           not null
+        constraint rejudge_author_id_fk
+            references "user"
+            on delete cascade,
+    statuses     text[]                                         not null,
+    language_id  integer,
+    created_from timestamp,
+    created_to   timestamp,
+    created_at   timestamp default timezone('utc'::text, now()) not null
+);
+
+create index rejudge_task_id_index
+    on rejudge (task_id);
+
+create table rejudge_solution
+(
+    rejudge_id       uuid             not null
+        constraint rejudge_solution_rejudge_id_fk
+            references rejudge
+            on delete cascade,
+    solution_id      uuid             not null
+        constraint rejudge_solution_solution_id_fk
+            references solution
+            on delete cascade,
+    old_status       text             not null,
+    old_passed_count integer          not null,
+    old_score        double precision not null,
+    new_status       text,
+    new_passed_count integer,
+    new_score        double precision,
+    finished_at      timestamp,
+    constraint rejudge_solution_pk
+        primary key (rejudge_id, solution_id)
+);
+
+create index rejudge_solution_solution_id_index
+    on rejudge_solution (solution_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table rejudge_solution;
+
+drop table rejudge;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution
+    add column error_reason text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution
+    drop column error_reason;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+create table language
+(
+    id                     integer                                        not null
+        constraint language_pk
+            primary key,
+    name                   text                                           not null,
+    judge_name             text                                           not null,
+    editor_mode            text      default ''::text                     not null,
+    default_template       text      default ''::text                     not null,
+    compiler_options       text      default ''::text                     not null,
+    command_line_arguments text      default ''::text                     not null,
+    enabled                boolean   default false                        not null,
+    created_at             timestamp default timezone('utc'::text, now()) not null,
+    updated_at             timestamp default timezone('utc'::text, now()) not null
+);
+
+insert into language (id, name, judge_name, editor_mode, enabled)
+values (1, 'JavaScript', 'JavaScript (Node.js)', 'javascript', true),
+       (2, 'TypeScript', 'TypeScript', 'typescript', true)
+on conflict do nothing;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table language;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table language
+    add column compiled boolean default false not null;
+
+update language
+set compiled = true
+where id = 2;
+
+alter table solution
+    add column compile_output text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution
+    drop column compile_output;
+
+alter table language
+    drop column compiled;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+create table solution_stats
+(
+    task_id        uuid                                           not null
+        constraint solution_stats_task_id_fk
+            references task
+            on delete cascade,
+    language_id    integer                                        not null,
+    metric         text                                           not null,
+    accepted_count integer                                        not null,
+    min_value      double precision                               not null,
+    max_value      double precision                               not null,
+    quantiles      double precision[]                             not null,
+    histogram      integer[]                                      not null,
+    refreshed_at   timestamp default timezone('utc'::text, now()) not null,
+    constraint solution_stats_pk
+        primary key (task_id, language_id, metric)
+);
+
+alter table solution
+    add column runtime_percentile double precision,
+    add column memory_percentile  double precision;
+
+create index solution_accepted_idx
+    on solution (task_id, language_id)
+    where status = 'completed';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index solution_accepted_idx;
+
+alter table solution
+    drop column memory_percentile,
+    drop column runtime_percentile;
+
+drop table solution_stats;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+create table plagiarism_check
+(
+    id              uuid      default gen_random_uuid()            not null
+        constraint plagiarism_check_pk
+            primary key,
+    task_id         uuid                                           not null
+        constraint plagiarism_check_task_id_fk
+            references task
+            on delete cascade,
+    author_id       uuid                                           not null
+        constraint plagiarism_check_author_id_fk
+            references "user"
+            on delete cascade,
+    status          text                                           not null,
+    threshold       double precision                               not null,
+    solutions_count integer   default 0                            not null,
+    pairs_count     integer   default 0                            not null,
+    error           text,
+    created_at      timestamp default timezone('utc'::text, now()) not null,
+    finished_at     timestamp
+);
+
+create index plagiarism_check_task_id_index
+    on plagiarism_check (task_id);
+
+create table plagiarism_pair
+(
+    check_id      uuid                   not null
+        constraint plagiarism_pair_check_id_fk
+            references plagiarism_check
+            on delete cascade,
+    solution_a_id uuid                   not null
+        constraint plagiarism_pair_solution_a_id_fk
+            references solution
+            on delete cascade,
+    solution_b_id uuid                   not null
+        constraint plagiarism_pair_solution_b_id_fk
+            references solution
+            on delete cascade,
+    similarity_a  double precision       not null,
+    similarity_b  double precision       not null,
+    similarity    double precision       not null,
+    matches       jsonb default '[]'::jsonb not null,
+    constraint plagiarism_pair_pk
+        primary key (check_id, solution_a_id, solution_b_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table plagiarism_pair;
+
+drop table plagiarism_check;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tokens of submissions are prefixed with name of the judge node which runs them
+alter table solution_result
+    alter column submission_token type text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+update solution_result
+set submission_token = regexp_replace(submission_token, '^.*\.', '')
+where submission_token like '%.%';
+
+alter table solution_result
+    alter column submission_token type uuid using submission_token::uuid;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution_result
+    add column stdout_truncated boolean   default false                          not null,
+    add column stderr_truncated boolean   default false                          not null,
+    add column created_at       timestamp default timezone('utc'::text, now()) not null;
+
+create index solution_result_created_at_index
+    on solution_result (created_at);
+
+create table solution_output
+(
+    solution_id  uuid                                           not null,
+    test_case_id uuid                                           not null,
+    stdout       bytea,
+    stderr       bytea,
+    compressed   boolean   default false                        not null,
+    created_at   timestamp default timezone('utc'::text, now()) not null,
+    constraint solution_output_pk
+        primary key (solution_id, test_case_id),
+    constraint solution_output_solution_result_fk
+        foreign key (solution_id, test_case_id) references solution_result (solution_id, test_case_id)
+            on delete cascade
+);
+
+create index solution_output_created_at_index
+    on solution_output (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table solution_output;
+
+drop index solution_result_created_at_index;
+
+alter table solution_result
+    drop column created_at,
+    drop column stderr_truncated,
+    drop column stdout_truncated;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table test_case
+    add column file_backed boolean default false not null,
+    add column input_size  bigint  default 0     not null,
+    add column output_size bigint  default 0     not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete
+from test_case
+where file_backed;
+
+alter table test_case
+    drop column output_size,
+    drop column input_size,
+    drop column file_backed;
+-- +goose StatementEnd
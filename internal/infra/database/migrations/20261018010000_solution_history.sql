@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution
+    add column created_at timestamp default timezone('utc'::text, now()) not null;
+
+create index solution_task_id_created_at_index
+    on solution (task_id, created_at);
+
+create index solution_user_id_created_at_index
+    on solution (user_id, created_at, id);
+
+create index solution_created_at_index
+    on solution (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index solution_created_at_index;
+
+drop index solution_user_id_created_at_index;
+
+drop index solution_task_id_created_at_index;
+
+alter table solution
+    drop column created_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution
+    add column validation boolean default false not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution
+    drop column validation;
+-- +goose StatementEnd
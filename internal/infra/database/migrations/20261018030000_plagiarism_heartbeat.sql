@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table plagiarism_check
+    add column heartbeat_at timestamp default timezone('utc'::text, now()) not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table plagiarism_check
+    drop column heartbeat_at;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table test_case
+    add column archived_at timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from test_case
+where archived_at is not null;
+
+alter table test_case
+    drop column archived_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution_queue
+    add column claimed_by text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution_queue
+    drop column claimed_by;
+-- +goose StatementEnd
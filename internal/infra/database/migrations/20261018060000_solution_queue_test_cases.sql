@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table solution_queue
+    add column test_case_ids uuid[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table solution_queue
+    drop column test_case_ids;
+-- +goose StatementEnd
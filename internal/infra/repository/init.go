@@ -5,8 +5,15 @@ import (
 	"lcode/internal/infra/repository/article"
 	"lcode/internal/infra/repository/auth"
 	"lcode/internal/infra/repository/comment"
+	"lcode/internal/infra/repository/language"
+	"lcode/internal/infra/repository/plagiarism"
+	"lcode/internal/infra/repository/rejudge"
 	"lcode/internal/infra/repository/solution"
+	solutionEvent "lcode/internal/infra/repository/solution_event"
+	solutionOutput "lcode/internal/infra/repository/solution_output"
+	solutionQueue "lcode/internal/infra/repository/solution_queue"
 	solutionResult "lcode/internal/infra/repository/solution_result"
+	solutionStats "lcode/internal/infra/repository/solution_stats"
 	"lcode/internal/infra/repository/task"
 	taskTemplate "lcode/internal/infra/repository/task_template"
 	testCase "lcode/internal/infra/repository/test_case"
@@ -27,9 +34,16 @@ type (
 		TestCase       *testCase.Repository
 		Solution       *solution.Repository
 		SolutionResult *solutionResult.Repository
+		SolutionOutput *solutionOutput.Repository
+		SolutionEvent  *solutionEvent.Repository
+		SolutionQueue  *solutionQueue.Repository
 		UserProgress   *userProgress.Repository
 		Article        *article.Repository
 		Comment        *comment.Repository
+		Rejudge        *rejudge.Repository
+		Language       *language.Repository
+		SolutionStats  *solutionStats.Repository
+		Plagiarism     *plagiarism.Repository
 	}
 )
 
@@ -41,8 +55,15 @@ func New(p *InitParams) *Repositories {
 		TestCase:       testCase.New(p.Config, p.DB),
 		Solution:       solution.New(p.DB),
 		SolutionResult: solutionResult.New(p.DB),
+		SolutionOutput: solutionOutput.New(p.DB),
+		SolutionEvent:  solutionEvent.New(p.DB),
+		SolutionQueue:  solutionQueue.New(p.DB),
 		UserProgress:   userProgress.New(p.DB),
 		Article:        article.New(p.Config, p.DB),
 		Comment:        comment.New(p.Config, p.DB),
+		Rejudge:        rejudge.New(p.DB),
+		Language:       language.New(p.DB),
+		SolutionStats:  solutionStats.New(p.DB),
+		Plagiarism:     plagiarism.New(p.DB),
 	}
 }
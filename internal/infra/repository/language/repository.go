@@ -0,0 +1,160 @@
+package language
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+)
+
+const languageColumns = `id, name, judge_name, editor_mode, default_template, compiler_options,
+	       command_line_arguments, enabled, compiled, created_at, updated_at`
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+func (r *Repository) Create(ctx context.Context, entity domain.CreateLanguageEntity) (l domain.Language, err error) {
+	sq := sql_query_maker.NewQueryMaker(9)
+
+	sq.Add(`
+			INSERT INTO language (id, name, judge_name, editor_mode, default_template, compiler_options,
+			                      command_line_arguments, enabled, compiled)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING `+languageColumns,
+		entity.ID,
+		entity.Name,
+		entity.JudgeName,
+		entity.EditorMode,
+		entity.DefaultTemplate,
+		entity.CompilerOptions,
+		entity.CommandLineArguments,
+		entity.Enabled,
+		entity.Compiled,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Get(ctx, r.db.TxOrDB(ctx), &l, query, args...)
+	if err == nil {
+		return l, nil
+	}
+
+	var pgError *pgconn.PgError
+	if ok := errors.As(err, &pgError); ok && pgError.Code == postgres.ERRCODE_UNIQUE_VIOLATION {
+		err = &struct_errors.ErrExist{Err: err, Msg: "Language already registered"}
+	}
+
+	return l, errors.Wrap(err, "Create language repo")
+}
+
+func (r *Repository) Update(ctx context.Context, dto domain.LanguageUpdateDTO) (l domain.Language, err error) {
+	sq := sql_query_maker.NewQueryMaker(8)
+
+	languages := []domain.Language{}
+
+	sq.Add("UPDATE language SET")
+
+	if dto.Input.Name != nil {
+		sq.Add("name = ?,", *dto.Input.Name)
+	}
+
+	if dto.Input.EditorMode != nil {
+		sq.Add("editor_mode = ?,", *dto.Input.EditorMode)
+	}
+
+	if dto.Input.DefaultTemplate != nil {
+		sq.Add("default_template = ?,", *dto.Input.DefaultTemplate)
+	}
+
+	if dto.Input.CompilerOptions != nil {
+		sq.Add("compiler_options = ?,", *dto.Input.CompilerOptions)
+	}
+
+	if dto.Input.CommandLineArguments != nil {
+		sq.Add("command_line_arguments = ?,", *dto.Input.CommandLineArguments)
+	}
+
+	if dto.Input.Enabled != nil {
+		sq.Add("enabled = ?,", *dto.Input.Enabled)
+	}
+
+	if dto.Input.Compiled != nil {
+		sq.Add("compiled = ?,", *dto.Input.Compiled)
+	}
+
+	sq.Add("updated_at = timezone('utc'::text, now())")
+
+	sq.Where("id = ?", dto.ID)
+
+	sq.Add("RETURNING " + languageColumns)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &languages, query, args...)
+	if err != nil {
+		return l, errors.Wrap(err, "Update language repo")
+	}
+
+	if len(languages) < 1 {
+		err = struct_errors.NewErrNotFound("Language not found", nil)
+
+		return l, errors.Wrap(err, "Update language repo")
+	}
+
+	return languages[0], nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id domain.LanguageType) (l domain.Language, err error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	languages := []domain.Language{}
+
+	sq.Add("SELECT "+languageColumns+" FROM language WHERE id = ?", id)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &languages, query, args...)
+	if err != nil {
+		return l, errors.Wrap(err, "GetByID language repo")
+	}
+
+	if len(languages) < 1 {
+		err = struct_errors.NewErrNotFound("Language not found", nil)
+
+		return l, errors.Wrap(err, "GetByID language repo")
+	}
+
+	return languages[0], nil
+}
+
+func (r *Repository) GetAll(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error) {
+	sq := sql_query_maker.NewQueryMaker(0)
+
+	languages := []domain.Language{}
+
+	sq.Add("SELECT " + languageColumns + " FROM language")
+
+	if dto.OnlyEnabled {
+		sq.Where("enabled")
+	}
+
+	sq.Add("ORDER BY name")
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &languages, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAll language repo")
+	}
+
+	return languages, nil
+}
@@ -0,0 +1,255 @@
+package plagiarism
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"time"
+)
+
+const checkColumns = `id, task_id, author_id, status, threshold, solutions_count, pairs_count, error, created_at, finished_at`
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+func (r *Repository) Create(
+	ctx context.Context,
+	entity domain.CreatePlagiarismCheckEntity,
+) (check domain.PlagiarismCheck, err error) {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	checks := []domain.PlagiarismCheck{}
+
+	sq.Add(`
+			INSERT INTO plagiarism_check (task_id, author_id, status, threshold)
+			SELECT t.id, ?, ?, ?
+			FROM task t
+			WHERE t.id = ?
+			RETURNING `+checkColumns,
+		entity.AuthorID,
+		domain.PlagiarismCheckStatusRunning,
+		entity.Threshold,
+		entity.TaskID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &checks, query, args...)
+	if err != nil {
+		return check, errors.Wrap(err, "Create plagiarism repo")
+	}
+
+	if len(checks) < 1 {
+		err = struct_errors.NewErrNotFound("Task not found", nil)
+
+		return check, errors.Wrap(err, "Create plagiarism repo")
+	}
+
+	return checks[0], nil
+}
+
+// Finish sets the final status of the check and counts its pairs.
+func (r *Repository) Finish(ctx context.Context, entity domain.FinishPlagiarismCheckEntity) error {
+	sq := sql_query_maker.NewQueryMaker(5)
+
+	sq.Add(`
+			UPDATE plagiarism_check
+			SET status          = ?,
+			    solutions_count = ?,
+			    error           = ?,
+			    pairs_count     = (SELECT COUNT(*) FROM plagiarism_pair WHERE check_id = ?),
+			    finished_at     = timezone('utc'::text, now())
+			WHERE id = ?`,
+		entity.Status,
+		entity.SolutionsCount,
+		entity.Error,
+		entity.ID,
+		entity.ID,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Finish plagiarism repo")
+	}
+
+	return nil
+}
+
+// Heartbeat marks the running check as alive.
+func (r *Repository) Heartbeat(ctx context.Context, id string) error {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(`
+			UPDATE plagiarism_check
+			SET heartbeat_at = timezone('utc'::text, now())
+			WHERE id = ? AND status = ?`,
+		id,
+		domain.PlagiarismCheckStatusRunning,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Heartbeat plagiarism repo")
+	}
+
+	return nil
+}
+
+// FailRunning marks running checks without heartbeat during staleAfter as
+// failed, their instance was stopped or crashed.
+func (r *Repository) FailRunning(ctx context.Context, reason string, staleAfter time.Duration) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	sq.Add(`
+			UPDATE plagiarism_check
+			SET status      = ?,
+			    error       = ?,
+			    finished_at = timezone('utc'::text, now())
+			WHERE status = ?
+			  AND heartbeat_at < timezone('utc'::text, now()) - make_interval(secs => ?::double precision)`,
+		domain.PlagiarismCheckStatusFailed,
+		reason,
+		domain.PlagiarismCheckStatusRunning,
+		staleAfter.Seconds(),
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "FailRunning plagiarism repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (r *Repository) AddPairs(ctx context.Context, checkID string, pairs []domain.PlagiarismPair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	sq := sql_query_maker.NewQueryMaker(7)
+
+	solutionsA := make([]string, 0, len(pairs))
+	solutionsB := make([]string, 0, len(pairs))
+	similaritiesA := make([]float64, 0, len(pairs))
+	similaritiesB := make([]float64, 0, len(pairs))
+	similarities := make([]float64, 0, len(pairs))
+	matches := make([]string, 0, len(pairs))
+
+	for i := range pairs {
+		m, err := json.Marshal(pairs[i].Matches)
+		if err != nil {
+			return errors.Wrap(err, "AddPairs plagiarism repo")
+		}
+
+		solutionsA = append(solutionsA, pairs[i].SolutionAID)
+		solutionsB = append(solutionsB, pairs[i].SolutionBID)
+		similaritiesA = append(similaritiesA, pairs[i].SimilarityA)
+		similaritiesB = append(similaritiesB, pairs[i].SimilarityB)
+		similarities = append(similarities, pairs[i].Similarity)
+		matches = append(matches, string(m))
+	}
+
+	sq.Add(`
+			INSERT INTO plagiarism_pair (check_id, solution_a_id, solution_b_id, similarity_a, similarity_b, similarity, matches)
+			SELECT ?, p.solution_a_id, p.solution_b_id, p.similarity_a, p.similarity_b, p.similarity, p.matches::jsonb
+			FROM unnest(?::uuid[], ?::uuid[], ?::double precision[], ?::double precision[], ?::double precision[], ?::text[])
+			         AS p (solution_a_id, solution_b_id, similarity_a, similarity_b, similarity, matches)`,
+		checkID,
+		solutionsA,
+		solutionsB,
+		similaritiesA,
+		similaritiesB,
+		similarities,
+		matches,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "AddPairs plagiarism repo")
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByID(
+	ctx context.Context,
+	dto domain.GetPlagiarismCheckDTO,
+) (check domain.PlagiarismCheck, err error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	checks := []domain.PlagiarismCheck{}
+
+	sq.Add(`
+			SELECT `+checkColumns+`
+			FROM plagiarism_check
+			WHERE id = ? AND task_id = ?`,
+		dto.CheckID,
+		dto.TaskID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &checks, query, args...)
+	if err != nil {
+		return check, errors.Wrap(err, "GetByID plagiarism repo")
+	}
+
+	if len(checks) < 1 {
+		err = struct_errors.NewErrNotFound("Plagiarism check not found", nil)
+
+		return check, errors.Wrap(err, "GetByID plagiarism repo")
+	}
+
+	return checks[0], nil
+}
+
+// Pairs returns pairs of the check, the most similar first.
+func (r *Repository) Pairs(ctx context.Context, checkID string) ([]domain.PlagiarismPair, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	pairs := []domain.PlagiarismPair{}
+
+	sq.Add(`
+			SELECT p.solution_a_id,
+			       sa.user_id AS user_a_id,
+			       p.solution_b_id,
+			       sb.user_id AS user_b_id,
+			       p.similarity_a,
+			       p.similarity_b,
+			       p.similarity,
+			       p.matches
+			FROM plagiarism_pair p
+			         JOIN solution sa ON sa.id = p.solution_a_id
+			         JOIN solution sb ON sb.id = p.solution_b_id
+			WHERE p.check_id = ?
+			ORDER BY p.similarity DESC, p.solution_a_id, p.solution_b_id`,
+		checkID,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &pairs, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Pairs plagiarism repo")
+	}
+
+	return pairs, nil
+}
@@ -0,0 +1,221 @@
+package rejudge
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"time"
+)
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+func (r *Repository) Create(ctx context.Context, entity domain.CreateRejudgeEntity) (rej domain.Rejudge, err error) {
+	sq := sql_query_maker.NewQueryMaker(6)
+
+	rejudges := []domain.Rejudge{}
+
+	statuses := make([]string, 0, len(entity.Filter.Statuses))
+	for i := range entity.Filter.Statuses {
+		statuses = append(statuses, string(entity.Filter.Statuses[i]))
+	}
+
+	sq.Add(`
+			INSERT INTO rejudge (task_id, author_id, statuses, language_id, created_from, created_to)
+			SELECT t.id, ?, ?::text[], ?::integer, ?::timestamp, ?::timestamp
+			FROM task t
+			WHERE t.id = ?
+			RETURNING id, task_id, author_id, statuses, language_id, created_from, created_to, created_at`,
+		entity.AuthorID,
+		statuses,
+		entity.Filter.LanguageID,
+		timeArg(entity.Filter.CreatedFrom),
+		timeArg(entity.Filter.CreatedTo),
+		entity.TaskID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &rejudges, query, args...)
+	if err != nil {
+		return rej, errors.Wrap(err, "Create rejudge repo")
+	}
+
+	if len(rejudges) < 1 {
+		err = struct_errors.NewErrNotFound("Task not found", nil)
+
+		return rej, errors.Wrap(err, "Create rejudge repo")
+	}
+
+	return rejudges[0], nil
+}
+
+// AddSolutions attaches solutions of the task matching the filter of the
+// rejudge and remembers their current verdicts. Solutions which are already
+// waiting in the queue are skipped.
+func (r *Repository) AddSolutions(ctx context.Context, rej domain.Rejudge) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(6)
+
+	statuses := make([]string, 0, len(rej.Statuses))
+	for i := range rej.Statuses {
+		statuses = append(statuses, string(rej.Statuses[i]))
+	}
+
+	sq.Add(`
+			INSERT INTO rejudge_solution (rejudge_id, solution_id, old_status, old_passed_count, old_score)
+			SELECT ?, s.id, s.status, s.passed_count, s.score
+			FROM solution s
+			WHERE s.task_id = ?
+			  AND s.status = ANY (?::text[])
+			  AND NOT EXISTS (SELECT 1 FROM solution_queue q WHERE q.solution_id = s.id)`,
+		rej.ID,
+		rej.TaskID,
+		statuses,
+	)
+
+	if rej.LanguageID != nil {
+		sq.Add("AND s.language_id = ?", *rej.LanguageID)
+	}
+
+	if rej.CreatedFrom != nil {
+		sq.Add("AND s.created_at >= ?", timeArg(rej.CreatedFrom))
+	}
+
+	if rej.CreatedTo != nil {
+		sq.Add("AND s.created_at < ?", timeArg(rej.CreatedTo))
+	}
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "AddSolutions rejudge repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, dto domain.GetRejudgeDTO) (rej domain.Rejudge, err error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	rejudges := []domain.Rejudge{}
+
+	sq.Add(`
+			SELECT id, task_id, author_id, statuses, language_id, created_from, created_to, created_at
+			FROM rejudge
+			WHERE id = ? AND task_id = ?`,
+		dto.RejudgeID,
+		dto.TaskID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &rejudges, query, args...)
+	if err != nil {
+		return rej, errors.Wrap(err, "GetByID rejudge repo")
+	}
+
+	if len(rejudges) < 1 {
+		err = struct_errors.NewErrNotFound("Rejudge not found", nil)
+
+		return rej, errors.Wrap(err, "GetByID rejudge repo")
+	}
+
+	return rejudges[0], nil
+}
+
+// Summary counts solutions of the rejudge, verdicts are compared only for
+// finished ones.
+func (r *Repository) Summary(ctx context.Context, rejudgeID string) (s domain.RejudgeSummary, err error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+			SELECT COUNT(*)                                             AS total,
+			       COUNT(finished_at)                                   AS finished,
+			       COUNT(*) FILTER (WHERE new_status <> old_status
+			                           OR new_score <> old_score)       AS changed,
+			       COUNT(*) FILTER (WHERE new_score > old_score)        AS improved,
+			       COUNT(*) FILTER (WHERE new_score < old_score)        AS worsened
+			FROM rejudge_solution
+			WHERE rejudge_id = ?`,
+		rejudgeID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Get(ctx, r.db.TxOrDB(ctx), &s, query, args...)
+	if err != nil {
+		return s, errors.Wrap(err, "Summary rejudge repo")
+	}
+
+	return s, nil
+}
+
+func (r *Repository) Transitions(ctx context.Context, rejudgeID string) ([]domain.RejudgeTransition, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	transitions := []domain.RejudgeTransition{}
+
+	sq.Add(`
+			SELECT old_status, new_status, COUNT(*) AS count
+			FROM rejudge_solution
+			WHERE rejudge_id = ? AND finished_at IS NOT NULL
+			GROUP BY old_status, new_status
+			ORDER BY old_status, new_status`,
+		rejudgeID,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &transitions, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Transitions rejudge repo")
+	}
+
+	return transitions, nil
+}
+
+// FinishSolution stores the new verdict of the solution in the unfinished
+// rejudge it belongs to, if there is one.
+func (r *Repository) FinishSolution(ctx context.Context, sol domain.Solution) error {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	sq.Add(`
+			UPDATE rejudge_solution
+			SET new_status       = ?,
+			    new_passed_count = ?,
+			    new_score        = ?,
+			    finished_at      = timezone('utc'::text, now())
+			WHERE solution_id = ? AND finished_at IS NULL`,
+		sol.Status,
+		sol.PassedCount,
+		sol.Score,
+		sol.Id,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "FinishSolution rejudge repo")
+	}
+
+	return nil
+}
+
+func timeArg(t *domain.IntTime) any {
+	if t == nil {
+		return nil
+	}
+
+	return time.Time(*t).UTC()
+}
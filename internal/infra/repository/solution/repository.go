@@ -2,11 +2,14 @@ package solution
 
 import (
 	"context"
+	"fmt"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
 	"github.com/pkg/errors"
 	"lcode/internal/domain"
+	"lcode/pkg/db"
 	"lcode/pkg/postgres"
+	"time"
 )
 
 func New(db *postgres.DbManager) *Repository {
@@ -21,14 +24,15 @@ func (r *Repository) Create(ctx context.Context, entity domain.CreateSolutionEnt
 	sq := sql_query_maker.NewQueryMaker(7)
 
 	sq.Add(
-		`INSERT INTO solution (user_id, code, status, task_id, language_id) 
-			   VALUES (?, ?, ?, ?, ?) 
-               RETURNING id, user_id, code, status, runtime, memory, task_id, language_id`,
+		`INSERT INTO solution (user_id, code, status, task_id, language_id, validation) 
+			   VALUES (?, ?, ?, ?, ?, ?) 
+               RETURNING id, user_id, code, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile`,
 		entity.User.ID,
 		entity.Code,
 		entity.Status,
 		entity.TaskID,
 		entity.LanguageID,
+		entity.Validation,
 	)
 
 	query, args := sq.Make()
@@ -42,12 +46,13 @@ func (r *Repository) Create(ctx context.Context, entity domain.CreateSolutionEnt
 }
 
 func (r *Repository) Update(ctx context.Context, dto domain.UpdateSolutionDTO) (sol domain.Solution, err error) {
-	sq := sql_query_maker.NewQueryMaker(3)
+	sq := sql_query_maker.NewQueryMaker(9)
 
 	sq.Add(`UPDATE solution SET`)
 
 	if dto.Status != nil {
-		sq.Add("status = ?,", *dto.Status)
+		// percentiles are computed again on the next refresh of solution stats
+		sq.Add("status = ?, runtime_percentile = NULL, memory_percentile = NULL,", *dto.Status)
 	}
 
 	if dto.Runtime != nil {
@@ -58,8 +63,28 @@ func (r *Repository) Update(ctx context.Context, dto domain.UpdateSolutionDTO) (
 		sq.Add("memory = ?,", *dto.Memory)
 	}
 
+	if dto.PassedCount != nil {
+		sq.Add("passed_count = ?,", *dto.PassedCount)
+	}
+
+	if dto.TotalCount != nil {
+		sq.Add("total_count = ?,", *dto.TotalCount)
+	}
+
+	if dto.Score != nil {
+		sq.Add("score = ?,", *dto.Score)
+	}
+
+	if dto.ErrorReason != nil {
+		sq.Add("error_reason = NULLIF(?::text, ''),", *dto.ErrorReason)
+	}
+
+	if dto.CompileOutput != nil {
+		sq.Add("compile_output = NULLIF(?::text, ''),", *dto.CompileOutput)
+	}
+
 	sq.Where("id = ?", dto.ID)
-	sq.Add("RETURNING id, user_id, code, status, runtime, memory, task_id, language_id")
+	sq.Add("RETURNING id, user_id, code, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile")
 
 	query, args := sq.Make()
 
@@ -77,7 +102,7 @@ func (r *Repository) SolutionsByUserAndTask(ctx context.Context, dto domain.GetS
 	results := []domain.Solution{}
 
 	sq.Add(`
-			SELECT id, user_id, code, status, runtime, memory, task_id, language_id
+			SELECT id, user_id, code, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile
 			FROM solution
 			WHERE user_id = ? AND task_id = ?`,
 		dto.User.ID,
@@ -94,11 +119,81 @@ func (r *Repository) SolutionsByUserAndTask(ctx context.Context, dto domain.GetS
 	return results, nil
 }
 
+// List returns page of solutions matching the filter ordered by creation time,
+// solutions created at the same time are ordered by id. Validation runs of
+// admins are not listed.
+func (r *Repository) List(ctx context.Context, params domain.SolutionParams) ([]domain.Solution, error) {
+	sq := sql_query_maker.NewQueryMaker(9)
+
+	results := []domain.Solution{}
+
+	sq.Add(`
+			SELECT id, user_id, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile
+			FROM solution s
+			WHERE NOT s.validation`,
+	)
+
+	if params.Filter.UserID != nil {
+		sq.Add("AND s.user_id = ?", *params.Filter.UserID)
+	}
+
+	if params.Filter.TaskID != nil {
+		sq.Add("AND s.task_id = ?", *params.Filter.TaskID)
+	}
+
+	if params.Filter.LanguageID != nil {
+		sq.Add("AND s.language_id = ?", *params.Filter.LanguageID)
+	}
+
+	if len(params.Filter.Statuses) != 0 {
+		statuses := make([]string, 0, len(params.Filter.Statuses))
+		for i := range params.Filter.Statuses {
+			statuses = append(statuses, string(params.Filter.Statuses[i]))
+		}
+
+		sq.Add("AND s.status = ANY (?::text[])", statuses)
+	}
+
+	if params.Filter.CreatedFrom != nil {
+		sq.Add("AND s.created_at >= ?", time.Time(*params.Filter.CreatedFrom).UTC())
+	}
+
+	if params.Filter.CreatedTo != nil {
+		sq.Add("AND s.created_at < ?", time.Time(*params.Filter.CreatedTo).UTC())
+	}
+
+	sort, order := db.DESC, "DESC"
+	if params.Sort == db.ASC {
+		sort, order = db.ASC, "ASC"
+	}
+
+	if params.Pagination.AfterID != nil {
+		sq.Add(
+			fmt.Sprintf(
+				"AND (s.created_at, s.id) %s (SELECT created_at, id FROM solution WHERE id = ?)",
+				db.GetLetterGreaterOrLessBySortType(sort),
+			),
+			*params.Pagination.AfterID,
+		)
+	}
+
+	sq.Add(fmt.Sprintf("ORDER BY s.created_at %[1]s, s.id %[1]s LIMIT ?", order), params.Pagination.Limit)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &results, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "List solution repo")
+	}
+
+	return results, nil
+}
+
 func (r *Repository) SolutionByID(ctx context.Context, id string) (sol domain.Solution, err error) {
 	sq := sql_query_maker.NewQueryMaker(1)
 
 	sq.Add(`
-			SELECT id, user_id, code, status, runtime, memory, task_id, language_id
+			SELECT id, user_id, code, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile
 			FROM solution
 			WHERE id = ?`,
 		id,
@@ -113,3 +208,28 @@ func (r *Repository) SolutionByID(ctx context.Context, id string) (sol domain.So
 
 	return sol, nil
 }
+
+// LatestAcceptedByTask returns the latest accepted solution of every user of
+// the task in every language, validation runs of admins are not counted.
+func (r *Repository) LatestAcceptedByTask(ctx context.Context, taskID string) ([]domain.Solution, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	results := []domain.Solution{}
+
+	sq.Add(`
+			SELECT DISTINCT ON (user_id, language_id) id, user_id, code, status, runtime, memory, task_id, language_id, passed_count, total_count, score, created_at, error_reason, compile_output, runtime_percentile, memory_percentile
+			FROM solution
+			WHERE task_id = ? AND status = 'completed' AND NOT validation
+			ORDER BY user_id, language_id, created_at DESC`,
+		taskID,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &results, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "LatestAcceptedByTask solution repo")
+	}
+
+	return results, nil
+}
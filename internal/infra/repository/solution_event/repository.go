@@ -0,0 +1,77 @@
+package solution_event
+
+import (
+	"context"
+	"encoding/json"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+)
+
+// channel of Postgres notifications with events of solutions
+const channel = "solution_events"
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+// Publish sends the notification to listeners of every instance. Published in
+// a transaction it is delivered on commit, notifications are delivered in the
+// order they are committed.
+func (r *Repository) Publish(ctx context.Context, n domain.SolutionEventNotification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "Publish solution_event repo")
+	}
+
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(`SELECT pg_notify(?, ?)`, channel, string(payload))
+
+	query, args := sq.Make()
+
+	_, err = r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Publish solution_event repo")
+	}
+
+	return nil
+}
+
+// Listen passes notifications to handle until ctx is done or the connection
+// fails. The connection is taken out of the pool, so it does not go back to
+// the pool still listening.
+func (r *Repository) Listen(ctx context.Context, handle func(domain.SolutionEventNotification)) error {
+	poolConn, err := r.db.GetDb().Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Listen solution_event repo")
+	}
+
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+channel)
+	if err != nil {
+		return errors.Wrap(err, "Listen solution_event repo")
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Listen solution_event repo")
+		}
+
+		var n domain.SolutionEventNotification
+
+		if err = json.Unmarshal([]byte(notification.Payload), &n); err != nil {
+			continue
+		}
+
+		handle(n)
+	}
+}
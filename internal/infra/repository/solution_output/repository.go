@@ -0,0 +1,108 @@
+package solution_output
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"time"
+)
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+// CreateBatch stores outputs replacing outputs of previous judging of the
+// same test cases.
+func (r *Repository) CreateBatch(ctx context.Context, entities ...domain.SolutionOutputEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	sq := sql_query_maker.NewQueryMaker(len(entities) * 5)
+
+	sq.Add(`INSERT INTO solution_output (solution_id, test_case_id, stdout, stderr, compressed)`)
+
+	for i := range entities {
+		sq.Values(
+			entities[i].SolutionID,
+			entities[i].TestCaseID,
+			entities[i].Stdout,
+			entities[i].Stderr,
+			entities[i].Compressed,
+		)
+	}
+
+	sq.Add(`
+			ON CONFLICT (solution_id, test_case_id) DO UPDATE
+			SET stdout     = excluded.stdout,
+			    stderr     = excluded.stderr,
+			    compressed = excluded.compressed,
+			    created_at = timezone('utc'::text, now())`,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "CreateBatch solution_output repo")
+	}
+
+	return nil
+}
+
+func (r *Repository) Get(ctx context.Context, solutionID, testCaseID string) (entity domain.SolutionOutputEntity, err error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	entities := []domain.SolutionOutputEntity{}
+
+	sq.Add(`
+			SELECT solution_id, test_case_id, stdout, stderr, compressed
+			FROM solution_output
+			WHERE solution_id = ? AND test_case_id = ?`,
+		solutionID,
+		testCaseID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &entities, query, args...)
+	if err != nil {
+		return entity, errors.Wrap(err, "Get solution_output repo")
+	}
+
+	if len(entities) < 1 {
+		err = struct_errors.NewErrNotFound("Full output is not available anymore", nil)
+
+		return entity, errors.Wrap(err, "Get solution_output repo")
+	}
+
+	return entities[0], nil
+}
+
+// DeleteExpired deletes outputs stored longer than ttl ago.
+func (r *Repository) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+			DELETE FROM solution_output
+			WHERE created_at < timezone('utc'::text, now()) - make_interval(secs => ?::double precision)`,
+		ttl.Seconds(),
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "DeleteExpired solution_output repo")
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,457 @@
+package solution_queue
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"time"
+)
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+// currentTestCaseIDs selects ids of current test cases of the task of the
+// solution s in the order they are judged.
+const currentTestCaseIDs = `
+		(SELECT array_agg(tc.id ORDER BY tc.created_at)
+		 FROM test_case tc
+		 WHERE tc.task_id = s.task_id
+		   AND tc.archived_at IS NULL)`
+
+// Push enqueues the solution, current test cases of its task are pinned to the
+// item, so the solution is judged on them even if they are replaced meanwhile.
+func (r *Repository) Push(ctx context.Context, entity domain.PushSolutionQueueEntity) error {
+	sq := sql_query_maker.NewQueryMaker(3)
+
+	sq.Add(
+		`
+		INSERT INTO solution_queue (solution_id, user_id, lane, test_case_ids)
+		SELECT s.id, ?, ?, `+currentTestCaseIDs+`
+		FROM solution s
+		WHERE s.id = ?
+		`,
+		entity.UserID,
+		entity.Lane,
+		entity.SolutionID,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Push solution_queue repo")
+	}
+
+	return nil
+}
+
+// PushRejudge pushes every solution of the rejudge to the rejudge lane.
+func (r *Repository) PushRejudge(ctx context.Context, rejudgeID string) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(
+		`
+		INSERT INTO solution_queue (solution_id, user_id, lane, test_case_ids)
+		SELECT s.id, s.user_id, ?, `+currentTestCaseIDs+`
+		FROM rejudge_solution rs
+		    JOIN solution s ON s.id = rs.solution_id
+		WHERE rs.rejudge_id = ?
+		`,
+		domain.SolutionQueueLaneRejudge,
+		rejudgeID,
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "PushRejudge solution_queue repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// Claim locks up to limit unclaimed items, marks them as claimed by the owner
+// and increments their attempts counter. Items are taken from lanes by priority,
+// inside a lane the n-th item of every user goes before the (n+1)-th item of
+// any user, so one user can not hold the queue. Turns are counted over claimed
+// items too, otherwise the next item of the user who was just served would be
+// the first one again.
+func (r *Repository) Claim(ctx context.Context, owner string, limit int) ([]domain.SolutionQueueItem, error) {
+	sq := sql_query_maker.NewQueryMaker(3)
+
+	items := []domain.SolutionQueueItem{}
+
+	lanes := make([]string, 0, len(domain.SolutionQueueLanes))
+	for i := range domain.SolutionQueueLanes {
+		lanes = append(lanes, string(domain.SolutionQueueLanes[i]))
+	}
+
+	sq.Add(`
+			UPDATE solution_queue
+			SET claimed_at = timezone('utc'::text, now()), claimed_by = ?, attempts = attempts + 1
+			WHERE solution_id IN (
+			    SELECT q.solution_id
+			    FROM solution_queue q
+			        JOIN (
+			            SELECT solution_id,
+			                   array_position(?::text[], lane)                                     AS priority,
+			                   row_number() OVER (PARTITION BY lane, user_id ORDER BY created_at) AS turn
+			            FROM solution_queue
+			        ) r ON r.solution_id = q.solution_id
+			    WHERE q.claimed_at IS NULL
+			    ORDER BY r.priority, r.turn, q.created_at
+			    LIMIT ?
+			    FOR UPDATE OF q SKIP LOCKED
+			)
+			RETURNING solution_id, user_id, lane, attempts, claimed_at, created_at, test_case_ids::text[] AS test_case_ids`,
+		owner,
+		lanes,
+		limit,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &items, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claim solution_queue repo")
+	}
+
+	return items, nil
+}
+
+// Release releases the claim of the item. Judging which was interrupted, e.g.
+// by shutdown, is not counted as an attempt.
+func (r *Repository) Release(ctx context.Context, solutionID string, interrupted bool) error {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(`
+			UPDATE solution_queue
+			SET claimed_at = NULL,
+			    claimed_by = NULL,
+			    attempts   = CASE WHEN ?::boolean THEN greatest(attempts - 1, 0) ELSE attempts END
+			WHERE solution_id = ?`,
+		interrupted,
+		solutionID,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Release solution_queue repo")
+	}
+
+	return nil
+}
+
+// ReleaseOwned releases claims of the owner like Release. Claims of other
+// instances and of items waiting for judge callbacks are kept.
+func (r *Repository) ReleaseOwned(ctx context.Context, owner string, interrupted bool) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(`
+			UPDATE solution_queue
+			SET claimed_at = NULL,
+			    claimed_by = NULL,
+			    attempts   = CASE WHEN ?::boolean THEN greatest(attempts - 1, 0) ELSE attempts END
+			WHERE claimed_by = ?`,
+		interrupted,
+		owner,
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "ReleaseOwned solution_queue repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// Disown keeps the item claimed without an owner: it waits for judge
+// callbacks, which are handled by any instance, so it is released only by
+// the watchdog when they are lost.
+func (r *Repository) Disown(ctx context.Context, solutionID string) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`UPDATE solution_queue SET claimed_by = NULL WHERE solution_id = ?`, solutionID)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Disown solution_queue repo")
+	}
+
+	return nil
+}
+
+// ReleaseStuck releases items claimed longer than deadline ago and returns
+// them, their attempts counters are kept.
+func (r *Repository) ReleaseStuck(ctx context.Context, deadline time.Duration) ([]domain.SolutionQueueItem, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	items := []domain.SolutionQueueItem{}
+
+	sq.Add(`
+			UPDATE solution_queue
+			SET claimed_at = NULL, claimed_by = NULL
+			WHERE claimed_at < timezone('utc'::text, now()) - make_interval(secs => ?::double precision)
+			RETURNING solution_id, user_id, lane, attempts, claimed_at, created_at`,
+		deadline.Seconds(),
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &items, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReleaseStuck solution_queue repo")
+	}
+
+	return items, nil
+}
+
+// Lock locks the item till the end of the transaction, so judging of the
+// solution is not changed concurrently.
+func (r *Repository) Lock(ctx context.Context, solutionID string) (item domain.SolutionQueueItem, err error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	items := []domain.SolutionQueueItem{}
+
+	sq.Add(`
+			SELECT solution_id, user_id, lane, attempts, claimed_at, created_at, test_case_ids::text[] AS test_case_ids
+			FROM solution_queue
+			WHERE solution_id = ?
+			FOR UPDATE`,
+		solutionID,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Select(ctx, r.db.TxOrDB(ctx), &items, query, args...)
+	if err != nil {
+		return item, errors.Wrap(err, "Lock solution_queue repo")
+	}
+
+	if len(items) < 1 {
+		err = struct_errors.NewErrNotFound("Solution is not in queue", nil)
+
+		return item, errors.Wrap(err, "Lock solution_queue repo")
+	}
+
+	return items[0], nil
+}
+
+func (r *Repository) Delete(ctx context.Context, solutionID string) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`DELETE FROM solution_queue WHERE solution_id = ?`, solutionID)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Delete solution_queue repo")
+	}
+
+	return nil
+}
+
+// DeleteWaiting removes the item only if it is not claimed by a worker yet.
+func (r *Repository) DeleteWaiting(ctx context.Context, solutionID string) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`DELETE FROM solution_queue WHERE solution_id = ? AND claimed_at IS NULL`, solutionID)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteWaiting solution_queue repo")
+	}
+
+	if res.RowsAffected() == 0 {
+		err = struct_errors.NewErrNotFound("Solution is not waiting in queue", nil)
+
+		return errors.Wrap(err, "DeleteWaiting solution_queue repo")
+	}
+
+	return nil
+}
+
+func (r *Repository) List(ctx context.Context, params domain.IdPaginationParams) ([]domain.QueuedSolution, error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	items := []domain.QueuedSolution{}
+
+	sq.Add(`
+			SELECT q.solution_id, q.user_id, q.lane, q.attempts, q.claimed_at, q.created_at,
+			       s.task_id, s.language_id
+			FROM solution_queue q
+			    JOIN solution s ON s.id = q.solution_id`,
+	)
+
+	if params.AfterID != nil {
+		sq.Add(
+			`WHERE (q.created_at, q.solution_id) > 
+			       (SELECT created_at, solution_id FROM solution_queue WHERE solution_id = ?)`,
+			*params.AfterID,
+		)
+	}
+
+	sq.Add("ORDER BY q.created_at, q.solution_id LIMIT ?", params.Limit)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &items, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "List solution_queue repo")
+	}
+
+	return items, nil
+}
+
+func (r *Repository) Counts(ctx context.Context) (c domain.SolutionQueueCounts, err error) {
+	err = pgxscan.Get(
+		ctx,
+		r.db.TxOrDB(ctx),
+		&c,
+		`SELECT COUNT(*) FILTER (WHERE claimed_at IS NULL)     AS waiting,
+		        COUNT(*) FILTER (WHERE claimed_at IS NOT NULL) AS claimed
+		 FROM solution_queue`,
+	)
+	if err != nil {
+		return c, errors.Wrap(err, "Counts solution_queue repo")
+	}
+
+	return c, nil
+}
+
+// Count counts items of every lane except rejudge, rejudges are started by
+// admins and must not make the queue full for users.
+func (r *Repository) Count(ctx context.Context) (count int, err error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`SELECT COUNT(*) FROM solution_queue WHERE lane <> ?`, domain.SolutionQueueLaneRejudge)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Get(ctx, r.db.TxOrDB(ctx), &count, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "Count solution_queue repo")
+	}
+
+	return count, nil
+}
+
+// LockLimits takes transaction level advisory lock of the queue limits. The
+// limits are counted and the item is pushed under it, so concurrent
+// submissions can not all pass the checks.
+func (r *Repository) LockLimits(ctx context.Context) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`SELECT pg_advisory_xact_lock(hashtext(?))`, "solution_queue_limits")
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "LockLimits solution_queue repo")
+	}
+
+	return nil
+}
+
+// CountByUser counts waiting and claimed items of the user in the lane.
+func (r *Repository) CountByUser(ctx context.Context, userID string, lane domain.SolutionQueueLane) (count int, err error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	sq.Add(`SELECT COUNT(*) FROM solution_queue WHERE user_id = ? AND lane = ?`, userID, lane)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Get(ctx, r.db.TxOrDB(ctx), &count, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "CountByUser solution_queue repo")
+	}
+
+	return count, nil
+}
+
+// EnqueueOrphaned pushes back every solution that is still in testing status
+// but has no queue item, e.g. after a crash in the middle of judging. The lane
+// is restored from the solution: validation runs go to the validation lane,
+// solutions of unfinished rejudges to the rejudge lane.
+func (r *Repository) EnqueueOrphaned(ctx context.Context) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	sq.Add(`
+		INSERT INTO solution_queue (solution_id, user_id, lane, test_case_ids)
+		SELECT s.id,
+		       s.user_id,
+		       CASE
+		           WHEN s.validation THEN ?::text
+		           WHEN EXISTS (SELECT 1
+		                        FROM rejudge_solution rs
+		                        WHERE rs.solution_id = s.id
+		                          AND rs.finished_at IS NULL) THEN ?::text
+		           ELSE ?::text
+		       END,
+		       `+currentTestCaseIDs+`
+		FROM solution s
+		WHERE s.status = ?
+		  AND NOT EXISTS (SELECT 1 FROM solution_queue q WHERE q.solution_id = s.id)
+		`,
+		domain.SolutionQueueLaneValidation,
+		domain.SolutionQueueLaneRejudge,
+		domain.SolutionQueueLaneUser,
+		domain.SolutionStatusTesting,
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "EnqueueOrphaned solution_queue repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// DeleteExhausted removes items which were claimed at least maxAttempts times
+// and returns their solution ids. Like ReleaseOwned, only unclaimed items and
+// claims of the owner are removed, items judged by other instances or waiting
+// for judge callbacks are kept.
+func (r *Repository) DeleteExhausted(ctx context.Context, owner string, maxAttempts int) ([]string, error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	ids := []string{}
+
+	sq.Add(`
+			DELETE FROM solution_queue
+			WHERE attempts >= ? AND (claimed_at IS NULL OR claimed_by = ?)
+			RETURNING solution_id`,
+		maxAttempts,
+		owner,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &ids, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "DeleteExhausted solution_queue repo")
+	}
+
+	return ids, nil
+}
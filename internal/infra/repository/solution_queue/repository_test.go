@@ -0,0 +1,136 @@
+package solution_queue
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"os"
+	"testing"
+	"time"
+)
+
+// testDatabaseURLEnv points to a migrated database, tests of the repository
+// are skipped without it. Everything is done in a transaction which is rolled
+// back, so the database is left as it was.
+const testDatabaseURLEnv = "LCODE_TEST_DATABASE_URL"
+
+func newTestRepository(t *testing.T) (*Repository, context.Context) {
+	t.Helper()
+
+	dsn := os.Getenv(testDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	tx, err := postgres.NewTransactionProvider(pool).NewTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	t.Cleanup(func() { _ = tx.Rollback(ctx) })
+
+	return New(postgres.NewDBManger(pool)), ctx
+}
+
+func TestClaimInterleavesUsers(t *testing.T) {
+	r, ctx := newTestRepository(t)
+	db := r.db.TxOrDB(ctx)
+
+	// items already queued in the database would be claimed as well
+	_, err := db.Exec(ctx, `DELETE FROM solution_queue`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var taskID string
+
+	err = pgxscan.Get(ctx, db, &taskID, `
+		INSERT INTO task (name, number, description, difficulty, category, runtime_limit, memory_limit)
+		VALUES ('claim test', 0, '', 'easy', 'test', 1, 1024)
+		RETURNING id`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]string{}
+
+	for _, name := range []string{"spammer", "other"} {
+		var id string
+
+		err = pgxscan.Get(ctx, db, &id, `
+			INSERT INTO "user" (email, first_name, last_name, username, password_hash)
+			VALUES ($1 || '@claim.test', $1, $1, $1 || '_claim_test', '')
+			RETURNING id`,
+			name,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users[name] = id
+	}
+
+	// the spammer submits three solutions before the other user submits two
+	pushed := []string{"spammer", "spammer", "spammer", "other", "other"}
+	owners := map[string]string{}
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, name := range pushed {
+		var solutionID string
+
+		err = pgxscan.Get(ctx, db, &solutionID, `
+			INSERT INTO solution (task_id, user_id, code, status)
+			VALUES ($1, $2, '', 'testing')
+			RETURNING id`,
+			taskID,
+			users[name],
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = db.Exec(ctx, `
+			INSERT INTO solution_queue (solution_id, user_id, lane, created_at)
+			VALUES ($1, $2, $3, $4)`,
+			solutionID,
+			users[name],
+			string(domain.SolutionQueueLaneUser),
+			createdAt.Add(time.Duration(i)*time.Second),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		owners[solutionID] = name
+	}
+
+	want := []string{"spammer", "other", "spammer", "other", "spammer"}
+
+	for i := range want {
+		// solutions are claimed one by one like the workers do, claimed ones
+		// are still judged
+		items, err := r.Claim(ctx, "test", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 {
+			t.Fatalf("claim %d: got %d items, want 1", i, len(items))
+		}
+
+		if got := owners[items[0].SolutionID]; got != want[i] {
+			t.Errorf("claim %d: got solution of %s, want %s", i, got, want[i])
+		}
+	}
+}
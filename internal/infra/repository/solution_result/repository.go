@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	"lcode/internal/domain"
 	"lcode/pkg/postgres"
+	"time"
 )
 
 func New(db *postgres.DbManager) *Repository {
@@ -21,7 +22,7 @@ func (r *Repository) CreateBatch(ctx context.Context, results ...domain.Solution
 	sq := sql_query_maker.NewQueryMaker(4)
 
 	sq.Add(`INSERT INTO solution_result 
-    			  (solution_id, test_case_id, submission_token, status, runtime, memory, stdout, stderr)`,
+    			  (solution_id, test_case_id, submission_token, status, runtime, memory, stdout, stderr, stdout_truncated, stderr_truncated)`,
 	)
 
 	for i := range results {
@@ -34,6 +35,8 @@ func (r *Repository) CreateBatch(ctx context.Context, results ...domain.Solution
 			results[i].Memory,
 			results[i].Stdout,
 			results[i].Stderr,
+			results[i].StdoutTruncated,
+			results[i].StderrTruncated,
 		)
 	}
 
@@ -46,16 +49,86 @@ func (r *Repository) CreateBatch(ctx context.Context, results ...domain.Solution
 	return nil
 }
 
+// Update sets the verdict of the judge to the result with its submission token.
+func (r *Repository) Update(ctx context.Context, result domain.SolutionResult) error {
+	sq := sql_query_maker.NewQueryMaker(9)
+
+	sq.Add(`
+			UPDATE solution_result
+			SET status = ?, runtime = ?, memory = ?, stdout = ?, stderr = ?, stdout_truncated = ?, stderr_truncated = ?
+			WHERE solution_id = ? AND submission_token = ?`,
+		result.Status,
+		result.Runtime,
+		result.Memory,
+		result.Stdout,
+		result.Stderr,
+		result.StdoutTruncated,
+		result.StderrTruncated,
+		result.SolutionID,
+		result.SubmissionToken,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "Update solution_result repo")
+	}
+
+	return nil
+}
+
+// SetSubmissionToken replaces the callback key of the pending result with the
+// token of its submission.
+func (r *Repository) SetSubmissionToken(ctx context.Context, solutionID string, key string, token string) error {
+	sq := sql_query_maker.NewQueryMaker(3)
+
+	sq.Add(
+		`UPDATE solution_result SET submission_token = ? WHERE solution_id = ? AND submission_token = ?`,
+		token,
+		solutionID,
+		key,
+	)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "SetSubmissionToken solution_result repo")
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteBySolutionID(ctx context.Context, solutionID string) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`DELETE FROM solution_result WHERE solution_id = ?`, solutionID)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteBySolutionID solution_result repo")
+	}
+
+	return nil
+}
+
 func (r *Repository) ResultsBySolutionID(ctx context.Context, solutionID string) ([]domain.SolutionResult, error) {
 	sq := sql_query_maker.NewQueryMaker(1)
 
 	results := []domain.SolutionResult{}
 
 	sq.Add(`
-			SELECT solution_id, test_case_id, submission_token, 
-			       status, runtime, memory, stdout, stderr 
-			FROM solution_result
-			WHERE solution_id = ?`,
+			SELECT r.solution_id, r.test_case_id, r.submission_token,
+			       r.status, r.runtime, r.memory, r.stdout, r.stderr, r.stdout_truncated, r.stderr_truncated,
+			       CASE WHEN tc.file_backed THEN NULL ELSE tc.input END AS input, tc.visibility,
+			       tc.task_id, tc.file_backed
+			FROM solution_result r
+			    JOIN test_case tc ON tc.id = r.test_case_id
+			WHERE r.solution_id = ?
+			ORDER BY tc.created_at`,
 		solutionID,
 	)
 
@@ -68,3 +141,25 @@ func (r *Repository) ResultsBySolutionID(ctx context.Context, solutionID string)
 
 	return results, nil
 }
+
+// PruneOutputs clears outputs of results created longer than retention ago.
+func (r *Repository) PruneOutputs(ctx context.Context, retention time.Duration) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+			UPDATE solution_result
+			SET stdout = NULL, stderr = NULL
+			WHERE created_at < timezone('utc'::text, now()) - make_interval(secs => ?::double precision)
+			  AND (stdout IS NOT NULL OR stderr IS NOT NULL)`,
+		retention.Seconds(),
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "PruneOutputs solution_result repo")
+	}
+
+	return res.RowsAffected(), nil
+}
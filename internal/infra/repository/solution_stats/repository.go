@@ -0,0 +1,197 @@
+package solution_stats
+
+import (
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	sql_query_maker "github.com/m-a-r-a-t/sql-query-maker"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+)
+
+func New(db *postgres.DbManager) *Repository {
+	return &Repository{db: db}
+}
+
+type Repository struct {
+	db *postgres.DbManager
+}
+
+// Refresh computes stats of accepted solutions for every task and language
+// by runtime and memory. Values equal to the maximum fall into the last
+// histogram bucket, all values are in the first one when they are equal.
+func (r *Repository) Refresh(ctx context.Context, buckets int) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	fractions := make([]float64, domain.SolutionStatsQuantiles)
+	for i := range fractions {
+		fractions[i] = float64(i) / float64(domain.SolutionStatsQuantiles-1)
+	}
+
+	sq.Add(`
+			WITH accepted AS (SELECT s.task_id,
+			                         s.language_id,
+			                         m.metric,
+			                         CASE m.metric
+			                             WHEN 'runtime' THEN s.runtime
+			                             ELSE s.memory::double precision END AS value
+			                  FROM solution s
+			                           CROSS JOIN (VALUES ('runtime'), ('memory')) AS m (metric)
+			                  WHERE s.status = 'completed'
+			                    AND NOT s.validation),
+			     bounds AS (SELECT task_id,
+			                       language_id,
+			                       metric,
+			                       COUNT(*)                                                          AS accepted_count,
+			                       MIN(value)                                                        AS min_value,
+			                       MAX(value)                                                        AS max_value,
+			                       percentile_cont(?::double precision[]) WITHIN GROUP (ORDER BY value) AS quantiles
+			                FROM accepted
+			                GROUP BY task_id, language_id, metric),
+			     buckets AS (SELECT a.task_id,
+			                        a.language_id,
+			                        a.metric,
+			                        CASE
+			                            WHEN b.min_value = b.max_value THEN 1
+			                            ELSE LEAST(width_bucket(a.value, b.min_value, b.max_value, ?::integer), ?::integer)
+			                            END  AS bucket,
+			                        COUNT(*) AS count
+			                 FROM accepted a
+			                          JOIN bounds b USING (task_id, language_id, metric)
+			                 GROUP BY 1, 2, 3, 4),
+			     histograms AS (SELECT b.task_id,
+			                           b.language_id,
+			                           b.metric,
+			                           array_agg(COALESCE(k.count, 0)::integer ORDER BY g.bucket) AS histogram
+			                    FROM bounds b
+			                             CROSS JOIN generate_series(1, ?::integer) AS g (bucket)
+			                             LEFT JOIN buckets k ON k.task_id = b.task_id
+			                        AND k.language_id = b.language_id
+			                        AND k.metric = b.metric
+			                        AND k.bucket = g.bucket
+			                    GROUP BY b.task_id, b.language_id, b.metric)
+			INSERT
+			INTO solution_stats (task_id, language_id, metric, accepted_count, min_value, max_value, quantiles, histogram,
+			                     refreshed_at)
+			SELECT b.task_id,
+			       b.language_id,
+			       b.metric,
+			       b.accepted_count,
+			       b.min_value,
+			       b.max_value,
+			       b.quantiles,
+			       h.histogram,
+			       timezone('utc'::text, now())
+			FROM bounds b
+			         JOIN histograms h USING (task_id, language_id, metric)
+			ON CONFLICT (task_id, language_id, metric) DO UPDATE
+			    SET accepted_count = excluded.accepted_count,
+			        min_value      = excluded.min_value,
+			        max_value      = excluded.max_value,
+			        quantiles      = excluded.quantiles,
+			        histogram      = excluded.histogram,
+			        refreshed_at   = excluded.refreshed_at`,
+		fractions,
+		buckets,
+		buckets,
+		buckets,
+	)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "Refresh solution stats repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// DeleteStale deletes stats of tasks and languages which have no accepted
+// solutions anymore, e.g. after a rejudge.
+func (r *Repository) DeleteStale(ctx context.Context) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(0)
+
+	sq.Add(`
+			DELETE
+			FROM solution_stats st
+			WHERE NOT EXISTS (SELECT 1
+			                  FROM solution s
+			                  WHERE s.task_id = st.task_id
+			                    AND s.language_id = st.language_id
+			                    AND s.status = 'completed'
+			                    AND NOT s.validation)`)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "DeleteStale solution stats repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// UpdatePercentiles stores exact percentiles of accepted solutions: a
+// percentage of other accepted solutions of the task in the same language
+// with greater runtime and memory. The only accepted solution is better than
+// all others.
+func (r *Repository) UpdatePercentiles(ctx context.Context) (int64, error) {
+	sq := sql_query_maker.NewQueryMaker(0)
+
+	sq.Add(`
+			UPDATE solution s
+			SET runtime_percentile = p.runtime_percentile,
+			    memory_percentile  = p.memory_percentile
+			FROM (SELECT id,
+			             CASE
+			                 WHEN COUNT(*) OVER w = 1 THEN 100
+			                 ELSE 100 * percent_rank()
+			                            OVER (PARTITION BY task_id, language_id ORDER BY runtime DESC) END AS runtime_percentile,
+			             CASE
+			                 WHEN COUNT(*) OVER w = 1 THEN 100
+			                 ELSE 100 * percent_rank()
+			                            OVER (PARTITION BY task_id, language_id ORDER BY memory DESC) END  AS memory_percentile
+			      FROM solution
+			      WHERE status = 'completed' AND NOT validation
+			      WINDOW w AS (PARTITION BY task_id, language_id)) p
+			WHERE s.id = p.id
+			  AND (s.runtime_percentile IS DISTINCT FROM p.runtime_percentile
+			    OR s.memory_percentile IS DISTINCT FROM p.memory_percentile)`)
+
+	query, args := sq.Make()
+
+	res, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "UpdatePercentiles solution stats repo")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (r *Repository) GetByTaskAndLanguage(
+	ctx context.Context,
+	taskID string,
+	languageID domain.LanguageType,
+) ([]domain.SolutionStats, error) {
+	sq := sql_query_maker.NewQueryMaker(2)
+
+	stats := []domain.SolutionStats{}
+
+	sq.Add(`
+			SELECT task_id, language_id, metric, accepted_count, min_value, max_value, quantiles, histogram, refreshed_at
+			FROM solution_stats
+			WHERE task_id = ? AND language_id = ?`,
+		taskID,
+		languageID,
+	)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &stats, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetByTaskAndLanguage solution stats repo")
+	}
+
+	return stats, nil
+}
@@ -24,15 +24,17 @@ func New(cfg *config.Config, db *postgres.DbManager) *Repository {
 }
 
 func (r *Repository) Create(ctx context.Context, dto domain.TaskCreateInput) (taskID string, err error) {
-	sq := sql_query_maker.NewQueryMaker(6)
+	sq := sql_query_maker.NewQueryMaker(11)
 
 	sq.Add(
 		`
-	INSERT INTO task (name, description, difficulty, category, runtime_limit, memory_limit)
-	VALUES (?, ?, ?, ?, ?, ?)
+	INSERT INTO task (name, description, difficulty, category, runtime_limit, memory_limit, full_report,
+	                  checker_type, checker_epsilon, checker_code, checker_language_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	RETURNING id
 	`,
-		dto.Name, dto.Description, dto.Difficulty, dto.Category, dto.RuntimeLimit, dto.MemoryLimit,
+		dto.Name, dto.Description, dto.Difficulty, dto.Category, dto.RuntimeLimit, dto.MemoryLimit, dto.FullReport,
+		dto.CheckerType, dto.CheckerEpsilon, dto.CheckerCode, dto.CheckerLanguageID,
 	)
 
 	query, args := sq.Make()
@@ -56,7 +58,7 @@ func (r *Repository) Create(ctx context.Context, dto domain.TaskCreateInput) (ta
 }
 
 func (r *Repository) Update(ctx context.Context, id string, dto domain.TaskUpdateInput) error {
-	sq := sql_query_maker.NewQueryMaker(7)
+	sq := sql_query_maker.NewQueryMaker(12)
 
 	sq.Add("UPDATE task SET")
 
@@ -84,6 +86,26 @@ func (r *Repository) Update(ctx context.Context, id string, dto domain.TaskUpdat
 		sq.Add("memory_limit = ?,", *dto.MemoryLimit)
 	}
 
+	if dto.FullReport != nil {
+		sq.Add("full_report = ?,", *dto.FullReport)
+	}
+
+	if dto.CheckerType != nil {
+		sq.Add("checker_type = ?,", *dto.CheckerType)
+	}
+
+	if dto.CheckerEpsilon != nil {
+		sq.Add("checker_epsilon = ?,", *dto.CheckerEpsilon)
+	}
+
+	if dto.CheckerCode != nil {
+		sq.Add("checker_code = ?,", *dto.CheckerCode)
+	}
+
+	if dto.CheckerLanguageID != nil {
+		sq.Add("checker_language_id = ?,", *dto.CheckerLanguageID)
+	}
+
 	sq.Where("id = ?", id)
 
 	query, args := sq.Make()
@@ -128,7 +150,8 @@ func (r *Repository) GetByID(ctx context.Context, id string) (t domain.Task, err
 
 	sq.Add(
 		`
-	SELECT id, number, name, description, category, difficulty, runtime_limit, memory_limit
+	SELECT id, number, name, description, category, difficulty, runtime_limit, memory_limit, full_report,
+	       checker_type, checker_epsilon, checker_code, checker_language_id
 	FROM task
 	WHERE id = ?
 	`,
@@ -148,7 +171,9 @@ func (r *Repository) GetAllByParams(ctx context.Context, params domain.TaskParam
 	tasks := []domain.Task{}
 	sq := newFilter(r.cfg, 15)
 
-	sq.Add("SELECT id, number, name, description, category, difficulty, runtime_limit, memory_limit FROM task t")
+	sq.Add(`SELECT id, number, name, description, category, difficulty, runtime_limit, memory_limit, full_report,
+		       checker_type, checker_epsilon
+		FROM task t`)
 
 	if params.Pagination.AfterID != nil {
 		q := fmt.Sprintf(
@@ -20,14 +20,14 @@ func New(cfg *config.Config, db *postgres.DbManager) *Repository {
 }
 
 func (r *Repository) Create(ctx context.Context, taskID string, dto domain.TaskTemplateCreateInput) error {
-	sq := sql_query_maker.NewQueryMaker(4)
+	sq := sql_query_maker.NewQueryMaker(6)
 
 	sq.Add(
 		`
-	INSERT INTO task_template (task_id, language_id, template, wrapper)
-	VALUES (?, ?, ?, ?)
+	INSERT INTO task_template (task_id, language_id, template, wrapper, runtime_limit, memory_limit)
+	VALUES (?, ?, ?, ?, ?, ?)
 	`,
-		taskID, dto.LanguageID, dto.Template, dto.Wrapper,
+		taskID, dto.LanguageID, dto.Template, dto.Wrapper, dto.RuntimeLimit, dto.MemoryLimit,
 	)
 
 	query, args := sq.Make()
@@ -41,7 +41,7 @@ func (r *Repository) Create(ctx context.Context, taskID string, dto domain.TaskT
 }
 
 func (r *Repository) Update(ctx context.Context, id string, dto domain.TaskTemplateUpdateInput) error {
-	sq := sql_query_maker.NewQueryMaker(3)
+	sq := sql_query_maker.NewQueryMaker(5)
 
 	sq.Add("UPDATE task_template SET")
 
@@ -53,6 +53,14 @@ func (r *Repository) Update(ctx context.Context, id string, dto domain.TaskTempl
 		sq.Add("wrapper = ?,", *dto.Wrapper)
 	}
 
+	if dto.RuntimeLimit != nil {
+		sq.Add("runtime_limit = NULLIF(?::double precision, 0),", *dto.RuntimeLimit)
+	}
+
+	if dto.MemoryLimit != nil {
+		sq.Add("memory_limit = NULLIF(?::integer, 0),", *dto.MemoryLimit)
+	}
+
 	sq.Where("id = ?", id)
 
 	query, args := sq.Make()
@@ -99,7 +107,7 @@ func (r *Repository) GetAllByTaskID(ctx context.Context, id string) ([]domain.Ta
 
 	sq.Add(
 		`
-	SELECT id, task_id, language_id, template, wrapper
+	SELECT id, task_id, language_id, template, wrapper, runtime_limit, memory_limit
 	FROM task_template WHERE task_id = ?
 	`,
 		id)
@@ -8,6 +8,7 @@ import (
 	"lcode/config"
 	"lcode/internal/domain"
 	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
 )
 
 type Repository struct {
@@ -20,14 +21,14 @@ func New(cfg *config.Config, db *postgres.DbManager) *Repository {
 }
 
 func (r *Repository) Create(ctx context.Context, taskID string, dto domain.TestCaseCreateInput) error {
-	sq := sql_query_maker.NewQueryMaker(3)
+	sq := sql_query_maker.NewQueryMaker(4)
 
 	sq.Add(
 		`
-	INSERT INTO test_case (task_id, input, output)
-	VALUES (?, ?, ?)
+	INSERT INTO test_case (task_id, input, output, visibility)
+	VALUES (?, ?, ?, ?)
 	`,
-		taskID, dto.Input, dto.Output,
+		taskID, dto.Input, dto.Output, dto.Visibility,
 	)
 
 	query, args := sq.Make()
@@ -40,20 +41,53 @@ func (r *Repository) Create(ctx context.Context, taskID string, dto domain.TestC
 	return nil
 }
 
+// CreateFileBacked creates test case whose input and output are kept in files.
+// created_at is taken from the clock, so test cases created in one
+// transaction keep their order.
+func (r *Repository) CreateFileBacked(
+	ctx context.Context,
+	taskID string,
+	dto domain.TestCaseFileCreateInput,
+) (id string, err error) {
+	sq := sql_query_maker.NewQueryMaker(4)
+
+	sq.Add(
+		`
+	INSERT INTO test_case (task_id, input, output, visibility, file_backed, input_size, output_size, created_at)
+	VALUES (?, '', '', ?, true, ?, ?, timezone('utc'::text, clock_timestamp()))
+	RETURNING id
+	`,
+		taskID, dto.Visibility, dto.InputSize, dto.OutputSize,
+	)
+
+	query, args := sq.Make()
+
+	err = pgxscan.Get(ctx, r.db.TxOrDB(ctx), &id, query, args...)
+	if err != nil {
+		return "", errors.Wrap(err, "CreateFileBacked TestCase repo:")
+	}
+
+	return id, nil
+}
+
 func (r *Repository) Update(ctx context.Context, id string, dto domain.TestCaseUpdateInput) error {
-	sq := sql_query_maker.NewQueryMaker(3)
+	sq := sql_query_maker.NewQueryMaker(4)
 
 	sq.Add("UPDATE test_case SET")
 
 	if dto.Input != nil {
-		sq.Add("input = ?", *dto.Input)
+		sq.Add("input = ?,", *dto.Input)
 	}
 
 	if dto.Output != nil {
-		sq.Add("output = ?", *dto.Output)
+		sq.Add("output = ?,", *dto.Output)
+	}
+
+	if dto.Visibility != nil {
+		sq.Add("visibility = ?,", *dto.Visibility)
 	}
 
-	sq.Add("WHERE id = ?", id)
+	sq.Where("id = ? AND archived_at IS NULL", id)
 
 	query, args := sq.Make()
 
@@ -74,7 +108,7 @@ func (r *Repository) Update(ctx context.Context, id string, dto domain.TestCaseU
 func (r *Repository) Delete(ctx context.Context, id string) error {
 	sq := sql_query_maker.NewQueryMaker(1)
 
-	sq.Add("DELETE FROM test_case WHERE id = ?", id)
+	sq.Add("DELETE FROM test_case WHERE id = ? AND archived_at IS NULL", id)
 
 	query, args := sq.Make()
 
@@ -92,12 +126,62 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// ArchiveByTaskID hides current test cases of the task. Archived test cases
+// are kept for results of solutions judged on them.
+func (r *Repository) ArchiveByTaskID(ctx context.Context, taskID string) error {
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+	UPDATE test_case SET archived_at = timezone('utc'::text, now())
+	WHERE task_id = ? AND archived_at IS NULL`, taskID)
+
+	query, args := sq.Make()
+
+	_, err := r.db.TxOrDB(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "ArchiveByTaskID TestCase repo:")
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id string) (domain.TestCase, error) {
+	tcs := []domain.TestCase{}
+
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+	SELECT id, task_id, '' AS number, input, output, visibility, file_backed,
+	       CASE WHEN file_backed THEN input_size ELSE octet_length(input) END AS input_size,
+	       CASE WHEN file_backed THEN output_size ELSE octet_length(output) END AS output_size
+	FROM test_case WHERE id = ? AND archived_at IS NULL`, id)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &tcs, query, args...)
+	if err != nil {
+		return domain.TestCase{}, errors.Wrap(err, "GetByID TestCase repo:")
+	}
+
+	if len(tcs) < 1 {
+		err = struct_errors.NewErrNotFound("TestCase not found", nil)
+
+		return domain.TestCase{}, errors.Wrap(err, "GetByID TestCase repo:")
+	}
+
+	return tcs[0], nil
+}
+
 func (r *Repository) GetAllByTaskID(ctx context.Context, id string) ([]domain.TestCase, error) {
 	tcs := []domain.TestCase{}
 
 	sq := sql_query_maker.NewQueryMaker(1)
 
-	sq.Add("SELECT id, task_id, row_number() over (ORDER BY created_at) AS number, input, output FROM test_case WHERE task_id = ?", id)
+	sq.Add(`
+	SELECT id, task_id, row_number() over (ORDER BY created_at) AS number, input, output, visibility, file_backed,
+	       CASE WHEN file_backed THEN input_size ELSE octet_length(input) END AS input_size,
+	       CASE WHEN file_backed THEN output_size ELSE octet_length(output) END AS output_size
+	FROM test_case WHERE task_id = ? AND archived_at IS NULL`, id)
 
 	query, args := sq.Make()
 
@@ -108,3 +192,28 @@ func (r *Repository) GetAllByTaskID(ctx context.Context, id string) ([]domain.Te
 
 	return tcs, nil
 }
+
+// GetByIDs returns test cases in the order of ids, archived ones included,
+// numbered by their positions. Deleted test cases are skipped.
+func (r *Repository) GetByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error) {
+	tcs := []domain.TestCase{}
+
+	sq := sql_query_maker.NewQueryMaker(1)
+
+	sq.Add(`
+	SELECT tc.id, tc.task_id, p.number, tc.input, tc.output, tc.visibility, tc.file_backed,
+	       CASE WHEN tc.file_backed THEN tc.input_size ELSE octet_length(tc.input) END AS input_size,
+	       CASE WHEN tc.file_backed THEN tc.output_size ELSE octet_length(tc.output) END AS output_size
+	FROM unnest(?::uuid[]) WITH ORDINALITY AS p (id, number)
+	    JOIN test_case tc ON tc.id = p.id
+	ORDER BY p.number`, ids)
+
+	query, args := sq.Make()
+
+	err := pgxscan.Select(ctx, r.db.TxOrDB(ctx), &tcs, query, args...)
+	if err != nil {
+		return tcs, errors.Wrap(err, "GetByIDs TestCase repo:")
+	}
+
+	return tcs, nil
+}
@@ -36,13 +36,13 @@ func (r *Repository) StatisticsByUserID(
 		WITH complete_s AS 
 			(SELECT DISTINCT task_id, user_id, status
 		                     FROM solution
-		                     WHERE status = '%s'),
+		                     WHERE status = '%s' AND NOT validation),
 			statuses AS
 			(SELECT DISTINCT s.task_id, s.user_id, COALESCE(complete_s.status, '%s') AS status
 		      FROM solution s
 		          LEFT JOIN complete_s
 		              ON s.user_id = complete_s.user_id AND s.task_id = complete_s.task_id
-		      WHERE s.user_id = ?)
+		      WHERE s.user_id = ? AND NOT s.validation)
 
 		SELECT t.%s AS param, COUNT(s.task_id) AS count_done, COUNT(t.id) AS count_total
 		FROM statuses s
@@ -79,13 +79,13 @@ func (r *Repository) ProgressByUserID(ctx context.Context, userID string) (p dom
 		WITH complete_s AS 
 			(SELECT DISTINCT task_id, user_id, status
 		                     FROM solution
-		                     WHERE status = '%s'),
+		                     WHERE status = '%s' AND NOT validation),
 			statuses AS
 			(SELECT DISTINCT s.task_id, s.user_id, COALESCE(complete_s.status, '%s') AS status
 		      FROM solution s
 		          LEFT JOIN complete_s
 		              ON s.user_id = complete_s.user_id AND s.task_id = complete_s.task_id
-		      WHERE s.user_id = ?)
+		      WHERE s.user_id = ? AND NOT s.validation)
 
 		SELECT status, array_agg(task_id) as task_ids
 		FROM statuses
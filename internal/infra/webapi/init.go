@@ -1,24 +1,159 @@
 package webapi
 
 import (
+	"context"
+	"github.com/pkg/errors"
 	"lcode/config"
+	"lcode/internal/domain"
 	"lcode/internal/infra/webapi/judge"
 )
 
+const (
+	judgeDriverJudge0   = "judge0"
+	judgeDriverFake     = "fake"
+	judgeDriverEmulator = "emulator"
+
+	judgeSchemeHTTP  = "http"
+	judgeSchemeHTTPS = "https"
+)
+
 type (
 	InitParams struct {
 		Config *config.Config
 	}
 
+	Judge interface {
+		CreateSubmission(ctx context.Context, data domain.CreateJudgeSubmission) (domain.JudgeSubmissionInfo, error)
+		CreateSubmissionBatch(ctx context.Context, data []domain.CreateJudgeSubmission) ([]string, error)
+		GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error)
+		GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error)
+		GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error)
+		Ping(ctx context.Context) error
+	}
+
+	// JudgeNodes reports stats of judge servers
+	JudgeNodes interface {
+		Nodes() []domain.JudgeNodeStats
+	}
+
 	APIs struct {
-		Judge *judge.API
+		Judge      Judge
+		JudgeNodes JudgeNodes
 	}
 )
 
-func New(p *InitParams) *APIs {
-	judgeAPI := judge.New(&p.Config.JudgeConfig)
+func New(p *InitParams) (*APIs, error) {
+	balancer, err := newJudgeBalancer(&p.Config.JudgeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "New webapi")
+	}
 
 	return &APIs{
-		Judge: judgeAPI,
+		Judge:      newJudge(balancer, &p.Config.JudgeConfig),
+		JudgeNodes: balancer,
+	}, nil
+}
+
+// newJudge wraps balancer of judge nodes with retries, circuit breaker and
+// cache of judge capabilities.
+func newJudge(balancer *judge.Balancer, cfg *config.JudgeConfig) Judge {
+	resilient := judge.NewResilient(balancer, cfg)
+
+	return judge.NewCached(resilient, cfg.CapabilitiesRefreshInterval)
+}
+
+func newJudgeBalancer(cfg *config.JudgeConfig) (*judge.Balancer, error) {
+	if domain.JudgeSubmissionMode(cfg.SubmissionMode) == domain.JudgeSubmissionModeCallback {
+		if cfg.Driver == judgeDriverFake {
+			return nil, errors.New("fake judge can not send callbacks, use emulator driver")
+		}
+
+		if cfg.CallbackURL == "" || cfg.CallbackSecret == "" {
+			return nil, errors.New("callback URL and secret of judge are required in callback mode")
+		}
+	}
+
+	switch cfg.Driver {
+	case judgeDriverJudge0:
+		nodes := make([]judge.BalancerNode, 0, len(cfg.Endpoints))
+		names := make(map[string]struct{}, len(cfg.Endpoints))
+
+		for _, endpoint := range cfg.Endpoints {
+			if endpoint.Scheme != judgeSchemeHTTP && endpoint.Scheme != judgeSchemeHTTPS {
+				return nil, errors.Errorf("unknown scheme %q of judge endpoint %q", endpoint.Scheme, endpoint.Name)
+			}
+
+			if *endpoint.Weight <= 0 {
+				return nil, errors.Errorf("weight of judge endpoint %q is not positive", endpoint.Name)
+			}
+
+			if _, ok := names[endpoint.Name]; ok {
+				return nil, errors.Errorf("duplicate name of judge endpoint %q", endpoint.Name)
+			}
+			names[endpoint.Name] = struct{}{}
+
+			api := judge.New(endpoint, cfg.RequestTimeout)
+
+			nodes = append(nodes, judge.BalancerNode{
+				Name:    endpoint.Name,
+				Address: api.Addr(),
+				Weight:  *endpoint.Weight,
+				Client:  api,
+			})
+		}
+
+		return judge.NewBalancer(cfg, nodes...), nil
+	case judgeDriverFake:
+		fake, err := newFake(&cfg.Fake)
+		if err != nil {
+			return nil, err
+		}
+
+		return judge.NewBalancer(cfg, judge.BalancerNode{
+			Name:   judgeDriverFake,
+			Weight: 1,
+			Client: fake,
+		}), nil
+	case judgeDriverEmulator:
+		fake, err := newFake(&cfg.Fake)
+		if err != nil {
+			return nil, err
+		}
+
+		host, port, err := judge.StartEmulator(fake)
+		if err != nil {
+			return nil, err
+		}
+
+		api := judge.New(config.JudgeEndpointConfig{Scheme: judgeSchemeHTTP, Host: host, Port: port}, cfg.RequestTimeout)
+
+		return judge.NewBalancer(cfg, judge.BalancerNode{
+			Name:    judgeDriverEmulator,
+			Address: api.Addr(),
+			Weight:  1,
+			Client:  api,
+		}), nil
+	default:
+		return nil, errors.Errorf("unknown judge driver %q", cfg.Driver)
 	}
 }
+
+// newFake returns fake judge scripted by config.
+func newFake(cfg *config.JudgeFakeConfig) (*judge.Fake, error) {
+	fake := judge.NewFake()
+
+	if err := fake.SetDefaultStdout(judge.FakeStdout(cfg.Stdout)); err != nil {
+		return nil, err
+	}
+
+	for _, v := range cfg.Verdicts {
+		stdout := v.Stdout
+
+		fake.SetVerdict(v.Stdin, judge.FakeVerdict{
+			Status: domain.JudgeStatus(v.Status),
+			Stdout: &stdout,
+		})
+	}
+
+	return fake, nil
+}
@@ -0,0 +1,467 @@
+package judge
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/pkg/struct_errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingTTL bounds how long submissions which were never polled till their
+// finish are counted as load of the node
+const pendingTTL = time.Minute * 10
+
+var errNoJudgeNodes = errors.New("all judge nodes are unavailable")
+
+// BalancerNode is a judge server served by the balancer.
+type BalancerNode struct {
+	Name    string
+	Address string
+	Weight  int
+	Client  client
+}
+
+// NewBalancer distributes requests between judge nodes. Every node has its own
+// circuit breaker, requests go to the node with the least load per unit of
+// weight whose breaker is not open and fail over to the next one when the node
+// is unavailable or its queue is full. Tokens of submissions are prefixed with
+// name of the node, so their results are fetched from the node which runs them
+// even after nodes are added or reordered in config. Names must be unique.
+func NewBalancer(cfg *config.JudgeConfig, nodes ...BalancerNode) *Balancer {
+	b := &Balancer{
+		nodes:          make([]*node, 0, len(nodes)),
+		byName:         make(map[string]int, len(nodes)),
+		callbackSecret: cfg.CallbackSecret,
+		now:            time.Now,
+	}
+
+	for i := range nodes {
+		b.nodes = append(b.nodes, &node{
+			BalancerNode: nodes[i],
+			breaker:      newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+			pending:      make(map[string]time.Time),
+		})
+		b.byName[nodes[i].Name] = i
+	}
+
+	return b
+}
+
+type Balancer struct {
+	nodes []*node
+	// byName maps names of nodes encoded in tokens to their indexes
+	byName map[string]int
+	// callbackSecret signs callback URLs again when the node is added to them
+	callbackSecret string
+	now            func() time.Time
+}
+
+type node struct {
+	BalancerNode
+	breaker *breaker
+
+	mu          sync.Mutex
+	inFlight    int
+	pending     map[string]time.Time
+	requests    int64
+	failures    int64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func (b *Balancer) CreateSubmission(
+	ctx context.Context,
+	data domain.CreateJudgeSubmission,
+) (info domain.JudgeSubmissionInfo, err error) {
+	i, err := b.do(ctx, false, func(n *node) (err error) {
+		info, err = n.Client.CreateSubmission(ctx, b.withCallbackNode(n, data))
+
+		return err
+	})
+	if err != nil {
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission judge balancer")
+	}
+
+	info.Token = b.encodeToken(i, info.Token)
+
+	return info, nil
+}
+
+func (b *Balancer) CreateSubmissionBatch(
+	ctx context.Context,
+	data []domain.CreateJudgeSubmission,
+) (tokens []string, err error) {
+	i, err := b.do(ctx, false, func(n *node) (err error) {
+		nodeData := make([]domain.CreateJudgeSubmission, 0, len(data))
+		for j := range data {
+			nodeData = append(nodeData, b.withCallbackNode(n, data[j]))
+		}
+
+		tokens, err = n.Client.CreateSubmissionBatch(ctx, nodeData)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateSubmissionBatch judge balancer")
+	}
+
+	b.nodes[i].addPending(tokens, b.now())
+
+	for j := range tokens {
+		tokens[j] = b.encodeToken(i, tokens[j])
+	}
+
+	return tokens, nil
+}
+
+// GetSubmissionBatch fetches submissions from nodes which created them and
+// returns them in the order of the passed tokens.
+func (b *Balancer) GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error) {
+	nodeTokens := make(map[int][]string)
+	positions := make(map[int][]int)
+
+	for j := range tokens {
+		i, token, err := b.decodeToken(tokens[j])
+		if err != nil {
+			return nil, errors.Wrap(err, "GetSubmissionBatch judge balancer")
+		}
+
+		nodeTokens[i] = append(nodeTokens[i], token)
+		positions[i] = append(positions[i], j)
+	}
+
+	infos := make([]domain.JudgeSubmissionInfo, len(tokens))
+
+	for i, nt := range nodeTokens {
+		n := b.nodes[i]
+
+		if !n.breaker.Allow() {
+			err := domain.NewJudgeUnavailableError(errBreakerOpen)
+
+			return nil, errors.Wrap(err, "GetSubmissionBatch judge balancer")
+		}
+
+		n.begin()
+		nodeInfos, err := n.Client.GetSubmissionBatch(ctx, nt)
+		n.end(ctx, err, b.now())
+
+		if err != nil {
+			return nil, errors.Wrap(err, "GetSubmissionBatch judge balancer")
+		}
+
+		if len(nodeInfos) != len(nt) {
+			err = struct_errors.NewInternalErr(
+				fmt.Errorf("judge node %s returned %d submissions for %d tokens", n.Name, len(nodeInfos), len(nt)),
+			)
+
+			return nil, errors.Wrap(err, "GetSubmissionBatch judge balancer")
+		}
+
+		n.finishPending(nodeInfos)
+
+		for k, j := range positions[i] {
+			infos[j] = nodeInfos[k]
+			infos[j].Token = tokens[j]
+		}
+	}
+
+	return infos, nil
+}
+
+func (b *Balancer) GetAvailableLanguages(ctx context.Context) (languages []domain.JudgeLanguageInfo, err error) {
+	_, err = b.do(ctx, true, func(n *node) (err error) {
+		languages, err = n.Client.GetAvailableLanguages(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableLanguages judge balancer")
+	}
+
+	return languages, nil
+}
+
+func (b *Balancer) GetAvailableStatuses(ctx context.Context) (statuses []domain.JudgeStatusInfo, err error) {
+	_, err = b.do(ctx, true, func(n *node) (err error) {
+		statuses, err = n.Client.GetAvailableStatuses(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableStatuses judge balancer")
+	}
+
+	return statuses, nil
+}
+
+// Ping pings every node, so their states are up to date, and succeeds when
+// at least one of them is reachable.
+func (b *Balancer) Ping(ctx context.Context) error {
+	errs := make([]error, len(b.nodes))
+
+	var wg sync.WaitGroup
+
+	for i, n := range b.nodes {
+		if !n.breaker.Allow() {
+			errs[i] = domain.NewJudgeUnavailableError(errBreakerOpen)
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			n.begin()
+			errs[i] = n.Client.Ping(ctx)
+			n.end(ctx, errs[i], b.now())
+		}()
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err := error(domain.NewJudgeUnavailableError(errNoJudgeNodes))
+
+	for i := range errs {
+		if errs[i] == nil {
+			return nil
+		}
+
+		err = errs[i]
+	}
+
+	return errors.Wrap(err, "Ping judge balancer")
+}
+
+// Nodes returns stats of judge nodes in the configured order.
+func (b *Balancer) Nodes() []domain.JudgeNodeStats {
+	now := b.now()
+	stats := make([]domain.JudgeNodeStats, 0, len(b.nodes))
+
+	for _, n := range b.nodes {
+		stats = append(stats, n.stats(now))
+	}
+
+	return stats
+}
+
+// do sends the request to the least loaded node which is not known to be down
+// and fails over to the next node while nodes are unavailable or busy.
+// Requests which are not idempotent fail over only when they were not
+// accepted by the node.
+func (b *Balancer) do(ctx context.Context, idempotent bool, request func(n *node) error) (int, error) {
+	tried := make([]bool, len(b.nodes))
+	err := error(domain.NewJudgeUnavailableError(errNoJudgeNodes))
+
+	for {
+		i, ok := b.pick(tried)
+		if !ok {
+			return 0, err
+		}
+
+		tried[i] = true
+		n := b.nodes[i]
+
+		n.begin()
+		err = request(n)
+		n.end(ctx, err, b.now())
+
+		var unavailableErr *domain.JudgeUnavailableError
+		var queueIsFullErr *domain.JudgeQueueIsFullError
+
+		switch {
+		case ctx.Err() != nil:
+			return 0, ctx.Err()
+		case !idempotent && err != nil && !notAccepted(err):
+			return i, err
+		case errors.As(err, &unavailableErr), errors.As(err, &queueIsFullErr):
+			continue
+		default:
+			return i, err
+		}
+	}
+}
+
+// pick returns index of the node with the least load per unit of weight among
+// nodes which were not tried yet and are let through by their breakers.
+func (b *Balancer) pick(tried []bool) (int, bool) {
+	now := b.now()
+	candidates := make([]int, 0, len(b.nodes))
+	scores := make([]float64, len(b.nodes))
+
+	for i, n := range b.nodes {
+		if tried[i] || n.breaker.State() == BreakerStateOpen {
+			continue
+		}
+
+		candidates = append(candidates, i)
+		scores[i] = float64(n.load(now)+1) / float64(n.Weight)
+	}
+
+	slices.SortStableFunc(candidates, func(x, y int) int {
+		return cmp.Compare(scores[x], scores[y])
+	})
+
+	for _, i := range candidates {
+		if b.nodes[i].breaker.Allow() {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// decodeToken returns index of the node which issued the token and the token
+// local to the node. Names of nodes may contain dots, tokens of nodes do not.
+func (b *Balancer) decodeToken(token string) (int, string, error) {
+	sep := strings.LastIndex(token, ".")
+	if sep < 0 {
+		return 0, "", struct_errors.NewInternalErr(fmt.Errorf("token %q is not issued by judge balancer", token))
+	}
+
+	i, ok := b.byName[token[:sep]]
+	if !ok {
+		return 0, "", struct_errors.NewInternalErr(fmt.Errorf("token %q is not issued by judge balancer", token))
+	}
+
+	return i, token[sep+1:], nil
+}
+
+func (b *Balancer) encodeToken(i int, token string) string {
+	return domain.JudgeNodeToken(b.nodes[i].Name, token)
+}
+
+// withCallbackNode adds name of the node to the signed callback URL of the
+// submission, so the token of the callback can be encoded like the token
+// returned by the balancer.
+func (b *Balancer) withCallbackNode(n *node, data domain.CreateJudgeSubmission) domain.CreateJudgeSubmission {
+	if data.CallbackURL == "" {
+		return data
+	}
+
+	callbackURL, err := domain.JudgeCallbackURLWithNode(b.callbackSecret, data.CallbackURL, n.Name)
+	if err != nil {
+		return data
+	}
+
+	data.CallbackURL = callbackURL
+
+	return data
+}
+
+func (n *node) begin() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.inFlight++
+	n.requests++
+}
+
+// end counts result of the request by the breaker of the node, a full queue
+// means that the node is alive, it is just busy.
+func (n *node) end(ctx context.Context, err error, now time.Time) {
+	var unavailableErr *domain.JudgeUnavailableError
+	var queueIsFullErr *domain.JudgeQueueIsFullError
+
+	switch {
+	case ctx.Err() != nil:
+		n.breaker.Abort()
+	case errors.As(err, &unavailableErr):
+		n.breaker.Failure()
+	default:
+		n.breaker.Success()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.inFlight--
+
+	if ctx.Err() == nil && (unavailableErr != nil || errors.As(err, &queueIsFullErr)) {
+		n.failures++
+		n.lastError = err.Error()
+		n.lastErrorAt = now
+	}
+}
+
+func (n *node) addPending(tokens []string, now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.purgePending(now)
+
+	for i := range tokens {
+		n.pending[tokens[i]] = now
+	}
+}
+
+func (n *node) finishPending(infos []domain.JudgeSubmissionInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i := range infos {
+		if infos[i].Status.IsFinished() {
+			delete(n.pending, infos[i].Token)
+		}
+	}
+}
+
+// load returns requests being sent to the node and submissions running on it.
+func (n *node) load(now time.Time) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.purgePending(now)
+
+	return n.inFlight + len(n.pending)
+}
+
+func (n *node) purgePending(now time.Time) {
+	for token, createdAt := range n.pending {
+		if now.Sub(createdAt) > pendingTTL {
+			delete(n.pending, token)
+		}
+	}
+}
+
+func (n *node) stats(now time.Time) domain.JudgeNodeStats {
+	state := n.breaker.State()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.purgePending(now)
+
+	stats := domain.JudgeNodeStats{
+		Name:     n.Name,
+		Address:  n.Address,
+		Weight:   n.Weight,
+		State:    state,
+		InFlight: n.inFlight,
+		Pending:  len(n.pending),
+		Requests: n.requests,
+		Failures: n.failures,
+	}
+
+	if n.lastError != "" {
+		lastError := n.lastError
+		lastErrorAt := domain.IntTime(n.lastErrorAt)
+
+		stats.LastError = &lastError
+		stats.LastErrorAt = &lastErrorAt
+	}
+
+	return stats
+}
@@ -0,0 +1,94 @@
+package judge
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half_open"
+)
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// breaker is a circuit breaker: it opens after threshold failures in a row
+// and rejects requests during cooldown, then lets a single trial request
+// through and closes again if it succeeds.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	now       func() time.Time
+}
+
+// Allow reports whether the request can be sent to the judge.
+func (b *breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.trial = true
+
+	return true
+}
+
+func (b *breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// Abort is called when the request was cancelled by the caller and tells
+// nothing about the judge.
+func (b *breaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.threshold:
+		return BreakerStateClosed
+	case b.trial || b.now().Sub(b.openedAt) >= b.cooldown:
+		return BreakerStateHalfOpen
+	default:
+		return BreakerStateOpen
+	}
+}
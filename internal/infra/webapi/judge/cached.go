@@ -0,0 +1,168 @@
+package judge
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"sync"
+	"time"
+)
+
+// capabilityRetryInterval bounds how often capabilities are fetched again
+// after the judge failed to answer, so requests do not pile up on a judge
+// which is down.
+const capabilityRetryInterval = time.Second * 10
+
+// capabilityFetchTimeout bounds a single fetch of capabilities together with
+// retries of the resilient client, so a slow judge does not hold the callers.
+const capabilityFetchTimeout = time.Second * 5
+
+// NewCached wraps the judge client with cache of its capabilities: languages
+// and statuses are fetched on first use and refreshed once they are older than
+// refreshInterval. When refresh fails the stale values are served, an error is
+// returned only if capabilities were never discovered.
+func NewCached(c client, refreshInterval time.Duration) *Cached {
+	return &Cached{
+		client:    c,
+		languages: newCapability(c.GetAvailableLanguages, refreshInterval),
+		statuses:  newCapability(c.GetAvailableStatuses, refreshInterval),
+	}
+}
+
+type Cached struct {
+	client
+	languages *capability[[]domain.JudgeLanguageInfo]
+	statuses  *capability[[]domain.JudgeStatusInfo]
+}
+
+func (c *Cached) GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error) {
+	languages, err := c.languages.get(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableLanguages cached judge")
+	}
+
+	return languages, nil
+}
+
+func (c *Cached) GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error) {
+	statuses, err := c.statuses.get(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableStatuses cached judge")
+	}
+
+	return statuses, nil
+}
+
+func newCapability[T any](
+	fetch func(ctx context.Context) (T, error),
+	refreshInterval time.Duration,
+) *capability[T] {
+	return &capability[T]{
+		fetch:           fetch,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// capability is a lazily fetched value of the judge. Only one caller fetches
+// the value at a time without holding the mutex, others get the stale value or
+// the last error at once and wait only when there is nothing to return yet.
+type capability[T any] struct {
+	mu              sync.Mutex
+	fetch           func(ctx context.Context) (T, error)
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	value     T
+	fetched   bool
+	fetchedAt time.Time
+	retryAt   time.Time
+	lastErr   error
+	// fetching is closed when the fetch in flight is over
+	fetching chan struct{}
+}
+
+func (c *capability[T]) get(ctx context.Context) (T, error) {
+	for {
+		c.mu.Lock()
+
+		now := c.now()
+
+		if c.fetched && now.Sub(c.fetchedAt) < c.refreshInterval {
+			value := c.value
+			c.mu.Unlock()
+
+			return value, nil
+		}
+
+		if c.fetching != nil && !c.fetched && c.lastErr == nil {
+			fetching := c.fetching
+			c.mu.Unlock()
+
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				var value T
+
+				return value, ctx.Err()
+			}
+		}
+
+		if c.fetching != nil || now.Before(c.retryAt) {
+			value, err := c.stale()
+			c.mu.Unlock()
+
+			return value, err
+		}
+
+		c.fetching = make(chan struct{})
+		c.mu.Unlock()
+
+		return c.refresh(ctx)
+	}
+}
+
+// refresh fetches the value, it is called by the single caller which set
+// fetching.
+func (c *capability[T]) refresh(ctx context.Context) (T, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, capabilityFetchTimeout)
+	value, err := c.fetch(fetchCtx)
+	cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	close(c.fetching)
+	c.fetching = nil
+
+	if err != nil {
+		// cancellation by the caller tells nothing about the judge
+		if ctx.Err() == nil {
+			c.lastErr = err
+			c.retryAt = c.now().Add(min(capabilityRetryInterval, c.refreshInterval))
+		}
+
+		if c.fetched {
+			return c.value, nil
+		}
+
+		return value, err
+	}
+
+	c.value = value
+	c.fetched = true
+	c.fetchedAt = c.now()
+	c.retryAt = time.Time{}
+	c.lastErr = nil
+
+	return value, nil
+}
+
+func (c *capability[T]) stale() (T, error) {
+	if c.fetched {
+		return c.value, nil
+	}
+
+	return c.value, c.lastErr
+}
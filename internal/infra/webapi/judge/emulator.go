@@ -0,0 +1,259 @@
+package judge
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewEmulator returns handler which serves the subset of Judge0 API used by
+// API on top of the fake judge. It can be served with httptest.NewServer in
+// tests or with StartEmulator for offline development.
+func NewEmulator(fake *Fake) http.Handler {
+	e := &emulator{
+		fake:           fake,
+		callbackClient: &http.Client{Timeout: emulatorCallbackTimeout},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /submissions", e.createSubmission)
+	mux.HandleFunc("POST /submissions/batch", e.createSubmissionBatch)
+	mux.HandleFunc("GET /submissions/batch", e.getSubmissionBatch)
+	mux.HandleFunc("GET /submissions/{token}", e.getSubmission)
+	mux.HandleFunc("GET /languages", e.getLanguages)
+	mux.HandleFunc("GET /statuses", e.getStatuses)
+	mux.HandleFunc("GET /about", e.getAbout)
+
+	return mux
+}
+
+// StartEmulator serves the emulator on a random local port and returns its
+// host and port.
+func StartEmulator(fake *Fake) (host string, port string, err error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", errors.Wrap(err, "StartEmulator judge api")
+	}
+
+	go http.Serve(l, NewEmulator(fake)) //nolint:errcheck
+
+	host, port, err = net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return "", "", errors.Wrap(err, "StartEmulator judge api")
+	}
+
+	return host, port, nil
+}
+
+// emulatorCallbackTimeout bounds a callback request, a hanging callback
+// receiver must not pile up goroutines of the emulator.
+const emulatorCallbackTimeout = time.Second * 10
+
+type emulator struct {
+	fake           *Fake
+	callbackClient *http.Client
+}
+
+func (e *emulator) createSubmission(w http.ResponseWriter, r *http.Request) {
+	var req createSubmissionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	info, err := e.fake.CreateSubmission(r.Context(), req.CreateJudgeSubmission)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	go e.callback(req.CallbackURL, info)
+
+	if r.URL.Query().Get(waitQuery) != "true" {
+		writeJSON(w, http.StatusCreated, createSubmissionBatchResponseItem{Token: info.Token})
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, e.toResponse(info))
+}
+
+func (e *emulator) createSubmissionBatch(w http.ResponseWriter, r *http.Request) {
+	var req createSubmissionBatchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	tokens, err := e.fake.CreateSubmissionBatch(r.Context(), req.Submissions)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	for i := range req.Submissions {
+		if req.Submissions[i].CallbackURL == "" {
+			continue
+		}
+
+		infos, err := e.fake.GetSubmissionBatch(r.Context(), tokens[i:i+1])
+		if err != nil {
+			writeError(w, err)
+
+			return
+		}
+
+		go e.callback(req.Submissions[i].CallbackURL, infos[0])
+	}
+
+	resp := make([]createSubmissionBatchResponseItem, 0, len(tokens))
+	for i := range tokens {
+		resp = append(resp, createSubmissionBatchResponseItem{Token: tokens[i]})
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (e *emulator) getSubmissionBatch(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Split(r.URL.Query().Get(tokensQuery), ",")
+
+	infos, err := e.fake.GetSubmissionBatch(r.Context(), tokens)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	resp := getSubmissionBatchResponse{Submissions: make([]createSubmissionResponse, 0, len(infos))}
+	for i := range infos {
+		resp.Submissions = append(resp.Submissions, e.toResponse(infos[i]))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (e *emulator) getSubmission(w http.ResponseWriter, r *http.Request) {
+	infos, err := e.fake.GetSubmissionBatch(r.Context(), []string{r.PathValue("token")})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, e.toResponse(infos[0]))
+}
+
+func (e *emulator) getLanguages(w http.ResponseWriter, r *http.Request) {
+	languages, err := e.fake.GetAvailableLanguages(r.Context())
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, languages)
+}
+
+func (e *emulator) getStatuses(w http.ResponseWriter, r *http.Request) {
+	statuses, err := e.fake.GetAvailableStatuses(r.Context())
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (e *emulator) getAbout(w http.ResponseWriter, r *http.Request) {
+	if err := e.fake.Ping(r.Context()); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"version": "emulator"})
+}
+
+// callback sends the finished submission to its callback URL like Judge0 does,
+// failed callbacks are not repeated.
+func (e *emulator) callback(callbackURL string, info domain.JudgeSubmissionInfo) {
+	if callbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(e.toResponse(info))
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPut, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.callbackClient.Do(req)
+	if err != nil {
+		return
+	}
+
+	resp.Body.Close()
+}
+
+func (e *emulator) toResponse(info domain.JudgeSubmissionInfo) createSubmissionResponse {
+	resp := createSubmissionResponse{
+		Stdout:        info.Stdout,
+		Stderr:        info.Stderr,
+		CompileOutput: info.CompileOutput,
+		Memory:        info.Memory,
+		Time:          info.Time,
+		Token:         info.Token,
+		Status:        domain.JudgeStatusInfo{ID: info.Status},
+	}
+
+	for i := range fakeStatuses {
+		if fakeStatuses[i].ID == info.Status {
+			resp.Status = fakeStatuses[i]
+		}
+	}
+
+	return resp
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var queueIsFullError *domain.JudgeQueueIsFullError
+	if errors.As(err, &queueIsFullError) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "queue is full"})
+
+		return
+	}
+
+	var unavailableError *domain.JudgeUnavailableError
+	if errors.As(err, &unavailableError) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -8,10 +8,23 @@ type createSubmissionRequest struct {
 }
 
 type createSubmissionResponse struct {
-	Stdout *string                `json:"stdout"`
-	Stderr *string                `json:"stderr"`
-	Memory int                    `json:"memory"`
-	Time   float64                `json:"time,string"`
-	Token  string                 `json:"token"`
-	Status domain.JudgeStatusInfo `json:"status"`
+	Stdout        *string                `json:"stdout"`
+	Stderr        *string                `json:"stderr"`
+	CompileOutput *string                `json:"compile_output"`
+	Memory        int                    `json:"memory"`
+	Time          float64                `json:"time,string"`
+	Token         string                 `json:"token"`
+	Status        domain.JudgeStatusInfo `json:"status"`
+}
+
+type createSubmissionBatchRequest struct {
+	Submissions []domain.CreateJudgeSubmission `json:"submissions"`
+}
+
+type createSubmissionBatchResponseItem struct {
+	Token string `json:"token"`
+}
+
+type getSubmissionBatchResponse struct {
+	Submissions []createSubmissionResponse `json:"submissions"`
 }
@@ -0,0 +1,332 @@
+package judge
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/struct_errors"
+	"strings"
+	"sync"
+)
+
+var (
+	fakeLanguages = []domain.JudgeLanguageInfo{
+		{ID: domain.NodeJS, Name: "JavaScript (Node.js)"},
+		{ID: domain.TypeScript, Name: "TypeScript"},
+	}
+
+	errFakeDown = domain.NewJudgeUnavailableError(errors.New("fake judge is scripted to be down"))
+
+	fakeStatuses = []domain.JudgeStatusInfo{
+		{ID: domain.InQueue, Description: "In Queue"},
+		{ID: domain.Processing, Description: "Processing"},
+		{ID: domain.Accepted, Description: "Accepted"},
+		{ID: domain.WrongAnswer, Description: "Wrong Answer"},
+		{ID: domain.TimeLimitExceeded, Description: "Time Limit Exceeded"},
+		{ID: domain.CompilationError, Description: "Compilation Error"},
+		{ID: domain.RuntimeSIGSEV, Description: "Runtime Error (SIGSEGV)"},
+		{ID: domain.RuntimeSIGXFSZ, Description: "Runtime Error (SIGXFSZ)"},
+		{ID: domain.RuntimeSIGFPE, Description: "Runtime Error (SIGFPE)"},
+		{ID: domain.RuntimeSIGABRT, Description: "Runtime Error (SIGABRT)"},
+		{ID: domain.RuntimeNZEC, Description: "Runtime Error (NZEC)"},
+		{ID: domain.RuntimeOther, Description: "Runtime Error (Other)"},
+		{ID: domain.InternalError, Description: "Internal Error"},
+		{ID: domain.ExecFormatError, Description: "Exec Format Error"},
+	}
+)
+
+// FakeStdout is stdout of submissions without scripted one.
+type FakeStdout string
+
+const (
+	// FakeStdoutEcho prints stdin back, so solutions of tasks where the
+	// output equals the input are accepted
+	FakeStdoutEcho  FakeStdout = "echo"
+	FakeStdoutEmpty FakeStdout = "empty"
+)
+
+// FakeVerdict describes how the fake judge answers a submission.
+type FakeVerdict struct {
+	// Status of the submission, when empty stdout is compared with the
+	// expected output the same way Judge0 does it
+	Status domain.JudgeStatus
+	// Stdout of the submission, when nil it is made by the default stdout of
+	// the fake judge. Solutions are sent without expected output and checked
+	// by the solution manager, so their stdout has to be scripted
+	Stdout *string
+	Stderr *string
+	// CompileOutput is returned with CompilationError status
+	CompileOutput *string
+	Time          float64
+	Memory        int
+}
+
+// NewFake returns in-memory judge which echoes stdin of every submission unless
+// it is scripted otherwise with SetVerdict, SetDefaultVerdict, SetDefaultStdout,
+// SetQueueFull or SetUnavailable.
+func NewFake() *Fake {
+	return &Fake{
+		verdicts:      make(map[string]FakeVerdict),
+		defaultStdout: FakeStdoutEcho,
+		submissions:   make(map[string]domain.JudgeSubmissionInfo),
+	}
+}
+
+type Fake struct {
+	mu             sync.Mutex
+	verdicts       map[string]FakeVerdict
+	defaultVerdict FakeVerdict
+	defaultStdout  FakeStdout
+	queueFullCount int
+	downCount      int
+	submissions    map[string]domain.JudgeSubmissionInfo
+	created        []domain.CreateJudgeSubmission
+}
+
+// SetVerdict scripts the answer for submissions with the given stdin.
+func (f *Fake) SetVerdict(stdin string, v FakeVerdict) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.verdicts[stdin] = v
+}
+
+// SetDefaultVerdict scripts the answer for submissions without own verdict.
+func (f *Fake) SetDefaultVerdict(v FakeVerdict) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.defaultVerdict = v
+}
+
+// SetDefaultStdout sets stdout of submissions without scripted one.
+func (f *Fake) SetDefaultStdout(stdout FakeStdout) error {
+	if stdout != FakeStdoutEcho && stdout != FakeStdoutEmpty {
+		return errors.Errorf("unknown stdout %q of fake judge", stdout)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.defaultStdout = stdout
+
+	return nil
+}
+
+// SetQueueFull makes next n create calls fail with JudgeQueueIsFullError.
+func (f *Fake) SetQueueFull(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queueFullCount = n
+}
+
+// SetUnavailable makes next n calls fail with JudgeUnavailableError.
+func (f *Fake) SetUnavailable(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.downCount = n
+}
+
+// Submissions returns every submission accepted by the fake judge.
+func (f *Fake) Submissions() []domain.CreateJudgeSubmission {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	created := make([]domain.CreateJudgeSubmission, len(f.created))
+	copy(created, f.created)
+
+	return created
+}
+
+func (f *Fake) CreateSubmission(
+	ctx context.Context,
+	data domain.CreateJudgeSubmission,
+) (domain.JudgeSubmissionInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(errFakeDown, "CreateSubmission fake judge")
+	}
+
+	if f.takeQueueFull() {
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(domain.NewJudgeQueueIsFullError(), "CreateSubmission fake judge")
+	}
+
+	info, err := f.judge(data)
+	if err != nil {
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission fake judge")
+	}
+
+	return info, nil
+}
+
+func (f *Fake) CreateSubmissionBatch(
+	ctx context.Context,
+	data []domain.CreateJudgeSubmission,
+) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return nil, errors.Wrap(errFakeDown, "CreateSubmissionBatch fake judge")
+	}
+
+	if f.takeQueueFull() {
+		return nil, errors.Wrap(domain.NewJudgeQueueIsFullError(), "CreateSubmissionBatch fake judge")
+	}
+
+	tokens := make([]string, 0, len(data))
+
+	for i := range data {
+		info, err := f.judge(data[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "CreateSubmissionBatch fake judge")
+		}
+
+		tokens = append(tokens, info.Token)
+	}
+
+	return tokens, nil
+}
+
+func (f *Fake) GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return nil, errors.Wrap(errFakeDown, "GetSubmissionBatch fake judge")
+	}
+
+	infos := make([]domain.JudgeSubmissionInfo, 0, len(tokens))
+
+	for i := range tokens {
+		info, ok := f.submissions[tokens[i]]
+		if !ok {
+			err := struct_errors.NewErrNotFound("Submission not found", fmt.Errorf("token %s", tokens[i]))
+
+			return nil, errors.Wrap(err, "GetSubmissionBatch fake judge")
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (f *Fake) GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return nil, errors.Wrap(errFakeDown, "GetAvailableLanguages fake judge")
+	}
+
+	return fakeLanguages, nil
+}
+
+func (f *Fake) GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return nil, errors.Wrap(errFakeDown, "GetAvailableStatuses fake judge")
+	}
+
+	return fakeStatuses, nil
+}
+
+func (f *Fake) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.takeDown() {
+		return errors.Wrap(errFakeDown, "Ping fake judge")
+	}
+
+	return nil
+}
+
+func (f *Fake) takeQueueFull() bool {
+	if f.queueFullCount <= 0 {
+		return false
+	}
+
+	f.queueFullCount--
+
+	return true
+}
+
+func (f *Fake) takeDown() bool {
+	if f.downCount <= 0 {
+		return false
+	}
+
+	f.downCount--
+
+	return true
+}
+
+// judge must be called with locked mutex.
+func (f *Fake) judge(data domain.CreateJudgeSubmission) (domain.JudgeSubmissionInfo, error) {
+	token, err := newToken()
+	if err != nil {
+		return domain.JudgeSubmissionInfo{}, err
+	}
+
+	v, ok := f.verdicts[data.Stdin]
+	if !ok {
+		v = f.defaultVerdict
+	}
+
+	var stdout string
+
+	switch {
+	case v.Stdout != nil:
+		stdout = *v.Stdout
+	case f.defaultStdout == FakeStdoutEcho:
+		stdout = data.Stdin
+	}
+
+	status := v.Status
+	if status == 0 {
+		status = domain.Accepted
+
+		if data.ExpectedOutput != "" && strings.TrimRight(stdout, " \n") != strings.TrimRight(data.ExpectedOutput, " \n") {
+			status = domain.WrongAnswer
+		}
+	}
+
+	info := domain.JudgeSubmissionInfo{
+		Token:         token,
+		Stdout:        &stdout,
+		Stderr:        v.Stderr,
+		CompileOutput: v.CompileOutput,
+		Time:          v.Time,
+		Memory:        v.Memory,
+		Status:        status,
+	}
+
+	f.submissions[token] = info
+	f.created = append(f.created, data)
+
+	return info, nil
+}
+
+// newToken returns random UUID v4, tokens are stored in uuid columns.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "newToken fake judge")
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
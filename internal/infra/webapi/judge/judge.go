@@ -6,28 +6,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
 	"lcode/config"
 	"lcode/internal/domain"
 	"lcode/pkg/struct_errors"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const (
-	waitQuery = "wait"
+	waitQuery   = "wait"
+	tokensQuery = "tokens"
+	fieldsQuery = "fields"
 
-	submissionFields = "token,stdout,stderr,time,memory,message,status"
+	submissionFields = "token,stdout,stderr,compile_output,time,memory,message,status"
+
+	authTokenHeader = "X-Auth-Token"
+	authUserHeader  = "X-Auth-User"
 )
 
-func New(cfg *config.JudgeConfig) *API {
+func New(endpoint config.JudgeEndpointConfig, requestTimeout time.Duration) *API {
 	return &API{
-		addr:   fmt.Sprintf("http://%s:%s", cfg.Host, cfg.Port),
-		client: &http.Client{},
+		addr:      fmt.Sprintf("%s://%s", endpoint.Scheme, net.JoinHostPort(endpoint.Host, endpoint.Port)),
+		authToken: endpoint.AuthToken,
+		authUser:  endpoint.AuthUser,
+		client:    &http.Client{Timeout: requestTimeout},
 	}
 }
 
 type API struct {
-	addr   string
-	client *http.Client
+	addr      string
+	authToken string
+	authUser  string
+	client    *http.Client
+}
+
+// Addr returns base address of the judge.
+func (a *API) Addr() string {
+	return a.addr
 }
 
 func (a *API) CreateSubmission(
@@ -46,7 +64,7 @@ func (a *API) CreateSubmission(
 		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission judge api")
 	}
 
-	req, err := http.NewRequest("POST", a.addr+"/submissions", bytes.NewBuffer(jsonData))
+	req, err := a.newRequest(ctx, "POST", "/submissions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission judge api")
 	}
@@ -57,24 +75,19 @@ func (a *API) CreateSubmission(
 	q.Add(waitQuery, "true")
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := a.client.Do(req.WithContext(ctx))
+	resp, err := a.client.Do(req)
 	if err != nil {
-		err = struct_errors.NewBaseErr("Code solving system is unavailable", err)
-
-		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission judge api")
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(requestError(ctx, err), "CreateSubmission judge api")
 	}
+	defer resp.Body.Close()
 
 	d := json.NewDecoder(resp.Body)
 
 	switch resp.StatusCode {
 	case http.StatusCreated:
 		err = d.Decode(&submissionResp)
-	case http.StatusServiceUnavailable:
-		err = domain.NewJudgeQueueIsFullError()
 	default:
-		err = struct_errors.NewInternalErr(
-			fmt.Errorf("bad request to judge api with status code: %d", resp.StatusCode),
-		)
+		err = statusError(resp.StatusCode)
 	}
 
 	if err != nil {
@@ -82,31 +95,144 @@ func (a *API) CreateSubmission(
 	}
 
 	info := domain.JudgeSubmissionInfo{
-		Token:  submissionResp.Token,
-		Stdout: submissionResp.Stdout,
-		Stderr: submissionResp.Stderr,
-		Time:   submissionResp.Time,
-		Memory: submissionResp.Memory,
-		Status: submissionResp.Status.ID,
+		Token:         submissionResp.Token,
+		Stdout:        submissionResp.Stdout,
+		Stderr:        submissionResp.Stderr,
+		CompileOutput: submissionResp.CompileOutput,
+		Time:          submissionResp.Time,
+		Memory:        submissionResp.Memory,
+		Status:        submissionResp.Status.ID,
 	}
 
 	return info, nil
 }
 
+// CreateSubmissionBatch creates submissions without waiting for their results
+// and returns tokens in the same order as the passed data.
+func (a *API) CreateSubmissionBatch(
+	ctx context.Context,
+	data []domain.CreateJudgeSubmission,
+) ([]string, error) {
+	var batchResp []createSubmissionBatchResponseItem
+
+	jsonData, err := json.Marshal(createSubmissionBatchRequest{Submissions: data})
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateSubmissionBatch judge api")
+	}
+
+	req, err := a.newRequest(ctx, "POST", "/submissions/batch", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateSubmissionBatch judge api")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(requestError(ctx, err), "CreateSubmissionBatch judge api")
+	}
+	defer resp.Body.Close()
+
+	d := json.NewDecoder(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		err = d.Decode(&batchResp)
+	default:
+		err = statusError(resp.StatusCode)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateSubmissionBatch judge api")
+	}
+
+	if len(batchResp) != len(data) {
+		err = struct_errors.NewInternalErr(
+			fmt.Errorf("judge api returned %d tokens for %d submissions", len(batchResp), len(data)),
+		)
+
+		return nil, errors.Wrap(err, "CreateSubmissionBatch judge api")
+	}
+
+	tokens := make([]string, 0, len(batchResp))
+
+	for i := range batchResp {
+		if batchResp[i].Token == "" {
+			err = struct_errors.NewInternalErr(fmt.Errorf("judge api rejected submission #%d", i+1))
+
+			return nil, errors.Wrap(err, "CreateSubmissionBatch judge api")
+		}
+
+		tokens = append(tokens, batchResp[i].Token)
+	}
+
+	return tokens, nil
+}
+
+// GetSubmissionBatch returns current state of submissions by their tokens.
+// Submissions which are not finished yet have InQueue or Processing status.
+func (a *API) GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error) {
+	var batchResp getSubmissionBatchResponse
+
+	req, err := a.newRequest(ctx, "GET", "/submissions/batch", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSubmissionBatch judge api")
+	}
+
+	q := req.URL.Query()
+	q.Add(tokensQuery, strings.Join(tokens, ","))
+	q.Add(fieldsQuery, submissionFields)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(requestError(ctx, err), "GetSubmissionBatch judge api")
+	}
+	defer resp.Body.Close()
+
+	d := json.NewDecoder(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = d.Decode(&batchResp)
+	default:
+		err = statusError(resp.StatusCode)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSubmissionBatch judge api")
+	}
+
+	infos := make([]domain.JudgeSubmissionInfo, 0, len(batchResp.Submissions))
+
+	for i := range batchResp.Submissions {
+		infos = append(infos, domain.JudgeSubmissionInfo{
+			Token:         batchResp.Submissions[i].Token,
+			Stdout:        batchResp.Submissions[i].Stdout,
+			Stderr:        batchResp.Submissions[i].Stderr,
+			CompileOutput: batchResp.Submissions[i].CompileOutput,
+			Time:          batchResp.Submissions[i].Time,
+			Memory:        batchResp.Submissions[i].Memory,
+			Status:        batchResp.Submissions[i].Status.ID,
+		})
+	}
+
+	return infos, nil
+}
+
 func (a *API) GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error) {
 	var languages []domain.JudgeLanguageInfo
 
-	req, err := http.NewRequest("GET", a.addr+"/languages", nil)
+	req, err := a.newRequest(ctx, "GET", "/languages", nil)
 	if err != nil {
 		return languages, errors.Wrap(err, "GetAvailableLanguages judge api")
 	}
 
-	resp, err := a.client.Do(req.WithContext(ctx))
+	resp, err := a.client.Do(req)
 	if err != nil {
-		err = struct_errors.NewBaseErr("Code solving system is unavailable", err)
-
-		return languages, errors.Wrap(err, "GetAvailableLanguages judge api")
+		return languages, errors.Wrap(requestError(ctx, err), "GetAvailableLanguages judge api")
 	}
+	defer resp.Body.Close()
 
 	d := json.NewDecoder(resp.Body)
 
@@ -114,9 +240,7 @@ func (a *API) GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguage
 	case http.StatusOK:
 		err = d.Decode(&languages)
 	default:
-		err = struct_errors.NewInternalErr(
-			fmt.Errorf("bad request to judge api with status code: %d", resp.StatusCode),
-		)
+		err = statusError(resp.StatusCode)
 	}
 
 	if err != nil {
@@ -129,17 +253,16 @@ func (a *API) GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguage
 func (a *API) GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error) {
 	var statuses []domain.JudgeStatusInfo
 
-	req, err := http.NewRequest("GET", a.addr+"/statuses", nil)
+	req, err := a.newRequest(ctx, "GET", "/statuses", nil)
 	if err != nil {
 		return statuses, errors.Wrap(err, "GetAvailableStatuses judge api")
 	}
 
-	resp, err := a.client.Do(req.WithContext(ctx))
+	resp, err := a.client.Do(req)
 	if err != nil {
-		err = struct_errors.NewBaseErr("Code solving system is unavailable", err)
-
-		return statuses, errors.Wrap(err, "GetAvailableStatuses judge api")
+		return statuses, errors.Wrap(requestError(ctx, err), "GetAvailableStatuses judge api")
 	}
+	defer resp.Body.Close()
 
 	d := json.NewDecoder(resp.Body)
 
@@ -147,9 +270,7 @@ func (a *API) GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInf
 	case http.StatusOK:
 		err = d.Decode(&statuses)
 	default:
-		err = struct_errors.NewInternalErr(
-			fmt.Errorf("bad request to judge api with status code: %d", resp.StatusCode),
-		)
+		err = statusError(resp.StatusCode)
 	}
 
 	if err != nil {
@@ -158,3 +279,69 @@ func (a *API) GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInf
 
 	return statuses, nil
 }
+
+// Ping checks that the judge is reachable, Judge0 answers its about page
+// without touching the submissions queue.
+func (a *API) Ping(ctx context.Context) error {
+	req, err := a.newRequest(ctx, "GET", "/about", nil)
+	if err != nil {
+		return errors.Wrap(err, "Ping judge api")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return errors.Wrap(requestError(ctx, err), "Ping judge api")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(statusError(resp.StatusCode), "Ping judge api")
+	}
+
+	return nil
+}
+
+// newRequest creates request to the path of the judge authenticated with
+// configured headers.
+func (a *API) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.addr+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.authToken != "" {
+		req.Header.Set(authTokenHeader, a.authToken)
+	}
+
+	if a.authUser != "" {
+		req.Header.Set(authUserHeader, a.authUser)
+	}
+
+	return req, nil
+}
+
+// requestError tells cancellation of the request by the caller from failure
+// to reach the judge.
+func requestError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return domain.NewJudgeUnavailableError(err)
+}
+
+// statusError converts unexpected status code of the judge response to error.
+// Judge0 answers 503 when its queue is full, other server errors mean the
+// judge is unavailable and requests with them can be retried.
+func statusError(code int) error {
+	switch {
+	case code == http.StatusServiceUnavailable:
+		return domain.NewJudgeQueueIsFullError()
+	case code >= http.StatusInternalServerError:
+		return domain.NewJudgeUnavailableError(fmt.Errorf("judge api responded with status code: %d", code))
+	default:
+		return struct_errors.NewInternalErr(
+			fmt.Errorf("bad request to judge api with status code: %d", code),
+		)
+	}
+}
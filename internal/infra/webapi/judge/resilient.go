@@ -0,0 +1,221 @@
+package judge
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+var errBreakerOpen = errors.New("judge circuit breaker is open")
+
+type client interface {
+	CreateSubmission(ctx context.Context, data domain.CreateJudgeSubmission) (domain.JudgeSubmissionInfo, error)
+	CreateSubmissionBatch(ctx context.Context, data []domain.CreateJudgeSubmission) ([]string, error)
+	GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error)
+	GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error)
+	GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error)
+	Ping(ctx context.Context) error
+}
+
+// NewResilient wraps the judge client with retries and circuit breaker.
+// Requests failed because the judge is unavailable or its queue is full are
+// retried with exponential backoff and jitter, other errors are returned
+// right away. Submissions are created again only when the judge surely did
+// not accept the previous request, otherwise a retry could duplicate them.
+func NewResilient(c client, cfg *config.JudgeConfig) *Resilient {
+	return &Resilient{
+		client:      c,
+		breaker:     newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		maxAttempts: cfg.RetryMaxAttempts,
+		baseDelay:   cfg.RetryBaseDelay,
+		maxDelay:    cfg.RetryMaxDelay,
+	}
+}
+
+type Resilient struct {
+	client      client
+	breaker     *breaker
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// BreakerState returns state of the circuit breaker: closed, open or half_open.
+func (r *Resilient) BreakerState() string {
+	return r.breaker.State()
+}
+
+func (r *Resilient) CreateSubmission(
+	ctx context.Context,
+	data domain.CreateJudgeSubmission,
+) (info domain.JudgeSubmissionInfo, err error) {
+	err = r.do(ctx, false, func() (err error) {
+		info, err = r.client.CreateSubmission(ctx, data)
+
+		return err
+	})
+	if err != nil {
+		return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "CreateSubmission resilient judge")
+	}
+
+	return info, nil
+}
+
+func (r *Resilient) CreateSubmissionBatch(
+	ctx context.Context,
+	data []domain.CreateJudgeSubmission,
+) (tokens []string, err error) {
+	err = r.do(ctx, false, func() (err error) {
+		tokens, err = r.client.CreateSubmissionBatch(ctx, data)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateSubmissionBatch resilient judge")
+	}
+
+	return tokens, nil
+}
+
+func (r *Resilient) GetSubmissionBatch(
+	ctx context.Context,
+	tokens []string,
+) (infos []domain.JudgeSubmissionInfo, err error) {
+	err = r.do(ctx, true, func() (err error) {
+		infos, err = r.client.GetSubmissionBatch(ctx, tokens)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSubmissionBatch resilient judge")
+	}
+
+	return infos, nil
+}
+
+func (r *Resilient) GetAvailableLanguages(ctx context.Context) (languages []domain.JudgeLanguageInfo, err error) {
+	err = r.do(ctx, true, func() (err error) {
+		languages, err = r.client.GetAvailableLanguages(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableLanguages resilient judge")
+	}
+
+	return languages, nil
+}
+
+func (r *Resilient) GetAvailableStatuses(ctx context.Context) (statuses []domain.JudgeStatusInfo, err error) {
+	err = r.do(ctx, true, func() (err error) {
+		statuses, err = r.client.GetAvailableStatuses(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableStatuses resilient judge")
+	}
+
+	return statuses, nil
+}
+
+// Ping is not retried, health checks want to know the state of the judge
+// right now. Its result is still counted by the circuit breaker.
+func (r *Resilient) Ping(ctx context.Context) error {
+	if !r.breaker.Allow() {
+		return errors.Wrap(domain.NewJudgeUnavailableError(errBreakerOpen), "Ping resilient judge")
+	}
+
+	err := r.client.Ping(ctx)
+
+	var unavailableErr *domain.JudgeUnavailableError
+
+	switch {
+	case ctx.Err() != nil:
+		r.breaker.Abort()
+
+		return ctx.Err()
+	case errors.As(err, &unavailableErr):
+		r.breaker.Failure()
+	default:
+		r.breaker.Success()
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "Ping resilient judge")
+	}
+
+	return nil
+}
+
+// do calls the request until it succeeds, fails with an error which can not
+// be fixed by retrying or the retry budget is spent. Requests which are not
+// idempotent are retried only when they were not accepted by the judge.
+func (r *Resilient) do(ctx context.Context, idempotent bool, request func() error) error {
+	for attempt := 1; ; attempt++ {
+		if !r.breaker.Allow() {
+			return domain.NewJudgeUnavailableError(errBreakerOpen)
+		}
+
+		err := request()
+
+		var unavailableErr *domain.JudgeUnavailableError
+		var queueIsFullErr *domain.JudgeQueueIsFullError
+
+		switch {
+		case ctx.Err() != nil:
+			r.breaker.Abort()
+
+			return ctx.Err()
+		case errors.As(err, &unavailableErr):
+			r.breaker.Failure()
+		case errors.As(err, &queueIsFullErr):
+			// the judge is alive, it is just busy
+			r.breaker.Success()
+		default:
+			r.breaker.Success()
+
+			return err
+		}
+
+		if attempt >= r.maxAttempts || (!idempotent && !notAccepted(err)) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.backoff(attempt)):
+		}
+	}
+}
+
+// notAccepted tells whether the failed request surely did not create anything
+// on the judge: no node could be tried, the connection was not established or
+// the judge rejected the request because its queue is full. Other failures, e.g. a timeout after the
+// request was sent, may happen after the judge accepted it.
+func notAccepted(err error) bool {
+	var queueIsFullErr *domain.JudgeQueueIsFullError
+	var opErr *net.OpError
+
+	return errors.Is(err, errNoJudgeNodes) ||
+		errors.As(err, &queueIsFullErr) ||
+		(errors.As(err, &opErr) && opErr.Op == "dial")
+}
+
+// backoff returns delay before the next attempt: exponentially growing up to
+// maxDelay with full jitter in its upper half.
+func (r *Resilient) backoff(attempt int) time.Duration {
+	delay := r.maxDelay
+	if attempt < 32 {
+		delay = min(r.baseDelay<<(attempt-1), r.maxDelay)
+	}
+
+	half := delay / 2
+
+	return half + rand.N(half+1)
+}
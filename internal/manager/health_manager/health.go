@@ -0,0 +1,92 @@
+package health_manager
+
+import (
+	"context"
+	"lcode/config"
+	"lcode/internal/domain"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// pingTimeout bounds a single check, readiness probes must answer fast even
+// when a dependency hangs.
+const pingTimeout = time.Second * 2
+
+type (
+	Services struct {
+		Database Pinger
+		Judge    Pinger
+	}
+
+	Manager struct {
+		cfg      *config.Config
+		logger   *slog.Logger
+		services *Services
+	}
+)
+
+func New(cfg *config.Config, logger *slog.Logger, services *Services) *Manager {
+	return &Manager{
+		cfg:      cfg,
+		logger:   logger,
+		services: services,
+	}
+}
+
+// Readiness checks the database and the judge concurrently. Without the
+// database the API can not serve anything, without the judge it works in
+// degraded mode.
+func (m *Manager) Readiness(ctx context.Context) domain.Readiness {
+	var r domain.Readiness
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		r.Database = m.check(ctx, "database", m.services.Database)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		r.Judge = m.check(ctx, "judge", m.services.Judge)
+	}()
+
+	wg.Wait()
+
+	switch {
+	case r.Database.Status == domain.ComponentStatusDown:
+		r.Status = domain.ReadinessStatusNotReady
+	case r.Judge.Status == domain.ComponentStatusDown:
+		r.Status = domain.ReadinessStatusDegraded
+	default:
+		r.Status = domain.ReadinessStatusReady
+	}
+
+	return r
+}
+
+func (m *Manager) check(ctx context.Context, name string, p Pinger) domain.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.Ping(ctx)
+
+	h := domain.ComponentHealth{
+		Status:    domain.ComponentStatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		m.logger.Debug("health check failed", slog.String("component", name), slog.String("err", err.Error()))
+
+		h.Status = domain.ComponentStatusDown
+		h.Error = err.Error()
+	}
+
+	return h
+}
@@ -0,0 +1,16 @@
+package health_manager
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	HealthManager interface {
+		Readiness(ctx context.Context) domain.Readiness
+	}
+
+	Pinger interface {
+		Ping(ctx context.Context) error
+	}
+)
@@ -3,7 +3,11 @@ package manager
 import (
 	"lcode/config"
 	"lcode/internal/infra/webapi"
+	"lcode/internal/manager/health_manager"
+	"lcode/internal/manager/language_manager"
+	"lcode/internal/manager/plagiarism_manager"
 	"lcode/internal/manager/problem_manager"
+	"lcode/internal/manager/ranking_manager"
 	"lcode/internal/manager/solution_manager"
 	"lcode/internal/manager/user_manager"
 	"lcode/internal/service"
@@ -16,12 +20,17 @@ type (
 		Config             *config.Config
 		Logger             *slog.Logger
 		TransactionManager *postgres.TransactionProvider
+		DB                 *postgres.DbManager
 	}
 
 	Managers struct {
-		UserManager     *user_manager.Manager
-		ProblemManager  *problem_manager.Manager
-		SolutionManager *solution_manager.Manager
+		UserManager       *user_manager.Manager
+		ProblemManager    *problem_manager.Manager
+		SolutionManager   *solution_manager.Manager
+		HealthManager     *health_manager.Manager
+		LanguageManager   *language_manager.Manager
+		RankingManager    *ranking_manager.Manager
+		PlagiarismManager *plagiarism_manager.Manager
 	}
 )
 
@@ -34,7 +43,8 @@ func New(p *InitParams, services *service.Services, apis *webapi.APIs) *Managers
 			TaskService:         services.Task,
 			TaskTemplateService: services.TaskTemplate,
 			TestCaseService:     services.TestCase,
-			Judge:               apis.Judge,
+			TestCaseFSService:   services.TestCaseFS,
+			LanguageService:     services.Language,
 		},
 	)
 
@@ -46,7 +56,13 @@ func New(p *InitParams, services *service.Services, apis *webapi.APIs) *Managers
 			ProblemManager: problemManager,
 			Solution:       services.Solution,
 			SolutionResult: services.SolutionResult,
+			SolutionOutput: services.SolutionOutput,
+			SolutionEvent:  services.SolutionEvent,
+			SolutionQueue:  services.SolutionQueue,
+			Rejudge:        services.Rejudge,
+			Language:       services.Language,
 			Judge:          apis.Judge,
+			JudgeNodes:     apis.JudgeNodes,
 		},
 	)
 
@@ -60,9 +76,53 @@ func New(p *InitParams, services *service.Services, apis *webapi.APIs) *Managers
 		},
 	)
 
+	healthManager := health_manager.New(
+		p.Config,
+		p.Logger,
+		&health_manager.Services{
+			Database: p.DB,
+			Judge:    apis.Judge,
+		},
+	)
+
+	languageManager := language_manager.New(
+		p.Config,
+		p.Logger,
+		&language_manager.Services{
+			Language: services.Language,
+			Judge:    apis.Judge,
+		},
+	)
+
+	rankingManager := ranking_manager.New(
+		p.Config,
+		p.Logger,
+		p.TransactionManager,
+		&ranking_manager.Services{
+			Solution:      services.Solution,
+			SolutionStats: services.SolutionStats,
+		},
+	)
+
+	plagiarismManager := plagiarism_manager.New(
+		p.Config,
+		p.Logger,
+		p.TransactionManager,
+		&plagiarism_manager.Services{
+			Plagiarism:   services.Plagiarism,
+			Solution:     services.Solution,
+			TaskTemplate: services.TaskTemplate,
+			Language:     services.Language,
+		},
+	)
+
 	return &Managers{
-		UserManager:     userManager,
-		ProblemManager:  problemManager,
-		SolutionManager: solutionManager,
+		UserManager:       userManager,
+		ProblemManager:    problemManager,
+		SolutionManager:   solutionManager,
+		HealthManager:     healthManager,
+		LanguageManager:   languageManager,
+		RankingManager:    rankingManager,
+		PlagiarismManager: plagiarismManager,
 	}
 }
@@ -0,0 +1,20 @@
+package language_manager
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	LanguageManager interface {
+		CreateLanguage(ctx context.Context, dto domain.LanguageCreateDTO) (domain.Language, error)
+		UpdateLanguage(ctx context.Context, dto domain.LanguageUpdateDTO) (domain.Language, error)
+		Languages(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error)
+		DiscoveredLanguages(ctx context.Context) ([]domain.DiscoveredLanguage, error)
+		RegisteredLanguage(ctx context.Context, id domain.LanguageType) (domain.Language, bool, error)
+	}
+
+	Judge interface {
+		GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error)
+	}
+)
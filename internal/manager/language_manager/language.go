@@ -0,0 +1,146 @@
+package language_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/internal/service/language"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+)
+
+type (
+	Services struct {
+		Language language.Language
+		Judge    Judge
+	}
+
+	Manager struct {
+		cfg      *config.Config
+		logger   *slog.Logger
+		services *Services
+	}
+)
+
+func New(cfg *config.Config, logger *slog.Logger, services *Services) *Manager {
+	return &Manager{
+		cfg:      cfg,
+		logger:   logger,
+		services: services,
+	}
+}
+
+// CreateLanguage registers a language discovered from the judge, its judge
+// name is taken from the judge and used as display name unless one is given.
+func (m *Manager) CreateLanguage(ctx context.Context, dto domain.LanguageCreateDTO) (domain.Language, error) {
+	judgeLanguages, err := m.services.Judge.GetAvailableLanguages(ctx)
+	if err != nil {
+		return domain.Language{}, errors.Wrap(err, "CreateLanguage language manager")
+	}
+
+	judgeLanguage := findJudgeLanguage(judgeLanguages, dto.Input.ID)
+	if judgeLanguage == nil {
+		err = struct_errors.NewBaseErr("Language is not supported by the judge", nil)
+
+		return domain.Language{}, errors.Wrap(err, "CreateLanguage language manager")
+	}
+
+	entity := domain.CreateLanguageEntity{
+		LanguageCreateInput: dto.Input,
+		JudgeName:           judgeLanguage.Name,
+	}
+
+	if entity.Name == "" {
+		entity.Name = judgeLanguage.Name
+	}
+
+	l, err := m.services.Language.Create(ctx, entity)
+	if err != nil {
+		return l, errors.Wrap(err, "CreateLanguage language manager")
+	}
+
+	m.logger.Info("language registered", slog.Int("language_id", int(l.ID)), slog.Bool("enabled", l.Enabled))
+
+	return l, nil
+}
+
+func (m *Manager) UpdateLanguage(ctx context.Context, dto domain.LanguageUpdateDTO) (domain.Language, error) {
+	l, err := m.services.Language.Update(ctx, dto)
+	if err != nil {
+		return l, errors.Wrap(err, "UpdateLanguage language manager")
+	}
+
+	if dto.Input.Enabled != nil {
+		m.logger.Info("language availability changed", slog.Int("language_id", int(l.ID)), slog.Bool("enabled", l.Enabled))
+	}
+
+	return l, nil
+}
+
+func (m *Manager) Languages(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error) {
+	ls, err := m.services.Language.GetAll(ctx, dto)
+	if err != nil {
+		return nil, errors.Wrap(err, "Languages language manager")
+	}
+
+	return ls, nil
+}
+
+// DiscoveredLanguages returns languages of the judge marked with their state
+// in the registry, so admins can see which of them can be registered.
+func (m *Manager) DiscoveredLanguages(ctx context.Context) ([]domain.DiscoveredLanguage, error) {
+	judgeLanguages, err := m.services.Judge.GetAvailableLanguages(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "DiscoveredLanguages language manager")
+	}
+
+	registered, err := m.services.Language.GetAll(ctx, domain.LanguagesDTO{})
+	if err != nil {
+		return nil, errors.Wrap(err, "DiscoveredLanguages language manager")
+	}
+
+	enabled := make(map[domain.LanguageType]bool, len(registered))
+	for i := range registered {
+		enabled[registered[i].ID] = registered[i].Enabled
+	}
+
+	discovered := make([]domain.DiscoveredLanguage, 0, len(judgeLanguages))
+	for i := range judgeLanguages {
+		e, ok := enabled[judgeLanguages[i].ID]
+
+		discovered = append(discovered, domain.DiscoveredLanguage{
+			JudgeLanguageInfo: judgeLanguages[i],
+			Registered:        ok,
+			Enabled:           e,
+		})
+	}
+
+	return discovered, nil
+}
+
+// RegisteredLanguage returns the language from the registry and reports
+// whether it is registered.
+func (m *Manager) RegisteredLanguage(ctx context.Context, id domain.LanguageType) (domain.Language, bool, error) {
+	l, err := m.services.Language.GetByID(ctx, id)
+	if err == nil {
+		return l, true, nil
+	}
+
+	var notFoundErr *struct_errors.ErrNotFound
+	if errors.As(err, &notFoundErr) {
+		return l, false, nil
+	}
+
+	return l, false, errors.Wrap(err, "RegisteredLanguage language manager")
+}
+
+func findJudgeLanguage(languages []domain.JudgeLanguageInfo, id domain.LanguageType) *domain.JudgeLanguageInfo {
+	for i := range languages {
+		if languages[i].ID == id {
+			return &languages[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package plagiarism_manager
+
+import (
+	"cmp"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/pkg/winnowing"
+	"slices"
+)
+
+const (
+	// minSharedFingerprints filters pairs of tiny solutions which are equal
+	// once the template is removed, e.g. a single return statement
+	minSharedFingerprints = 3
+	// fingerprints found in more than commonShare of at least
+	// commonMinSolutions solutions are idioms of the task rather than copies
+	commonShare        = 0.5
+	commonMinSolutions = 5
+)
+
+type pairKey struct {
+	a, b int
+}
+
+// findPairs compares fingerprints of every two solutions of different users.
+// Code is tokenized by the syntax of its language, languages missing in
+// syntaxes are tokenized as C-like. Every k-gram of the templates of the task
+// is removed from fingerprints, so the shared boilerplate does not make
+// solutions similar.
+func findPairs(
+	solutions []domain.Solution,
+	templates []domain.TaskTemplate,
+	syntaxes map[domain.LanguageType]*winnowing.Syntax,
+	cfg config.PlagiarismConfig,
+	threshold float64,
+) []domain.PlagiarismPair {
+	syntax := func(id domain.LanguageType) *winnowing.Syntax {
+		if s, ok := syntaxes[id]; ok {
+			return s
+		}
+
+		return winnowing.CLike
+	}
+
+	boilerplate := make(map[uint64]struct{})
+	for i := range templates {
+		tokens := winnowing.Tokenize(templates[i].Template, syntax(templates[i].LanguageID))
+		// window of one hash selects all of them
+		for _, f := range winnowing.Fingerprints(tokens, cfg.KGramSize, 1) {
+			boilerplate[f.Hash] = struct{}{}
+		}
+	}
+
+	prints := make([]map[uint64]winnowing.Fingerprint, len(solutions))
+	holders := make(map[uint64][]int)
+
+	for i := range solutions {
+		prints[i] = make(map[uint64]winnowing.Fingerprint)
+
+		tokens := winnowing.Tokenize(solutions[i].Code, syntax(solutions[i].LanguageID))
+		for _, f := range winnowing.Fingerprints(tokens, cfg.KGramSize, cfg.WindowSize) {
+			if _, ok := boilerplate[f.Hash]; ok {
+				continue
+			}
+
+			if _, ok := prints[i][f.Hash]; ok {
+				continue
+			}
+
+			prints[i][f.Hash] = f
+			holders[f.Hash] = append(holders[f.Hash], i)
+		}
+	}
+
+	maxHolders := len(solutions)
+	if len(solutions) >= commonMinSolutions {
+		maxHolders = int(float64(len(solutions)) * commonShare)
+	}
+
+	sizes := make([]int, len(solutions))
+	shared := make(map[pairKey][]uint64)
+
+	for h, hs := range holders {
+		if len(hs) > maxHolders {
+			continue
+		}
+
+		for _, i := range hs {
+			sizes[i]++
+		}
+
+		for x := range hs {
+			for y := x + 1; y < len(hs); y++ {
+				if solutions[hs[x]].UserID == solutions[hs[y]].UserID {
+					continue
+				}
+
+				key := pairKey{a: hs[x], b: hs[y]}
+				shared[key] = append(shared[key], h)
+			}
+		}
+	}
+
+	pairs := make([]domain.PlagiarismPair, 0)
+
+	for key, hashes := range shared {
+		if len(hashes) < minSharedFingerprints {
+			continue
+		}
+
+		a, b := solutions[key.a], solutions[key.b]
+		similarityA := 100 * float64(len(hashes)) / float64(sizes[key.a])
+		similarityB := 100 * float64(len(hashes)) / float64(sizes[key.b])
+
+		similarity := max(similarityA, similarityB)
+		if similarity < threshold {
+			continue
+		}
+
+		pairs = append(pairs, domain.PlagiarismPair{
+			SolutionAID: a.Id,
+			UserAID:     a.UserID,
+			SolutionBID: b.Id,
+			UserBID:     b.UserID,
+			SimilarityA: similarityA,
+			SimilarityB: similarityB,
+			Similarity:  similarity,
+			Matches:     matchedRegions(prints[key.a], prints[key.b], hashes),
+		})
+	}
+
+	return pairs
+}
+
+// matchedRegions joins shared fingerprints which are adjacent in both
+// solutions into regions of lines.
+func matchedRegions(a, b map[uint64]winnowing.Fingerprint, hashes []uint64) []domain.PlagiarismMatch {
+	type match struct {
+		a, b winnowing.Fingerprint
+	}
+
+	matches := make([]match, 0, len(hashes))
+	for _, h := range hashes {
+		matches = append(matches, match{a: a[h], b: b[h]})
+	}
+
+	slices.SortFunc(matches, func(x, y match) int {
+		return cmp.Compare(x.a.Pos, y.a.Pos)
+	})
+
+	regions := make([]domain.PlagiarismMatch, 0, len(matches))
+
+	for _, m := range matches {
+		if n := len(regions); n > 0 {
+			last := &regions[n-1]
+
+			if m.a.StartLine <= last.AEndLine+1 &&
+				m.b.StartLine <= last.BEndLine+1 &&
+				m.b.EndLine >= last.BStartLine-1 {
+				last.AEndLine = max(last.AEndLine, m.a.EndLine)
+				last.BStartLine = min(last.BStartLine, m.b.StartLine)
+				last.BEndLine = max(last.BEndLine, m.b.EndLine)
+
+				continue
+			}
+		}
+
+		regions = append(regions, domain.PlagiarismMatch{
+			AStartLine: m.a.StartLine,
+			AEndLine:   m.a.EndLine,
+			BStartLine: m.b.StartLine,
+			BEndLine:   m.b.EndLine,
+		})
+	}
+
+	return regions
+}
@@ -0,0 +1,13 @@
+package plagiarism_manager
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	PlagiarismManager interface {
+		CheckTask(ctx context.Context, dto domain.CheckPlagiarismDTO) (domain.PlagiarismCheck, error)
+		PlagiarismCheck(ctx context.Context, dto domain.GetPlagiarismCheckDTO) (domain.PlagiarismCheck, error)
+	}
+)
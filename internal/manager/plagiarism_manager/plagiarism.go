@@ -0,0 +1,302 @@
+package plagiarism_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/internal/service/language"
+	"lcode/internal/service/plagiarism"
+	"lcode/internal/service/solution"
+	taskTemplate "lcode/internal/service/task_template"
+	"lcode/pkg/postgres"
+	"lcode/pkg/winnowing"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRunningChecks bounds checks computed at once, the others wait
+	maxRunningChecks = 2
+	interruptedError = "check was interrupted by restart"
+	// running checks beat every heartbeatInterval, checks without heartbeat
+	// during staleCheckTimeout are failed by any instance, so checks of other
+	// running instances are not touched on start
+	heartbeatInterval = time.Second * 30
+	staleCheckTimeout = heartbeatInterval * 3
+)
+
+type (
+	Services struct {
+		Plagiarism   plagiarism.Plagiarism
+		Solution     solution.Solution
+		TaskTemplate taskTemplate.TaskTemplate
+		Language     language.Language
+	}
+
+	Manager struct {
+		cfg                *config.Config
+		logger             *slog.Logger
+		transactionManager *postgres.TransactionProvider
+		services           *Services
+		slots              chan struct{}
+		checks             sync.WaitGroup
+
+		// runCtx is cancelled on shutdown, unfinished checks are failed once
+		// their heartbeat is stale
+		runCtx   context.Context
+		stopRuns context.CancelFunc
+	}
+)
+
+func New(
+	cfg *config.Config,
+	logger *slog.Logger,
+	transactionManager *postgres.TransactionProvider,
+	services *Services,
+) *Manager {
+	m := &Manager{
+		cfg:                cfg,
+		logger:             logger,
+		transactionManager: transactionManager,
+		services:           services,
+		slots:              make(chan struct{}, maxRunningChecks),
+	}
+
+	m.runCtx, m.stopRuns = context.WithCancel(context.Background())
+
+	m.checks.Add(1)
+
+	go m.runStaleChecker()
+
+	return m
+}
+
+// Shutdown stops waiting checks and waits for running ones.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.stopRuns()
+
+	done := make(chan struct{})
+
+	go func() {
+		m.checks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "Shutdown plagiarism manager")
+	case <-done:
+		return nil
+	}
+}
+
+// CheckTask starts a check of accepted solutions of the task in background,
+// its report is available when the check is completed.
+func (m *Manager) CheckTask(
+	ctx context.Context,
+	dto domain.CheckPlagiarismDTO,
+) (check domain.PlagiarismCheck, err error) {
+	threshold := m.cfg.PlagiarismConfig.Threshold
+	if dto.Input.Threshold != nil {
+		threshold = *dto.Input.Threshold
+	}
+
+	check, err = m.services.Plagiarism.Create(ctx, domain.CreatePlagiarismCheckEntity{
+		TaskID:    dto.TaskID,
+		AuthorID:  dto.User.ID,
+		Threshold: threshold,
+	})
+	if err != nil {
+		return check, errors.Wrap(err, "CheckTask plagiarism manager")
+	}
+
+	m.checks.Add(1)
+
+	go m.run(check)
+
+	check.Pairs = []domain.PlagiarismPair{}
+
+	m.logger.Info(
+		"plagiarism check started",
+		slog.String("task_id", check.TaskID),
+		slog.String("check_id", check.ID),
+	)
+
+	return check, nil
+}
+
+func (m *Manager) PlagiarismCheck(
+	ctx context.Context,
+	dto domain.GetPlagiarismCheckDTO,
+) (check domain.PlagiarismCheck, err error) {
+	check, err = m.services.Plagiarism.GetByID(ctx, dto)
+	if err != nil {
+		return check, errors.Wrap(err, "PlagiarismCheck plagiarism manager")
+	}
+
+	check.Pairs, err = m.services.Plagiarism.Pairs(ctx, check.ID)
+	if err != nil {
+		return check, errors.Wrap(err, "PlagiarismCheck plagiarism manager")
+	}
+
+	return check, nil
+}
+
+func (m *Manager) run(check domain.PlagiarismCheck) {
+	defer m.checks.Done()
+
+	stopHeartbeat := m.heartbeat(check.ID)
+	defer stopHeartbeat()
+
+	select {
+	case <-m.runCtx.Done():
+		return
+	case m.slots <- struct{}{}:
+	}
+
+	defer func() { <-m.slots }()
+
+	err := m.check(m.runCtx, check)
+	if err == nil || m.runCtx.Err() != nil {
+		return
+	}
+
+	m.logger.Error(
+		"plagiarism check failed",
+		slog.String("check_id", check.ID),
+		slog.String("err", err.Error()),
+	)
+
+	msg := err.Error()
+
+	err = m.services.Plagiarism.Finish(m.runCtx, domain.FinishPlagiarismCheckEntity{
+		ID:     check.ID,
+		Status: domain.PlagiarismCheckStatusFailed,
+		Error:  &msg,
+	})
+	if err != nil {
+		m.logger.Error("can not fail plagiarism check", slog.String("err", err.Error()))
+	}
+}
+
+// check fingerprints solutions and stores suspicious pairs together with the
+// completed status of the check.
+func (m *Manager) check(ctx context.Context, check domain.PlagiarismCheck) error {
+	solutions, err := m.services.Solution.LatestAcceptedByTask(ctx, check.TaskID)
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	templates, err := m.services.TaskTemplate.GetAllByTaskID(ctx, check.TaskID)
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	languages, err := m.services.Language.GetAll(ctx, domain.LanguagesDTO{})
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	syntaxes := make(map[domain.LanguageType]*winnowing.Syntax, len(languages))
+	for i := range languages {
+		syntaxes[languages[i].ID] = winnowing.SyntaxFor(languages[i].EditorMode)
+	}
+
+	pairs := findPairs(solutions, templates, syntaxes, m.cfg.PlagiarismConfig, check.Threshold)
+
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	err = m.services.Plagiarism.AddPairs(ctx, check.ID, pairs)
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	err = m.services.Plagiarism.Finish(ctx, domain.FinishPlagiarismCheckEntity{
+		ID:             check.ID,
+		Status:         domain.PlagiarismCheckStatusCompleted,
+		SolutionsCount: len(solutions),
+	})
+	if err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "check plagiarism manager")
+	}
+
+	m.logger.Info(
+		"plagiarism check completed",
+		slog.String("check_id", check.ID),
+		slog.Int("solutions", len(solutions)),
+		slog.Int("pairs", len(pairs)),
+	)
+
+	return nil
+}
+
+// heartbeat marks the check as alive until the returned function is called.
+func (m *Manager) heartbeat(checkID string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-m.runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := m.services.Plagiarism.Heartbeat(m.runCtx, checkID); err != nil {
+				m.logger.Error(
+					"can not mark plagiarism check alive",
+					slog.String("check_id", checkID),
+					slog.String("err", err.Error()),
+				)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// runStaleChecker fails checks interrupted by stop or crash of their instance
+// on start and then periodically until shutdown.
+func (m *Manager) runStaleChecker() {
+	defer m.checks.Done()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		failed, err := m.services.Plagiarism.FailRunning(m.runCtx, interruptedError, staleCheckTimeout)
+		if err != nil && m.runCtx.Err() == nil {
+			m.logger.Error("can not fail interrupted plagiarism checks", slog.String("err", err.Error()))
+		} else if failed > 0 {
+			m.logger.Info("interrupted plagiarism checks failed", slog.Int64("checks", failed))
+		}
+
+		select {
+		case <-m.runCtx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package problem_manager
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// archiveFileName matches input and output files of test cases in archives:
+// 1.in, 1.out, 2.in, ...
+var archiveFileName = regexp.MustCompile(`^([0-9]+)\.(in|out)$`)
+
+type archiveTestCase struct {
+	number int
+	input  *zip.File
+	output *zip.File
+}
+
+// archiveBudget counts bytes actually unpacked from the archive: sizes in
+// headers of files are written by the uploader, so they are checked only to
+// reject the archive early.
+type archiveBudget struct {
+	remaining int64
+}
+
+// reader returns reader of the file of the archive which fails once files of
+// the archive together exceed the budget.
+func (b *archiveBudget) reader(r io.Reader) io.Reader {
+	return &archiveBudgetReader{r: io.LimitReader(r, b.remaining+1), budget: b}
+}
+
+type archiveBudgetReader struct {
+	r      io.Reader
+	budget *archiveBudget
+}
+
+func (r *archiveBudgetReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+
+	r.budget.remaining -= int64(n)
+	if r.budget.remaining < 0 {
+		return n, struct_errors.NewBaseErr("Archive exceeds unpacked size limit", nil)
+	}
+
+	return n, err
+}
+
+// ReplaceProblemTestCases replaces all test cases of the task with test cases
+// of the zip archive in one transaction. Their input and output are stored in
+// files. The replaced test cases are archived with their files rather than
+// deleted, so results of solutions judged on them are kept.
+func (m *Manager) ReplaceProblemTestCases(
+	ctx context.Context,
+	dto domain.TestCaseArchiveUploadDTO,
+) (p domain.Problem, err error) {
+	_, err = m.services.TaskService.GetByID(ctx, dto.TaskID)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+
+	archive, closeArchive, err := m.services.TestCaseFSService.OpenArchive(ctx, dto.TaskID, dto.Archive)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+	defer closeArchive()
+
+	archiveTestCases, err := m.readTestArchive(archive, dto.Input.Samples)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	err = m.services.TestCaseService.ArchiveByTaskID(ctx, dto.TaskID)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+
+	created := make([]domain.TestCase, 0, len(archiveTestCases))
+	budget := &archiveBudget{remaining: m.cfg.Files.TestArchiveMaxUnpackedSize}
+
+	// files of test cases which are not committed are not needed
+	defer func() {
+		if err != nil {
+			m.deleteTestCaseFiles(created...)
+		}
+	}()
+
+	for i := range archiveTestCases {
+		tc := domain.TestCase{
+			TaskID:     dto.TaskID,
+			Visibility: domain.TestCaseVisibilityHidden,
+			FileBacked: true,
+			InputSize:  int64(archiveTestCases[i].input.UncompressedSize64),
+			OutputSize: int64(archiveTestCases[i].output.UncompressedSize64),
+		}
+
+		if slices.Contains(dto.Input.Samples, archiveTestCases[i].number) {
+			tc.Visibility = domain.TestCaseVisibilitySample
+		}
+
+		tc.ID, err = m.services.TestCaseService.CreateFileBacked(ctx, dto.TaskID, domain.TestCaseFileCreateInput{
+			Visibility: tc.Visibility,
+			InputSize:  tc.InputSize,
+			OutputSize: tc.OutputSize,
+		})
+		if err != nil {
+			return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+		}
+
+		err = m.createTestCaseFiles(ctx, &tc, &archiveTestCases[i], budget)
+		if err != nil {
+			return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+		}
+
+		created = append(created, tc)
+	}
+
+	p, err = m.FullProblemByTaskID(ctx, dto.TaskID)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ReplaceProblemTestCases:")
+	}
+
+	return p, nil
+}
+
+func (m *Manager) createTestCaseFiles(
+	ctx context.Context,
+	tc *domain.TestCase,
+	archiveTC *archiveTestCase,
+	budget *archiveBudget,
+) error {
+	input, err := archiveTC.input.Open()
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager createTestCaseFiles:")
+	}
+	defer input.Close()
+
+	output, err := archiveTC.output.Open()
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager createTestCaseFiles:")
+	}
+	defer output.Close()
+
+	err = m.services.TestCaseFSService.Create(ctx, domain.TestCaseFiles{
+		TaskID: tc.TaskID,
+		CaseID: tc.ID,
+		Input:  budget.reader(input),
+		Output: budget.reader(output),
+	})
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager createTestCaseFiles:")
+	}
+
+	return nil
+}
+
+// readTestArchive returns pairs of input and output files of the archive
+// ordered by their numbers. Directories of the archive are ignored, so
+// archives of a folder with tests are accepted as well. Archives with too many
+// files or too large files by their headers are rejected before unpacking.
+func (m *Manager) readTestArchive(archive *zip.Reader, samples []int) ([]archiveTestCase, error) {
+	if len(archive.File) > m.cfg.Files.TestArchiveMaxEntries {
+		return nil, struct_errors.NewBaseErr(
+			fmt.Sprintf("Archive has more than %d files", m.cfg.Files.TestArchiveMaxEntries),
+			nil,
+		)
+	}
+
+	byNumber := make(map[int]*archiveTestCase)
+	unpackedSize := uint64(0)
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+
+		name := path.Base(f.Name)
+
+		match := archiveFileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Unexpected file %s in archive", f.Name), nil)
+		}
+
+		if f.UncompressedSize64 > uint64(m.cfg.Files.TestCaseMaxSize) {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("File %s exceeds size limit", f.Name), nil)
+		}
+
+		if match[2] == domain.TestCaseInputExtension && f.UncompressedSize64 > uint64(m.cfg.Files.JudgeInputMaxSize) {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Input file %s exceeds size limit", f.Name), nil)
+		}
+
+		unpackedSize += f.UncompressedSize64
+		if unpackedSize > uint64(m.cfg.Files.TestArchiveMaxUnpackedSize) {
+			return nil, struct_errors.NewBaseErr("Archive exceeds unpacked size limit", nil)
+		}
+
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Invalid test case number of file %s", f.Name), nil)
+		}
+
+		tc, ok := byNumber[number]
+		if !ok {
+			tc = &archiveTestCase{number: number}
+			byNumber[number] = tc
+		}
+
+		file := &tc.input
+		if match[2] == domain.TestCaseOutputExtension {
+			file = &tc.output
+		}
+
+		if *file != nil {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Duplicate file %s in archive", f.Name), nil)
+		}
+
+		*file = f
+	}
+
+	if len(byNumber) == 0 {
+		return nil, struct_errors.NewBaseErr("Archive has no test cases", nil)
+	}
+
+	testCases := make([]archiveTestCase, 0, len(byNumber))
+
+	for number, tc := range byNumber {
+		if tc.input == nil || tc.output == nil {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Test case %d needs both .in and .out files", number), nil)
+		}
+
+		testCases = append(testCases, *tc)
+	}
+
+	slices.SortFunc(testCases, func(a, b archiveTestCase) int {
+		return a.number - b.number
+	})
+
+	for _, number := range samples {
+		if _, ok := byNumber[number]; !ok {
+			return nil, struct_errors.NewBaseErr(fmt.Sprintf("Sample test case %d is not in archive", number), nil)
+		}
+	}
+
+	return testCases, nil
+}
+
+// LoadTestCaseInputs reads inputs of file backed test cases for judging.
+func (m *Manager) LoadTestCaseInputs(ctx context.Context, testCases []domain.TestCase) error {
+	err := m.services.TestCaseFSService.LoadInputs(ctx, testCases)
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager LoadTestCaseInputs:")
+	}
+
+	return nil
+}
+
+// LoadTestCaseOutputs reads expected outputs of file backed test cases for
+// checking.
+func (m *Manager) LoadTestCaseOutputs(ctx context.Context, testCases []domain.TestCase) error {
+	err := m.services.TestCaseFSService.LoadOutputs(ctx, testCases)
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager LoadTestCaseOutputs:")
+	}
+
+	return nil
+}
+
+// OpenTestCaseOutput opens expected output of the test case for checking it
+// without reading the whole file into memory.
+func (m *Manager) OpenTestCaseOutput(ctx context.Context, testCase domain.TestCase) (io.ReadCloser, error) {
+	output, err := m.services.TestCaseFSService.OpenOutput(ctx, testCase)
+	if err != nil {
+		return nil, errors.Wrap(err, "ProblemManager Manager OpenTestCaseOutput:")
+	}
+
+	return output, nil
+}
+
+// LoadTestCaseSamples reads input and output of file backed test cases cut to
+// the size of samples for showing them.
+func (m *Manager) LoadTestCaseSamples(ctx context.Context, testCases []domain.TestCase) error {
+	err := m.services.TestCaseFSService.LoadSamples(ctx, testCases)
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager LoadTestCaseSamples:")
+	}
+
+	return nil
+}
+
+// deleteTestCaseFiles removes files of test cases which are deleted, failures
+// only leave garbage in the task dir, so they are logged.
+func (m *Manager) deleteTestCaseFiles(testCases ...domain.TestCase) {
+	err := m.services.TestCaseFSService.Delete(context.Background(), testCases...)
+	if err != nil {
+		m.logger.Error("can not delete test case files", slog.String("err", err.Error()))
+	}
+}
@@ -2,6 +2,7 @@ package problem_manager
 
 import (
 	"golang.org/x/net/context"
+	"io"
 	"lcode/internal/domain"
 )
 
@@ -17,14 +18,17 @@ type ProblemManager interface {
 	CreateProblemTestCase(ctx context.Context, dto domain.TestCaseCreateDTO) (domain.Problem, error)
 	UpdateProblemTestCase(ctx context.Context, dto domain.TestCaseUpdateDTO) (domain.Problem, error)
 	DeleteProblemTestCase(ctx context.Context, caseID string) error
+	ReplaceProblemTestCases(ctx context.Context, dto domain.TestCaseArchiveUploadDTO) (domain.Problem, error)
+	LoadTestCaseInputs(ctx context.Context, testCases []domain.TestCase) error
+	LoadTestCaseOutputs(ctx context.Context, testCases []domain.TestCase) error
+	LoadTestCaseSamples(ctx context.Context, testCases []domain.TestCase) error
+	OpenTestCaseOutput(ctx context.Context, testCase domain.TestCase) (io.ReadCloser, error)
 
 	FullProblemByTaskID(ctx context.Context, taskID string) (domain.Problem, error)
+	TestCasesByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error)
+	ProblemByTaskID(ctx context.Context, dto domain.GetProblemDTO) (domain.Problem, error)
 	TaskListByParams(ctx context.Context, dto domain.TaskParams) (domain.TaskList, error)
 
 	GetAvailableTaskAttributes(ctx context.Context) (domain.TaskAttributes, error)
-	GetAvailableTaskLanguages() ([]domain.JudgeLanguageInfo, error)
-}
-
-type Judge interface {
-	GetAvailableLanguages(ctx context.Context) ([]domain.JudgeLanguageInfo, error)
+	GetAvailableTaskLanguages(ctx context.Context) ([]domain.Language, error)
 }
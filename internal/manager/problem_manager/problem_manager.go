@@ -5,12 +5,16 @@ import (
 	"github.com/pkg/errors"
 	"lcode/config"
 	"lcode/internal/domain"
+	languageServ "lcode/internal/service/language"
 	taskServ "lcode/internal/service/task"
 	taskTemplateServ "lcode/internal/service/task_template"
 	testCaseServ "lcode/internal/service/test_case"
+	testCaseFSServ "lcode/internal/service/test_case_fs"
 	"lcode/pkg/postgres"
-	"log"
+	"lcode/pkg/struct_errors"
 	"log/slog"
+	"math"
+	"strconv"
 )
 
 type (
@@ -18,7 +22,8 @@ type (
 		TaskService         taskServ.Task
 		TaskTemplateService taskTemplateServ.TaskTemplate
 		TestCaseService     testCaseServ.TestCase
-		Judge               Judge
+		TestCaseFSService   testCaseFSServ.TestCaseFS
+		LanguageService     languageServ.Language
 	}
 
 	Manager struct {
@@ -26,8 +31,6 @@ type (
 		logger             *slog.Logger
 		transactionManager *postgres.TransactionProvider
 		services           *Services
-
-		availableLanguages []domain.JudgeLanguageInfo
 	}
 )
 
@@ -37,18 +40,11 @@ func New(
 	transactionManager *postgres.TransactionProvider,
 	services *Services,
 ) *Manager {
-
-	languages, err := services.Judge.GetAvailableLanguages(context.Background())
-	if err != nil {
-		log.Fatal("can not access judge api:", err.Error())
-	}
-
 	return &Manager{
 		cfg:                cfg,
 		logger:             logger,
 		transactionManager: transactionManager,
 		services:           services,
-		availableLanguages: languages,
 	}
 }
 
@@ -133,6 +129,11 @@ func (m *Manager) DeleteProblem(ctx context.Context, taskID string) (err error)
 		return errors.Wrap(err, "ProblemManager Manager DeleteProblem:")
 	}
 
+	err = m.services.TestCaseFSService.DeleteTaskDir(ctx, taskID)
+	if err != nil {
+		m.logger.Error("can not delete task files", slog.String("err", err.Error()))
+	}
+
 	return nil
 }
 
@@ -250,6 +251,19 @@ func (m *Manager) UpdateProblemTestCase(ctx context.Context,
 	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
 	defer tx.Rollback(ctx)
 
+	if dto.Input.Input != nil || dto.Input.Output != nil {
+		tc, err := m.services.TestCaseService.GetByID(ctx, dto.CaseID)
+		if err != nil {
+			return p, errors.Wrap(err, "ProblemManager Manager UpdateProblemTestCase:")
+		}
+
+		if tc.FileBacked {
+			err = struct_errors.NewBaseErr("Input and output of file backed test case are replaced by archive upload", nil)
+
+			return p, errors.Wrap(err, "ProblemManager Manager UpdateProblemTestCase:")
+		}
+	}
+
 	err = m.services.TestCaseService.Update(ctx, dto.CaseID, dto.Input)
 	if err != nil {
 		return p, errors.Wrap(err, "ProblemManager Manager UpdateProblemTestCase:")
@@ -275,6 +289,11 @@ func (m *Manager) DeleteProblemTestCase(ctx context.Context, caseID string) erro
 	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
 	defer tx.Rollback(ctx)
 
+	tc, err := m.services.TestCaseService.GetByID(ctx, caseID)
+	if err != nil {
+		return errors.Wrap(err, "ProblemManager Manager DeleteProblemTestCase:")
+	}
+
 	err = m.services.TestCaseService.Delete(ctx, caseID)
 	if err != nil {
 		return errors.Wrap(err, "ProblemManager Manager DeleteProblemTestCase:")
@@ -284,6 +303,8 @@ func (m *Manager) DeleteProblemTestCase(ctx context.Context, caseID string) erro
 		return errors.Wrap(err, "ProblemManager Manager DeleteProblemTestCase:")
 	}
 
+	m.deleteTestCaseFiles(tc)
+
 	return nil
 }
 
@@ -303,6 +324,10 @@ func (m *Manager) FullProblemByTaskID(ctx context.Context, taskID string) (p dom
 		return p, errors.Wrap(err, "ProblemManager Manager FullProblemByTaskID:")
 	}
 
+	for i := range taskTemplates {
+		taskTemplates[i].Limits = m.templateLimits(&task, &taskTemplates[i])
+	}
+
 	p = domain.Problem{
 		Task:          task,
 		TaskTemplates: taskTemplates,
@@ -312,6 +337,80 @@ func (m *Manager) FullProblemByTaskID(ctx context.Context, taskID string) (p dom
 	return p, nil
 }
 
+// TestCasesByIDs returns test cases pinned to a queued solution, archived ones
+// included.
+func (m *Manager) TestCasesByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error) {
+	testCases, err := m.services.TestCaseService.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "ProblemManager Manager TestCasesByIDs:")
+	}
+
+	return testCases, nil
+}
+
+// templateLimits returns limits of the template if they are overridden,
+// otherwise limits of the task multiplied by the language multipliers.
+func (m *Manager) templateLimits(task *domain.Task, tmpl *domain.TaskTemplate) domain.TaskTemplateLimits {
+	limits := domain.TaskTemplateLimits{
+		RuntimeLimit: task.RuntimeLimit,
+		MemoryLimit:  task.MemoryLimit,
+	}
+
+	multipliers := m.cfg.JudgeConfig.LanguageLimits[strconv.Itoa(int(tmpl.LanguageID))]
+
+	if tmpl.RuntimeLimit != nil {
+		limits.RuntimeLimit = *tmpl.RuntimeLimit
+	} else if multipliers.TimeMultiplier > 0 {
+		limits.RuntimeLimit = task.RuntimeLimit * multipliers.TimeMultiplier
+	}
+
+	if tmpl.MemoryLimit != nil {
+		limits.MemoryLimit = *tmpl.MemoryLimit
+	} else if multipliers.MemoryMultiplier > 0 {
+		limits.MemoryLimit = int(math.Round(float64(task.MemoryLimit) * multipliers.MemoryMultiplier))
+	}
+
+	// the judge rejects submissions with limits above its maximums
+	limits.RuntimeLimit = min(limits.RuntimeLimit, m.cfg.JudgeConfig.MaxTimeLimitSec)
+	limits.MemoryLimit = min(limits.MemoryLimit, m.cfg.JudgeConfig.MaxMemoryLimitKB)
+
+	return limits
+}
+
+// ProblemByTaskID returns the problem as the user can see it: users other
+// than admins get only sample test cases and no checker program.
+func (m *Manager) ProblemByTaskID(ctx context.Context, dto domain.GetProblemDTO) (p domain.Problem, err error) {
+	p, err = m.FullProblemByTaskID(ctx, dto.TaskID)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ProblemByTaskID:")
+	}
+
+	if dto.User.IsAdmin {
+		return p, nil
+	}
+
+	p.Task.CheckerCode = ""
+	p.Task.CheckerLanguageID = 0
+
+	samples := make([]domain.TestCase, 0, len(p.TestCases))
+
+	for i := range p.TestCases {
+		if p.TestCases[i].Visibility == domain.TestCaseVisibilitySample {
+			samples = append(samples, p.TestCases[i])
+		}
+	}
+
+	// samples are shown to users, so their files are read as well
+	err = m.LoadTestCaseSamples(ctx, samples)
+	if err != nil {
+		return p, errors.Wrap(err, "ProblemManager Manager ProblemByTaskID:")
+	}
+
+	p.TestCases = samples
+
+	return p, nil
+}
+
 func (m *Manager) TaskListByParams(ctx context.Context, dto domain.TaskParams) (tl domain.TaskList, err error) {
 	tl, err = m.services.TaskService.GetAllByParams(ctx, dto)
 	if err != nil {
@@ -330,7 +429,12 @@ func (m *Manager) GetAvailableTaskAttributes(ctx context.Context) (domain.TaskAt
 	return ta, nil
 }
 
-func (m *Manager) GetAvailableTaskLanguages() ([]domain.JudgeLanguageInfo, error) {
-	return m.availableLanguages, nil
+// GetAvailableTaskLanguages returns languages enabled in the registry.
+func (m *Manager) GetAvailableTaskLanguages(ctx context.Context) ([]domain.Language, error) {
+	ls, err := m.services.LanguageService.GetAll(ctx, domain.LanguagesDTO{OnlyEnabled: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "ProblemManager Manager GetAvailableTaskLanguages:")
+	}
 
+	return ls, nil
 }
@@ -0,0 +1,13 @@
+package ranking_manager
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	RankingManager interface {
+		SolutionRanking(ctx context.Context, dto domain.GetSolutionRankingDTO) (domain.SolutionRanking, error)
+		Refresh(ctx context.Context) error
+	}
+)
@@ -0,0 +1,245 @@
+package ranking_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/internal/service/solution"
+	solutionStats "lcode/internal/service/solution_stats"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type (
+	Services struct {
+		Solution      solution.Solution
+		SolutionStats solutionStats.SolutionStats
+	}
+
+	Manager struct {
+		cfg                *config.Config
+		logger             *slog.Logger
+		transactionManager *postgres.TransactionProvider
+		services           *Services
+		stopRefresh        context.CancelFunc
+		refresherDone      chan struct{}
+	}
+)
+
+func New(
+	cfg *config.Config,
+	logger *slog.Logger,
+	transactionManager *postgres.TransactionProvider,
+	services *Services,
+) *Manager {
+	ctx, stop := context.WithCancel(context.Background())
+
+	m := &Manager{
+		cfg:                cfg,
+		logger:             logger,
+		transactionManager: transactionManager,
+		services:           services,
+		stopRefresh:        stop,
+		refresherDone:      make(chan struct{}),
+	}
+
+	go m.runRefresher(ctx)
+
+	return m
+}
+
+// Shutdown stops periodic refresh and waits for the running one to finish.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.stopRefresh()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "Shutdown ranking manager")
+	case <-m.refresherDone:
+		return nil
+	}
+}
+
+// runRefresher refreshes stats right after start and then every
+// RefreshInterval until shutdown.
+func (m *Manager) runRefresher(ctx context.Context) {
+	defer close(m.refresherDone)
+
+	ticker := time.NewTicker(m.cfg.RankingConfig.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		err := m.Refresh(ctx)
+		if err != nil && ctx.Err() == nil {
+			m.logger.Error("can not refresh solution stats", slog.String("err", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh computes stats of accepted solutions again and stores exact
+// percentiles of accepted solutions, requests for ranking only read them.
+func (m *Manager) Refresh(ctx context.Context) error {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Refresh ranking manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	refreshed, err := m.services.SolutionStats.Refresh(ctx, m.cfg.RankingConfig.HistogramBuckets)
+	if err != nil {
+		return errors.Wrap(err, "Refresh ranking manager")
+	}
+
+	deleted, err := m.services.SolutionStats.DeleteStale(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Refresh ranking manager")
+	}
+
+	updated, err := m.services.SolutionStats.UpdatePercentiles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Refresh ranking manager")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "Refresh ranking manager")
+	}
+
+	m.logger.Debug(
+		"solution stats refreshed",
+		slog.Int64("stats", refreshed),
+		slog.Int64("deleted", deleted),
+		slog.Int64("solutions", updated),
+	)
+
+	return nil
+}
+
+// SolutionRanking returns percentiles of the accepted solution together with
+// histograms of accepted solutions of the task in the same language. Solutions
+// accepted after the last refresh have no stored percentiles yet, they are
+// estimated from quantiles of the stats.
+func (m *Manager) SolutionRanking(
+	ctx context.Context,
+	dto domain.GetSolutionRankingDTO,
+) (r domain.SolutionRanking, err error) {
+	sol, err := m.services.Solution.SolutionByID(ctx, dto.SolutionID)
+	if err != nil {
+		return r, errors.Wrap(err, "SolutionRanking ranking manager")
+	}
+
+	if sol.Status != domain.SolutionStatusCompleted {
+		err = struct_errors.NewBaseErr("Only accepted solutions are ranked", nil)
+
+		return r, errors.Wrap(err, "SolutionRanking ranking manager")
+	}
+
+	stats, err := m.services.SolutionStats.GetByTaskAndLanguage(ctx, sol.TaskID, sol.LanguageID)
+	if err != nil {
+		return r, errors.Wrap(err, "SolutionRanking ranking manager")
+	}
+
+	r = domain.SolutionRanking{
+		SolutionID: sol.Id,
+		TaskID:     sol.TaskID,
+		LanguageID: sol.LanguageID,
+		Runtime: domain.MetricRanking{
+			Value:      sol.Runtime,
+			Percentile: sol.RuntimePercentile,
+			Histogram:  []domain.HistogramBucket{},
+		},
+		Memory: domain.MetricRanking{
+			Value:      float64(sol.Memory),
+			Percentile: sol.MemoryPercentile,
+			Histogram:  []domain.HistogramBucket{},
+		},
+	}
+
+	for _, st := range stats {
+		var mr *domain.MetricRanking
+
+		switch st.Metric {
+		case domain.SolutionMetricRuntime:
+			mr = &r.Runtime
+		case domain.SolutionMetricMemory:
+			mr = &r.Memory
+		default:
+			continue
+		}
+
+		if mr.Percentile == nil {
+			p := estimatePercentile(st.Quantiles, mr.Value)
+			mr.Percentile = &p
+		}
+
+		mr.Histogram = histogram(st)
+
+		r.AcceptedCount = st.AcceptedCount
+		r.RefreshedAt = &st.RefreshedAt
+	}
+
+	return r, nil
+}
+
+// estimatePercentile returns a percentage of values greater than v, the
+// distribution between neighbouring quantiles is taken as uniform.
+func estimatePercentile(quantiles []float64, v float64) float64 {
+	n := len(quantiles)
+
+	switch {
+	case n == 0, v < quantiles[0]:
+		return 100
+	case v >= quantiles[n-1]:
+		return 0
+	}
+
+	// quantiles[i-1] <= v < quantiles[i]
+	i := sort.Search(n, func(i int) bool {
+		return quantiles[i] > v
+	})
+
+	lo, hi := quantiles[i-1], quantiles[i]
+	position := float64(i-1) + (v-lo)/(hi-lo)
+
+	return 100 * (1 - position/float64(n-1))
+}
+
+func histogram(st domain.SolutionStats) []domain.HistogramBucket {
+	if len(st.Histogram) == 0 {
+		return []domain.HistogramBucket{}
+	}
+
+	// all values are equal and counted in the first bucket
+	if st.MinValue == st.MaxValue {
+		return []domain.HistogramBucket{{
+			From:  st.MinValue,
+			To:    st.MaxValue,
+			Count: st.Histogram[0],
+		}}
+	}
+
+	width := (st.MaxValue - st.MinValue) / float64(len(st.Histogram))
+
+	buckets := make([]domain.HistogramBucket, 0, len(st.Histogram))
+	for i, count := range st.Histogram {
+		buckets = append(buckets, domain.HistogramBucket{
+			From:  st.MinValue + width*float64(i),
+			To:    st.MinValue + width*float64(i+1),
+			Count: count,
+		})
+	}
+
+	buckets[len(buckets)-1].To = st.MaxValue
+
+	return buckets
+}
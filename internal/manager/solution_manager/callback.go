@@ -0,0 +1,432 @@
+package solution_manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// judgeWithCallback creates submissions which are not waited for: the judge
+// sends their results to HandleJudgeCallback. All test cases are submitted at
+// once when full report is required, otherwise the next test case is
+// submitted when the previous one is accepted. The queue item stays claimed
+// till the last result arrives, so solutions whose callbacks are lost are
+// judged again by the watchdog.
+func (m *Manager) judgeWithCallback(ctx context.Context, item *workerItem) error {
+	solutionID := item.solution.Id
+	testCases := item.testCases
+
+	count := 1
+	if item.task.FullReport {
+		count = len(testCases)
+	}
+
+	pending, err := m.registerCallbacks(solutionID, testCases[:count])
+	if err != nil {
+		return errors.Wrap(err, "judgeWithCallback solution manager")
+	}
+
+	if pending == nil {
+		return nil
+	}
+
+	err = m.submitWithCallback(ctx, item, pending, testCases[:count])
+	if err != nil {
+		return errors.Wrap(err, "judgeWithCallback solution manager")
+	}
+
+	if context.Cause(ctx) != nil {
+		return errors.Wrap(context.Cause(ctx), "judgeWithCallback solution manager")
+	}
+
+	// callbacks may be handled by any instance, so the claim must outlive
+	// restarts and shutdown of this one
+	err = m.services.SolutionQueue.Disown(context.WithoutCancel(ctx), solutionID)
+	if err != nil {
+		return errors.Wrap(err, "judgeWithCallback solution manager")
+	}
+
+	return nil
+}
+
+// registerCallbacks replaces results of the solution with pending results of
+// the test cases before they are submitted, so the queue item is not locked
+// while the judge is called. Pending results hold random callback keys instead
+// of submission tokens, callbacks which arrive before the tokens are stored
+// find their results by the key. Nil is returned when the solution left the
+// queue.
+func (m *Manager) registerCallbacks(solutionID string, testCases []domain.TestCase) ([]domain.SolutionResult, error) {
+	baseCtx := context.Background()
+
+	tx, err := m.transactionManager.NewTx(baseCtx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+	ctx := context.WithValue(baseCtx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	_, err = m.services.SolutionQueue.Lock(ctx, solutionID)
+	if err != nil {
+		var notFoundErr *struct_errors.ErrNotFound
+		if errors.As(err, &notFoundErr) {
+			m.logger.Warn("solution left the queue before submission", slog.String("solution_id", solutionID))
+
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+
+	err = m.services.SolutionResult.DeleteBySolutionID(ctx, solutionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+
+	pending, err := pendingResults(solutionID, testCases)
+	if err != nil {
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+
+	err = m.saveResults(ctx, pending...)
+	if err != nil {
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, "registerCallbacks solution manager")
+	}
+
+	return pending, nil
+}
+
+// pendingResults returns results of the test cases waiting for submission.
+func pendingResults(solutionID string, testCases []domain.TestCase) ([]domain.SolutionResult, error) {
+	results := make([]domain.SolutionResult, 0, len(testCases))
+
+	for i := range testCases {
+		key, err := newCallbackKey()
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, domain.SolutionResult{
+			SolutionID:      solutionID,
+			TestCaseID:      testCases[i].ID,
+			SubmissionToken: key,
+			Status:          domain.InQueue,
+		})
+	}
+
+	return results, nil
+}
+
+func newCallbackKey() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "newCallbackKey solution manager")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// submitWithCallback creates submissions of the pending results of the test
+// cases batch by batch and replaces their callback keys with submission
+// tokens. Results which were replaced or got their callback meanwhile are not
+// touched.
+func (m *Manager) submitWithCallback(
+	ctx context.Context,
+	item *workerItem,
+	pending []domain.SolutionResult,
+	testCases []domain.TestCase,
+) error {
+	solutionID := item.solution.Id
+	tokens := make([]string, 0, len(testCases))
+
+	for start, end := 0, 0; start < len(testCases); start = end {
+		end = batchEnd(testCases, start, m.cfg.Files.JudgeBatchMaxSize)
+
+		submissions, err := m.newSubmissions(ctx, item, testCases[start:end])
+		if err != nil {
+			return errors.Wrap(err, "submitWithCallback solution manager")
+		}
+
+		for i := range submissions {
+			submissions[i].CallbackURL = m.callbackURL(solutionID, pending[start+i].SubmissionToken)
+		}
+
+		batchTokens, err := m.services.Judge.CreateSubmissionBatch(ctx, submissions)
+		if err != nil {
+			return errors.Wrap(err, "submitWithCallback solution manager")
+		}
+
+		tokens = append(tokens, batchTokens...)
+	}
+
+	for i := range tokens {
+		err := m.services.SolutionResult.SetSubmissionToken(
+			context.WithoutCancel(ctx),
+			solutionID,
+			pending[i].SubmissionToken,
+			tokens[i],
+		)
+		if err != nil {
+			return errors.Wrap(err, "submitWithCallback solution manager")
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) callbackURL(solutionID string, key string) string {
+	q := url.Values{}
+	q.Set(domain.JudgeCallbackKeyQuery, key)
+	// the URL is signed without node, the judge client signs it again when
+	// the node which runs the submission is chosen
+	q.Set(domain.JudgeCallbackSignatureQuery, domain.JudgeCallbackSignature(m.cfg.JudgeConfig.CallbackSecret, solutionID, key, ""))
+
+	return fmt.Sprintf(
+		"%s%s/%s?%s",
+		strings.TrimSuffix(m.cfg.JudgeConfig.CallbackURL, "/"),
+		domain.JudgeCallbackRoute,
+		url.PathEscape(solutionID),
+		q.Encode(),
+	)
+}
+
+// HandleJudgeCallback stores the result of the submission sent by the judge.
+// The next test case is submitted in background after the commit when the
+// solution stops on the first failed one, the solution is failed when it can
+// not be submitted. The solution is finished when its last result arrives.
+// Callbacks of solutions which left the queue or were submitted again are
+// ignored.
+func (m *Manager) HandleJudgeCallback(ctx context.Context, dto domain.JudgeCallbackDTO) error {
+	baseCtx := ctx
+
+	info := dto.Info
+	if dto.Node != "" {
+		info.Token = domain.JudgeNodeToken(dto.Node, info.Token)
+	}
+
+	if !info.Status.IsFinished() {
+		return nil
+	}
+
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	queueItem, err := m.services.SolutionQueue.Lock(ctx, dto.SolutionID)
+	if err != nil {
+		var notFoundErr *struct_errors.ErrNotFound
+		if errors.As(err, &notFoundErr) {
+			m.logger.Warn("callback of solution which is not judged", slog.String("solution_id", dto.SolutionID))
+
+			return nil
+		}
+
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	results, err := m.services.SolutionResult.ResultsBySolutionID(ctx, dto.SolutionID)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	idx := slices.IndexFunc(results, func(r domain.SolutionResult) bool {
+		return r.SubmissionToken == dto.Key || r.SubmissionToken == info.Token
+	})
+	if idx < 0 || results[idx].Status.IsFinished() {
+		m.logger.Warn("stale callback of solution", slog.String("solution_id", dto.SolutionID))
+
+		return nil
+	}
+
+	// the callback came before the token was stored by the submitter
+	if results[idx].SubmissionToken == dto.Key {
+		err = m.services.SolutionResult.SetSubmissionToken(ctx, dto.SolutionID, dto.Key, info.Token)
+		if err != nil {
+			return errors.Wrap(err, "HandleJudgeCallback solution manager")
+		}
+	}
+
+	sol, err := m.services.Solution.SolutionByID(ctx, dto.SolutionID)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	problem, err := m.services.ProblemManager.FullProblemByTaskID(ctx, sol.TaskID)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	task := &problem.Task
+
+	// results are scored against the test cases the solution was submitted on
+	testCases, err := m.pinnedTestCases(ctx, queueItem, &problem)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	pos := slices.IndexFunc(testCases, func(tc domain.TestCase) bool {
+		return tc.ID == results[idx].TestCaseID
+	})
+	if pos < 0 {
+		err = struct_errors.NewInternalErr(fmt.Errorf("test case %s not found", results[idx].TestCaseID))
+
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	err = m.applyChecker(ctx, task, &testCases[pos], &info)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	bounded := []domain.SolutionResult{newSolutionResult(sol.Id, testCases[pos].ID, info)}
+	outputs := m.boundOutputs(bounded)
+	result := bounded[0]
+
+	err = m.services.SolutionResult.Update(ctx, result)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	err = m.services.SolutionOutput.CreateBatch(ctx, outputs...)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	results[idx] = result
+
+	tx.AfterSuccess(ctx, func() {
+		m.publishTestCaseResult(pos+1, result)
+	})
+
+	switch {
+	case !task.FullReport && info.Status == domain.Accepted && pos+1 < len(testCases):
+		pending, err := pendingResults(sol.Id, testCases[pos+1:pos+2])
+		if err != nil {
+			return errors.Wrap(err, "HandleJudgeCallback solution manager")
+		}
+
+		err = m.saveResults(ctx, pending...)
+		if err != nil {
+			return errors.Wrap(err, "HandleJudgeCallback solution manager")
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "HandleJudgeCallback solution manager")
+		}
+
+		// the judge is called without the lock, the result of the test case
+		// waits for the token or for the callback meanwhile. The callback is
+		// acknowledged right away, Judge0 does not wait for it long enough to
+		// outlast retries of the submission
+		next := testCases[pos+1]
+
+		m.followUps.Add(1)
+		go func() {
+			defer m.followUps.Done()
+
+			ctx := context.WithoutCancel(baseCtx)
+
+			err := m.submitNextTestCase(ctx, &sol, problem.TaskTemplates, &next, pending)
+			if err != nil {
+				m.logger.Error("can not submit next test case", slog.String("err", err.Error()))
+
+				// no callback will finish the solution, so it is not left to
+				// the watchdog
+				m.failQueuedSolution(ctx, sol.Id, judgeErrorReason(err))
+			}
+		}()
+
+		return nil
+	case task.FullReport && slices.ContainsFunc(results, func(r domain.SolutionResult) bool {
+		return !r.Status.IsFinished()
+	}):
+		if err = tx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "HandleJudgeCallback solution manager")
+		}
+
+		return nil
+	}
+
+	var errorReason domain.SolutionErrorReason
+	var compileOutput string
+
+	updateSolutionDTO := judgedSolution(sol.Id, domain.SolutionStatusCompleted, results, len(testCases))
+	updateSolutionDTO.ErrorReason = &errorReason
+	updateSolutionDTO.CompileOutput = &compileOutput
+
+	updatedSol, err := m.services.Solution.Update(ctx, updateSolutionDTO)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	err = m.services.Rejudge.FinishSolution(ctx, updatedSol)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	err = m.services.SolutionQueue.Delete(ctx, sol.Id)
+	if err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	// after success functions run in order, so the finished event does not
+	// overtake the event of the last test case
+	tx.AfterSuccess(ctx, func() {
+		m.publishFinished(updatedSol)
+	})
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "HandleJudgeCallback solution manager")
+	}
+
+	return nil
+}
+
+func (m *Manager) submitNextTestCase(
+	ctx context.Context,
+	sol *domain.Solution,
+	templates []domain.TaskTemplate,
+	testCase *domain.TestCase,
+	pending []domain.SolutionResult,
+) error {
+	tmpl := findTemplate(templates, sol.LanguageID)
+	if tmpl == nil {
+		err := struct_errors.NewInternalErr(fmt.Errorf("template of language %d not found", sol.LanguageID))
+
+		return errors.Wrap(err, "submitNextTestCase solution manager")
+	}
+
+	lang, err := m.languageOptions(ctx, sol.LanguageID)
+	if err != nil {
+		return errors.Wrap(err, "submitNextTestCase solution manager")
+	}
+
+	item := workerItem{
+		solution: *sol,
+		template: *tmpl,
+		language: lang,
+	}
+
+	err = m.submitWithCallback(ctx, &item, pending, []domain.TestCase{*testCase})
+	if err != nil {
+		return errors.Wrap(err, "submitNextTestCase solution manager")
+	}
+
+	return nil
+}
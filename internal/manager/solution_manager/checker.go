@@ -0,0 +1,220 @@
+package solution_manager
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"lcode/internal/domain"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// expectedTokenSlack is how much longer than the whole output a token or a
+// line of the expected output may be and still match: numbers are compared by
+// value, so "1.000" matches "1". Longer ones are not read into memory.
+const expectedTokenSlack = 1024
+
+// checkerProgramInput is passed as JSON on stdin of the checker program.
+type checkerProgramInput struct {
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+	Output   string `json:"output"`
+}
+
+// applyChecker sets verdict of the submission which was run successfully by
+// comparing its stdout with the expected output using checker of the task.
+// Verdicts other than Accepted are final and left as is. Files of file backed
+// test cases are read only for the check.
+func (m *Manager) applyChecker(
+	ctx context.Context,
+	task *domain.Task,
+	testCase *domain.TestCase,
+	info *domain.JudgeSubmissionInfo,
+) error {
+	if info.Status != domain.Accepted {
+		return nil
+	}
+
+	var stdout string
+	if info.Stdout != nil {
+		stdout = *info.Stdout
+	}
+
+	if task.CheckerType == domain.CheckerProgram {
+		// the checker program gets input and expected output on stdin, so
+		// they are read into memory
+		loaded := []domain.TestCase{*testCase}
+
+		err := m.services.ProblemManager.LoadTestCaseInputs(ctx, loaded)
+		if err != nil {
+			return errors.Wrap(err, "applyChecker solution manager")
+		}
+
+		err = m.services.ProblemManager.LoadTestCaseOutputs(ctx, loaded)
+		if err != nil {
+			return errors.Wrap(err, "applyChecker solution manager")
+		}
+
+		status, err := m.runCheckerProgram(ctx, task, &loaded[0], stdout)
+		if err != nil {
+			return errors.Wrap(err, "applyChecker solution manager")
+		}
+
+		info.Status = status
+
+		return nil
+	}
+
+	expected, err := m.services.ProblemManager.OpenTestCaseOutput(ctx, *testCase)
+	if err != nil {
+		return errors.Wrap(err, "applyChecker solution manager")
+	}
+	defer expected.Close()
+
+	ok, err := checkOutput(task.CheckerType, task.CheckerEpsilon, expected, stdout)
+	if err != nil {
+		return errors.Wrap(err, "applyChecker solution manager")
+	}
+
+	if !ok {
+		info.Status = domain.WrongAnswer
+	}
+
+	return nil
+}
+
+func (m *Manager) runCheckerProgram(
+	ctx context.Context,
+	task *domain.Task,
+	testCase *domain.TestCase,
+	stdout string,
+) (domain.JudgeStatus, error) {
+	stdin, err := json.Marshal(checkerProgramInput{
+		Input:    testCase.Input,
+		Expected: testCase.Output,
+		Output:   stdout,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "runCheckerProgram solution manager")
+	}
+
+	lang, err := m.languageOptions(ctx, task.CheckerLanguageID)
+	if err != nil {
+		return 0, errors.Wrap(err, "runCheckerProgram solution manager")
+	}
+
+	info, err := m.services.Judge.CreateSubmission(ctx, domain.CreateJudgeSubmission{
+		SourceCode:           task.CheckerCode,
+		LanguageID:           task.CheckerLanguageID,
+		Stdin:                string(stdin),
+		CpuTimeLimit:         task.RuntimeLimit,
+		MemoryLimit:          task.MemoryLimit,
+		CompilerOptions:      lang.CompilerOptions,
+		CommandLineArguments: lang.CommandLineArguments,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "runCheckerProgram solution manager")
+	}
+
+	status := checkerProgramVerdict(info.Status)
+	if status == domain.InternalError {
+		m.logger.Error(
+			"checker program failed",
+			slog.String("task_id", task.ID),
+			slog.Int("status", int(info.Status)),
+		)
+	}
+
+	return status, nil
+}
+
+// checkerProgramVerdict returns verdict of the solution by status of the
+// checker program: it exits with non-zero code when the output is wrong, any
+// other failure means the checker itself is broken and the verdict is unknown.
+func checkerProgramVerdict(status domain.JudgeStatus) domain.JudgeStatus {
+	switch status {
+	case domain.Accepted:
+		return domain.Accepted
+	case domain.RuntimeNZEC:
+		return domain.WrongAnswer
+	default:
+		return domain.InternalError
+	}
+}
+
+// checkOutput reports whether output matches expected output for built-in
+// checkers. The expected output is read incrementally: it may be a file of
+// hundreds of megabytes, while the output is limited by the judge.
+func checkOutput(checker domain.CheckerType, epsilon float64, expected io.Reader, output string) (bool, error) {
+	e := newExpectedReader(expected, len(output)+expectedTokenSlack)
+
+	switch checker {
+	case domain.CheckerWhitespace:
+		return e.equalLineTokens(lineTokens(output))
+	case domain.CheckerTokens:
+		return e.equalTokens(strings.Fields(output), func(e, o string) bool {
+			return e == o
+		})
+	case domain.CheckerFloat:
+		return e.equalTokens(strings.Fields(output), func(e, o string) bool {
+			return floatTokensEqual(e, o, epsilon)
+		})
+	case domain.CheckerUnorderedLines:
+		return e.equalUnorderedLines(trimmedLines(output))
+	default:
+		return e.equalExact(normalizeNewlines(strings.TrimRight(output, " \t\r\n")))
+	}
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// trimmedLines returns lines without surrounding whitespace, empty lines at
+// the end are dropped.
+func trimmedLines(s string) []string {
+	lines := strings.Split(normalizeNewlines(strings.TrimRight(s, " \t\r\n")), "\n")
+
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+
+	return lines
+}
+
+func lineTokens(s string) [][]string {
+	lines := trimmedLines(s)
+	tokens := make([][]string, 0, len(lines))
+
+	for i := range lines {
+		tokens = append(tokens, strings.Fields(lines[i]))
+	}
+
+	return tokens
+}
+
+// floatTokensEqual compares tokens as numbers when both are numbers, with
+// absolute or relative error up to epsilon, and as strings otherwise.
+func floatTokensEqual(expected, output string, epsilon float64) bool {
+	e, errE := strconv.ParseFloat(expected, 64)
+	o, errO := strconv.ParseFloat(output, 64)
+
+	if errE != nil || errO != nil {
+		return expected == output
+	}
+
+	if math.IsNaN(e) || math.IsNaN(o) {
+		return math.IsNaN(e) && math.IsNaN(o)
+	}
+
+	diff := math.Abs(e - o)
+
+	return diff <= epsilon || diff <= epsilon*math.Abs(e)
+}
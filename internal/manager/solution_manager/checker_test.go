@@ -0,0 +1,107 @@
+package solution_manager
+
+import (
+	"io"
+	"lcode/internal/domain"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCheckOutput(t *testing.T) {
+	// longer than the buffer of the expected reader, so the token is split
+	// between reads
+	long := strings.Repeat("x", 5000)
+
+	tests := []struct {
+		name     string
+		checker  domain.CheckerType
+		epsilon  float64
+		expected string
+		output   string
+		want     bool
+	}{
+		{name: "exact equal", checker: domain.CheckerExact, expected: "1 2\n3", output: "1 2\n3", want: true},
+		{name: "exact crlf expected", checker: domain.CheckerExact, expected: "1 2\r\n3\r\n", output: "1 2\n3", want: true},
+		{name: "exact crlf output", checker: domain.CheckerExact, expected: "1 2\n3\n", output: "1 2\r\n3\r\n", want: true},
+		{name: "exact trailing blank lines expected", checker: domain.CheckerExact, expected: "3\n\n\n", output: "3", want: true},
+		{name: "exact trailing blank lines output", checker: domain.CheckerExact, expected: "3", output: "3\n\n\n", want: true},
+		{name: "exact inner whitespace", checker: domain.CheckerExact, expected: "1 2", output: "1  2", want: false},
+		{name: "exact shorter output", checker: domain.CheckerExact, expected: "33", output: "3", want: false},
+		{name: "exact longer output", checker: domain.CheckerExact, expected: "3", output: "33", want: false},
+		{name: "exact empty", checker: domain.CheckerExact, expected: "\n", output: "", want: true},
+		{name: "exact long line", checker: domain.CheckerExact, expected: long + "\n", output: long, want: true},
+
+		{name: "whitespace equal", checker: domain.CheckerWhitespace, expected: "1   2\n3\n", output: "1 2\n3", want: true},
+		{name: "whitespace crlf", checker: domain.CheckerWhitespace, expected: "1 2\r\n3\r\n", output: "1 2\r\n3", want: true},
+		{name: "whitespace trailing blank lines", checker: domain.CheckerWhitespace, expected: "3\n\n\n", output: "3\n", want: true},
+		{name: "whitespace lines differ", checker: domain.CheckerWhitespace, expected: "1\n2", output: "1 2", want: false},
+		{name: "whitespace inner blank line", checker: domain.CheckerWhitespace, expected: "1\n\n2", output: "1\n2", want: false},
+		{name: "whitespace split token", checker: domain.CheckerWhitespace, expected: "1 " + long + "\n2", output: "1 " + long + "\n2", want: true},
+
+		{name: "tokens equal", checker: domain.CheckerTokens, expected: "1\n2  3\n", output: "1 2 3", want: true},
+		{name: "tokens crlf", checker: domain.CheckerTokens, expected: "1\r\n2\r\n\r\n", output: "1 2", want: true},
+		{name: "tokens missing", checker: domain.CheckerTokens, expected: "1 2 3", output: "1 2", want: false},
+		{name: "tokens extra", checker: domain.CheckerTokens, expected: "1 2", output: "1 2 3", want: false},
+		{name: "tokens split token", checker: domain.CheckerTokens, expected: "1 " + long + " 2\n", output: "1\n" + long + "\n2", want: true},
+		{name: "tokens split unicode", checker: domain.CheckerTokens, expected: "привет мир\n", output: "привет\nмир", want: true},
+		{name: "tokens too long", checker: domain.CheckerTokens, expected: "1 " + long, output: "1 y", want: false},
+
+		{name: "float within epsilon", checker: domain.CheckerFloat, epsilon: 1e-6, expected: "0.3333333\n", output: "0.33333335", want: true},
+		{name: "float trailing zeros", checker: domain.CheckerFloat, epsilon: 1e-6, expected: "1.000", output: "1", want: true},
+		{name: "float out of epsilon", checker: domain.CheckerFloat, epsilon: 1e-6, expected: "1", output: "1.1", want: false},
+		{name: "float words", checker: domain.CheckerFloat, epsilon: 1e-6, expected: "yes\r\n", output: "yes", want: true},
+
+		{name: "unordered equal", checker: domain.CheckerUnorderedLines, expected: "a\nb\nc\n", output: "c\r\na\nb", want: true},
+		{name: "unordered trailing blank lines", checker: domain.CheckerUnorderedLines, expected: "a\nb\n\n\n", output: "b\na", want: true},
+		{name: "unordered counts differ", checker: domain.CheckerUnorderedLines, expected: "a\na\nb", output: "a\nb\nb", want: false},
+		{name: "unordered inner blank line", checker: domain.CheckerUnorderedLines, expected: "a\n\nb", output: "a\nb\n", want: false},
+		{name: "unordered inner blank line both", checker: domain.CheckerUnorderedLines, expected: "a\r\n\r\nb\r\n", output: "b\n\na", want: true},
+	}
+
+	readers := []struct {
+		name string
+		new  func(s string) io.Reader
+	}{
+		{name: "whole", new: func(s string) io.Reader { return strings.NewReader(s) }},
+		{name: "one byte", new: func(s string) io.Reader { return iotest.OneByteReader(strings.NewReader(s)) }},
+		{name: "half", new: func(s string) io.Reader { return iotest.HalfReader(strings.NewReader(s)) }},
+	}
+
+	for _, tt := range tests {
+		for _, r := range readers {
+			t.Run(tt.name+"/"+r.name, func(t *testing.T) {
+				got, err := checkOutput(tt.checker, tt.epsilon, r.new(tt.expected), tt.output)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != tt.want {
+					t.Errorf("checkOutput(%q, %q) = %v, want %v", tt.expected, tt.output, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckerProgramVerdict(t *testing.T) {
+	tests := []struct {
+		status domain.JudgeStatus
+		want   domain.JudgeStatus
+	}{
+		{status: domain.Accepted, want: domain.Accepted},
+		{status: domain.RuntimeNZEC, want: domain.WrongAnswer},
+		{status: domain.WrongAnswer, want: domain.InternalError},
+		{status: domain.TimeLimitExceeded, want: domain.InternalError},
+		{status: domain.CompilationError, want: domain.InternalError},
+		{status: domain.RuntimeSIGSEV, want: domain.InternalError},
+		{status: domain.RuntimeOther, want: domain.InternalError},
+		{status: domain.InternalError, want: domain.InternalError},
+	}
+
+	for _, tt := range tests {
+		if got := checkerProgramVerdict(tt.status); got != tt.want {
+			t.Errorf("checkerProgramVerdict(%d) = %d, want %d", tt.status, got, tt.want)
+		}
+	}
+}
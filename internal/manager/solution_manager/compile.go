@@ -0,0 +1,54 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+)
+
+// Limits of the run made by the compile step. Judge0 compiles with its own
+// maximum limits, these ones bound only the run of the compiled program.
+const (
+	compileCpuTimeLimit  = 0.1
+	compileWallTimeLimit = 1.0
+)
+
+// compile checks that the code of a compiled language compiles before test
+// cases are run, so a compilation error is reported once for the whole
+// solution instead of failing every test case. Judge0 has no compile-only
+// mode: the code is run once with empty stdin and minimal time limits, only
+// the compilation verdict of this run is used. Runtime errors and time limits
+// of the run are expected, e.g. the program reads input which is not there,
+// and they are not failures of the solution. Code of other languages is not
+// checked.
+func (m *Manager) compile(
+	ctx context.Context,
+	srcCode string,
+	languageID domain.LanguageType,
+	template *domain.TaskTemplate,
+	lang *domain.Language,
+) (compileOutput *string, ok bool, err error) {
+	if !lang.Compiled {
+		return nil, true, nil
+	}
+
+	info, err := m.services.Judge.CreateSubmission(ctx, domain.CreateJudgeSubmission{
+		SourceCode:           srcCode,
+		LanguageID:           languageID,
+		CpuTimeLimit:         compileCpuTimeLimit,
+		WallTimeLimit:        compileWallTimeLimit,
+		MemoryLimit:          template.Limits.MemoryLimit,
+		CompilerOptions:      lang.CompilerOptions,
+		CommandLineArguments: lang.CommandLineArguments,
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "compile solution manager")
+	}
+
+	// any other verdict means that the code compiled
+	if info.Status == domain.CompilationError {
+		return info.CompileOutput, false, nil
+	}
+
+	return nil, true, nil
+}
@@ -6,5 +6,6 @@ type workerItem struct {
 	solution  domain.Solution
 	task      domain.Task
 	template  domain.TaskTemplate
+	language  domain.Language
 	testCases []domain.TestCase
 }
@@ -0,0 +1,104 @@
+package solution_manager
+
+import (
+	"lcode/internal/domain"
+	"sync"
+)
+
+const subscriberBufferSize = 64
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[string]map[chan domain.SolutionEvent]struct{}),
+	}
+}
+
+// eventBroker fans out judging progress of a solution to its subscribers.
+// Publishing never blocks the worker: events for a slow subscriber are dropped.
+// The finished event is never dropped, the subscription of a slow subscriber
+// is ended instead, so it reads the final status of the solution itself.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan domain.SolutionEvent]struct{}
+	closed      bool
+}
+
+func (b *eventBroker) Subscribe(solutionID string) (<-chan domain.SolutionEvent, func()) {
+	ch := make(chan domain.SolutionEvent, subscriberBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+
+		return ch, func() {}
+	}
+
+	if _, ok := b.subscribers[solutionID]; !ok {
+		b.subscribers[solutionID] = make(map[chan domain.SolutionEvent]struct{})
+	}
+
+	b.subscribers[solutionID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		subs, ok := b.subscribers[solutionID]
+		if !ok {
+			return
+		}
+
+		if _, ok = subs[ch]; !ok {
+			return
+		}
+
+		delete(subs, ch)
+		close(ch)
+
+		if len(subs) == 0 {
+			delete(b.subscribers, solutionID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *eventBroker) Publish(solutionID string, event domain.SolutionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subscribers[solutionID]
+
+	for ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			if event.Type == domain.SolutionEventFinished {
+				delete(subs, ch)
+				close(ch)
+			}
+		}
+	}
+
+	if len(subs) == 0 {
+		delete(b.subscribers, solutionID)
+	}
+}
+
+// Close ends every subscription, so event streams are finished on shutdown.
+func (b *eventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for solutionID, subs := range b.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+
+		delete(b.subscribers, solutionID)
+	}
+}
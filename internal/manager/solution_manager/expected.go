@@ -0,0 +1,289 @@
+package solution_manager
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// expectedReader reads expected output of a test case for built-in checkers
+// without holding it in memory. Runes keep their bytes as is, so invalid UTF-8
+// is compared byte by byte like in strings. Tokens and lines longer than
+// maxLen can not match the output, they are skipped and reported as too long.
+type expectedReader struct {
+	r       *bufio.Reader
+	maxLen  int
+	raw     [utf8.UTFMax]byte
+	newline bool
+	buf     []byte
+}
+
+func newExpectedReader(r io.Reader, maxLen int) *expectedReader {
+	return &expectedReader{
+		r:      bufio.NewReader(r),
+		maxLen: maxLen,
+	}
+}
+
+// next returns the next rune and its bytes which are valid till the next call.
+func (e *expectedReader) next() (rune, []byte, error) {
+	b, err := e.r.Peek(utf8.UTFMax)
+	if len(b) == 0 {
+		return 0, nil, err
+	}
+
+	rn, size := utf8.DecodeRune(b)
+	n := copy(e.raw[:], b[:size])
+
+	_, _ = e.r.Discard(size)
+
+	return rn, e.raw[:n], nil
+}
+
+// nextToken returns the next whitespace separated token or "\n" at line
+// breaks.
+func (e *expectedReader) nextToken() (token string, tooLong bool, err error) {
+	if e.newline {
+		e.newline = false
+
+		return "\n", false, nil
+	}
+
+	e.buf = e.buf[:0]
+
+	for {
+		rn, raw, err := e.next()
+		if err != nil {
+			if err == io.EOF && (len(e.buf) != 0 || tooLong) {
+				return string(e.buf), tooLong, nil
+			}
+
+			return "", false, err
+		}
+
+		switch {
+		case rn == '\n':
+			if len(e.buf) == 0 && !tooLong {
+				return "\n", false, nil
+			}
+
+			e.newline = true
+
+			return string(e.buf), tooLong, nil
+		case unicode.IsSpace(rn):
+			if len(e.buf) != 0 || tooLong {
+				return string(e.buf), tooLong, nil
+			}
+		case tooLong || len(e.buf)+len(raw) > e.maxLen:
+			tooLong = true
+		default:
+			e.buf = append(e.buf, raw...)
+		}
+	}
+}
+
+// nextLine returns the next line without surrounding whitespace. It returns
+// io.EOF only when nothing is left, so the last line may lack a line break.
+func (e *expectedReader) nextLine() (line string, tooLong bool, err error) {
+	e.buf = e.buf[:0]
+	// trailing holds bytes of whitespace at the end of buf, whitespace which
+	// did not fit is dropped and only lets the line end
+	trailing := 0
+	dropped := false
+	read := false
+
+	for {
+		rn, raw, err := e.next()
+		if err != nil {
+			if err == io.EOF && read {
+				return string(e.buf[:len(e.buf)-trailing]), tooLong, nil
+			}
+
+			return "", false, err
+		}
+
+		read = true
+
+		if rn == '\n' {
+			return string(e.buf[:len(e.buf)-trailing]), tooLong, nil
+		}
+
+		space := unicode.IsSpace(rn)
+
+		switch {
+		case tooLong:
+		case space && len(e.buf) == 0:
+		case dropped && !space:
+			tooLong = true
+		case len(e.buf)+len(raw) > e.maxLen:
+			if space {
+				dropped = true
+			} else {
+				tooLong = true
+			}
+		default:
+			e.buf = append(e.buf, raw...)
+
+			if space {
+				trailing += len(raw)
+			} else {
+				trailing = 0
+			}
+		}
+	}
+}
+
+// equalExact compares the expected output with newlines normalized against
+// the output, the rest of the expected output may be only trailing whitespace.
+func (e *expectedReader) equalExact(output string) (bool, error) {
+	i := 0
+
+	for {
+		c, err := e.r.ReadByte()
+		if err == io.EOF {
+			return i == len(output), nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if c == '\r' {
+			if next, err := e.r.Peek(1); err == nil && next[0] == '\n' {
+				continue
+			}
+		}
+
+		if i < len(output) {
+			if c != output[i] {
+				return false, nil
+			}
+
+			i++
+
+			continue
+		}
+
+		if !strings.ContainsRune(" \t\r\n", rune(c)) {
+			return false, nil
+		}
+	}
+}
+
+// equalTokens compares whitespace separated tokens of the expected output
+// with tokens of the output.
+func (e *expectedReader) equalTokens(output []string, equal func(e, o string) bool) (bool, error) {
+	i := 0
+
+	for {
+		token, tooLong, err := e.nextToken()
+		if err == io.EOF {
+			return i == len(output), nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if token == "\n" {
+			continue
+		}
+
+		if tooLong || i == len(output) || !equal(token, output[i]) {
+			return false, nil
+		}
+
+		i++
+	}
+}
+
+// equalLineTokens compares the expected output with tokens of the output
+// line by line, empty lines at the end of the expected output are ignored.
+func (e *expectedReader) equalLineTokens(output [][]string) (bool, error) {
+	line, pos := 0, 0
+
+	for {
+		token, tooLong, err := e.nextToken()
+		if err == io.EOF {
+			if line < len(output) {
+				return line == len(output)-1 && pos == len(output[line]), nil
+			}
+
+			return true, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if token == "\n" {
+			if line < len(output) && pos != len(output[line]) {
+				return false, nil
+			}
+
+			line++
+			pos = 0
+
+			continue
+		}
+
+		if tooLong || line >= len(output) || pos >= len(output[line]) || token != output[line][pos] {
+			return false, nil
+		}
+
+		pos++
+	}
+}
+
+// equalUnorderedLines compares lines of the expected output with lines of the
+// output as multisets, empty lines at the end of the expected output are
+// ignored.
+func (e *expectedReader) equalUnorderedLines(output []string) (bool, error) {
+	counts := make(map[string]int, len(output))
+	for i := range output {
+		counts[output[i]]++
+	}
+
+	remaining := len(output)
+	// empty lines are counted once a non-empty line follows them
+	emptyLines := 0
+
+	for {
+		line, tooLong, err := e.nextLine()
+		if err == io.EOF {
+			return remaining == 0, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if tooLong {
+			return false, nil
+		}
+
+		if line == "" {
+			emptyLines++
+
+			continue
+		}
+
+		for ; emptyLines > 0; emptyLines-- {
+			if counts[""] == 0 {
+				return false, nil
+			}
+
+			counts[""]--
+			remaining--
+		}
+
+		if counts[line] == 0 {
+			return false, nil
+		}
+
+		counts[line]--
+		remaining--
+	}
+}
@@ -2,17 +2,36 @@ package solution_manager
 
 import (
 	"context"
+	"io"
 	"lcode/internal/domain"
 )
 
 type (
 	SolutionManager interface {
 		CreateSolution(ctx context.Context, dto domain.CreateSolutionDTO) (sol domain.Solution, err error)
-		GetAvailableSolutionStatuses() ([]domain.JudgeStatusInfo, error)
+		RunSolution(ctx context.Context, dto domain.RunSolutionDTO) ([]domain.RunResult, error)
+		GetAvailableSolutionStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error)
+		SubscribeSolutionEvents(solutionID string) (<-chan domain.SolutionEvent, func())
+		SolutionResults(ctx context.Context, dto domain.GetSolutionResultsDTO) ([]domain.SolutionResult, error)
+		SolutionOutput(ctx context.Context, dto domain.GetSolutionOutputDTO) (string, error)
+		HandleJudgeCallback(ctx context.Context, dto domain.JudgeCallbackDTO) error
+
+		QueueStats(ctx context.Context) (domain.SolutionQueueStats, error)
+		QueuedSolutions(ctx context.Context, dto domain.GetQueuedSolutionsDTO) (domain.QueuedSolutionList, error)
+		CancelQueuedSolution(ctx context.Context, dto domain.CancelQueuedSolutionDTO) (domain.Solution, error)
+		ResizeWorkerPool(ctx context.Context, dto domain.ResizeWorkerPoolDTO) (domain.SolutionQueueStats, error)
+
+		RejudgeTask(ctx context.Context, dto domain.RejudgeTaskDTO) (domain.Rejudge, error)
+		Rejudge(ctx context.Context, dto domain.GetRejudgeDTO) (domain.Rejudge, error)
 	}
 
 	ProblemManager interface {
 		FullProblemByTaskID(ctx context.Context, taskID string) (domain.Problem, error)
+		TestCasesByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error)
+		LoadTestCaseInputs(ctx context.Context, testCases []domain.TestCase) error
+		LoadTestCaseOutputs(ctx context.Context, testCases []domain.TestCase) error
+		LoadTestCaseSamples(ctx context.Context, testCases []domain.TestCase) error
+		OpenTestCaseOutput(ctx context.Context, testCase domain.TestCase) (io.ReadCloser, error)
 	}
 
 	Judge interface {
@@ -21,6 +40,13 @@ type (
 			data domain.CreateJudgeSubmission,
 		) (domain.JudgeSubmissionInfo, error)
 
+		CreateSubmissionBatch(ctx context.Context, data []domain.CreateJudgeSubmission) ([]string, error)
+		GetSubmissionBatch(ctx context.Context, tokens []string) ([]domain.JudgeSubmissionInfo, error)
+
 		GetAvailableStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error)
 	}
+
+	JudgeNodes interface {
+		Nodes() []domain.JudgeNodeStats
+	}
 )
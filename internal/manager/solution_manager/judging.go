@@ -0,0 +1,205 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"math"
+	"time"
+)
+
+const (
+	// judgeBatchSize is the default MAX_SUBMISSION_BATCH_SIZE of Judge0
+	judgeBatchSize = 20
+)
+
+func newSolutionResult(solutionID, testCaseID string, info domain.JudgeSubmissionInfo) domain.SolutionResult {
+	return domain.SolutionResult{
+		SolutionID:      solutionID,
+		TestCaseID:      testCaseID,
+		SubmissionToken: info.Token,
+		Status:          info.Status,
+		Runtime:         info.Time,
+		Memory:          info.Memory,
+		Stdout:          info.Stdout,
+		Stderr:          info.Stderr,
+	}
+}
+
+// judgeSequentially runs test cases one by one waiting for every result and
+// stops at the first test case which was not accepted unless fullReport is set.
+func (m *Manager) judgeSequentially(ctx context.Context, item *workerItem) ([]domain.SolutionResult, error) {
+	solutionID := item.solution.Id
+	task := &item.task
+	testCases := item.testCases
+	results := make([]domain.SolutionResult, 0, len(testCases))
+
+	for i := range testCases {
+		submissions, err := m.newSubmissions(ctx, item, testCases[i:i+1])
+		if err != nil {
+			return results, errors.Wrap(err, "judgeSequentially solution manager")
+		}
+
+		info, err := m.services.Judge.CreateSubmission(ctx, submissions[0])
+		if err != nil {
+			return results, errors.Wrap(err, "judgeSequentially solution manager")
+		}
+
+		err = m.applyChecker(ctx, task, &testCases[i], &info)
+		if err != nil {
+			return results, errors.Wrap(err, "judgeSequentially solution manager")
+		}
+
+		result := newSolutionResult(solutionID, testCases[i].ID, info)
+		results = append(results, result)
+
+		m.publishTestCaseResult(i+1, result)
+
+		if info.Status != domain.Accepted && !task.FullReport {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// judgeBatch creates submissions for all test cases at once, so they run in
+// parallel inside the judge, and polls them by tokens until the verdict is
+// known. Inputs are read batch by batch, see batchEnd, while the submissions
+// are created. Like judgeSequentially it returns results up to the first test
+// case which was not accepted unless fullReport is set.
+func (m *Manager) judgeBatch(ctx context.Context, item *workerItem) ([]domain.SolutionResult, error) {
+	solutionID := item.solution.Id
+	task := &item.task
+	testCases := item.testCases
+	tokens := make([]string, 0, len(testCases))
+
+	for start, end := 0, 0; start < len(testCases); start = end {
+		end = batchEnd(testCases, start, m.cfg.Files.JudgeBatchMaxSize)
+
+		submissions, err := m.newSubmissions(ctx, item, testCases[start:end])
+		if err != nil {
+			return nil, errors.Wrap(err, "judgeBatch solution manager")
+		}
+
+		batchTokens, err := m.services.Judge.CreateSubmissionBatch(ctx, submissions)
+		if err != nil {
+			return nil, errors.Wrap(err, "judgeBatch solution manager")
+		}
+
+		tokens = append(tokens, batchTokens...)
+	}
+
+	positions := make(map[string]int, len(tokens))
+	for i := range tokens {
+		positions[tokens[i]] = i
+	}
+
+	infos := make([]*domain.JudgeSubmissionInfo, len(tokens))
+
+	ticker := time.NewTicker(m.cfg.JudgeConfig.BatchPollInterval)
+	defer ticker.Stop()
+
+	fullReport := task.FullReport
+
+	for pending := pendingTokens(tokens, infos, fullReport); len(pending) != 0; pending = pendingTokens(tokens, infos, fullReport) {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "judgeBatch solution manager")
+		case <-ticker.C:
+		}
+
+		for start := 0; start < len(pending); start += judgeBatchSize {
+			end := min(start+judgeBatchSize, len(pending))
+
+			batchInfos, err := m.services.Judge.GetSubmissionBatch(ctx, pending[start:end])
+			if err != nil {
+				return nil, errors.Wrap(err, "judgeBatch solution manager")
+			}
+
+			for i := range batchInfos {
+				pos, ok := positions[batchInfos[i].Token]
+				if !ok || !batchInfos[i].Status.IsFinished() {
+					continue
+				}
+
+				err = m.applyChecker(ctx, task, &testCases[pos], &batchInfos[i])
+				if err != nil {
+					return nil, errors.Wrap(err, "judgeBatch solution manager")
+				}
+
+				infos[pos] = &batchInfos[i]
+
+				m.publishTestCaseResult(pos+1, newSolutionResult(solutionID, testCases[pos].ID, batchInfos[i]))
+			}
+		}
+	}
+
+	results := make([]domain.SolutionResult, 0, len(infos))
+
+	for i := range infos {
+		results = append(results, newSolutionResult(solutionID, testCases[i].ID, *infos[i]))
+
+		if infos[i].Status != domain.Accepted && !task.FullReport {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// batchEnd returns end of the batch of test cases which starts at start: up to
+// judgeBatchSize test cases with total input up to maxSize bytes, inputs of
+// the batch are held in memory together. A larger test case makes a batch of
+// its own.
+func batchEnd(testCases []domain.TestCase, start int, maxSize int64) int {
+	end := start
+	var size int64
+
+	for end < len(testCases) && end-start < judgeBatchSize {
+		size += testCases[end].InputSize
+		if end > start && size > maxSize {
+			break
+		}
+
+		end++
+	}
+
+	return end
+}
+
+// pendingTokens returns tokens of unfinished submissions which still can
+// affect the verdict: submissions after the first failed one are ignored
+// unless fullReport is set.
+func pendingTokens(tokens []string, infos []*domain.JudgeSubmissionInfo, fullReport bool) []string {
+	pending := make([]string, 0, len(tokens))
+
+	for i := range infos {
+		if infos[i] == nil {
+			pending = append(pending, tokens[i])
+
+			continue
+		}
+
+		if infos[i].Status != domain.Accepted && !fullReport {
+			break
+		}
+	}
+
+	return pending
+}
+
+// solutionScore returns percentage of accepted test cases.
+func solutionScore(results []domain.SolutionResult, total int) (passed int, score float64) {
+	for i := range results {
+		if results[i].Status == domain.Accepted {
+			passed++
+		}
+	}
+
+	if total == 0 {
+		return passed, 0
+	}
+
+	return passed, math.Round(float64(passed)*10000/float64(total)) / 100
+}
@@ -0,0 +1,61 @@
+package solution_manager
+
+import (
+	"sync"
+	"time"
+)
+
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		interval: interval,
+		windows:  make(map[string]rateWindow),
+	}
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter allows at most limit calls per key during fixed time windows.
+type rateLimiter struct {
+	limit     int
+	interval  time.Duration
+	mu        sync.Mutex
+	windows   map[string]rateWindow
+	lastSweep time.Time
+}
+
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastSweep) >= l.interval {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.interval {
+				delete(l.windows, k)
+			}
+		}
+
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.interval {
+		l.windows[key] = rateWindow{start: now, count: 1}
+
+		return true
+	}
+
+	if w.count >= l.limit {
+		return false
+	}
+
+	w.count++
+	l.windows[key] = w
+
+	return true
+}
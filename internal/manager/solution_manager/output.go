@@ -0,0 +1,175 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// boundOutputs truncates outputs of the results to MaxBytes and returns full
+// outputs of the truncated ones, they are stored apart for download.
+func (m *Manager) boundOutputs(results []domain.SolutionResult) []domain.SolutionOutput {
+	outputs := make([]domain.SolutionOutput, 0)
+
+	for i := range results {
+		stdout, stdoutTruncated := truncateOutput(results[i].Stdout, m.cfg.OutputConfig.MaxBytes)
+		stderr, stderrTruncated := truncateOutput(results[i].Stderr, m.cfg.OutputConfig.MaxBytes)
+
+		if !stdoutTruncated && !stderrTruncated {
+			continue
+		}
+
+		outputs = append(outputs, domain.SolutionOutput{
+			SolutionID: results[i].SolutionID,
+			TestCaseID: results[i].TestCaseID,
+			Stdout:     results[i].Stdout,
+			Stderr:     results[i].Stderr,
+		})
+
+		results[i].Stdout, results[i].StdoutTruncated = stdout, stdoutTruncated
+		results[i].Stderr, results[i].StderrTruncated = stderr, stderrTruncated
+	}
+
+	return outputs
+}
+
+// truncateOutput cuts the output to maxBytes without splitting a UTF-8
+// character.
+func truncateOutput(output *string, maxBytes int) (*string, bool) {
+	if output == nil || len(*output) <= maxBytes {
+		return output, false
+	}
+
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart((*output)[end]) {
+		end--
+	}
+
+	truncated := (*output)[:end]
+
+	return &truncated, true
+}
+
+// saveResults stores the results with bounded outputs.
+func (m *Manager) saveResults(ctx context.Context, results ...domain.SolutionResult) error {
+	outputs := m.boundOutputs(results)
+
+	err := m.services.SolutionResult.CreateBatch(ctx, results...)
+	if err != nil {
+		return errors.Wrap(err, "saveResults solution manager")
+	}
+
+	err = m.services.SolutionOutput.CreateBatch(ctx, outputs...)
+	if err != nil {
+		return errors.Wrap(err, "saveResults solution manager")
+	}
+
+	return nil
+}
+
+// SolutionOutput returns the full output of the test case. Outputs which were
+// truncated are available till FullTTL passes.
+func (m *Manager) SolutionOutput(ctx context.Context, dto domain.GetSolutionOutputDTO) (string, error) {
+	results, err := m.services.SolutionResult.ResultsBySolutionID(ctx, dto.SolutionID)
+	if err != nil {
+		return "", errors.Wrap(err, "SolutionOutput solution manager")
+	}
+
+	idx := slices.IndexFunc(results, func(r domain.SolutionResult) bool {
+		return r.TestCaseID == dto.TestCaseID
+	})
+	if idx < 0 {
+		err = struct_errors.NewErrNotFound("Solution result not found", nil)
+
+		return "", errors.Wrap(err, "SolutionOutput solution manager")
+	}
+
+	result := results[idx]
+
+	if !dto.User.IsAdmin && result.Visibility != domain.TestCaseVisibilitySample {
+		err = struct_errors.NewBaseErr("Output of the hidden test case is not available", nil)
+
+		return "", errors.Wrap(err, "SolutionOutput solution manager")
+	}
+
+	inline, truncated := result.Stdout, result.StdoutTruncated
+	if dto.Stream == domain.OutputStreamStderr {
+		inline, truncated = result.Stderr, result.StderrTruncated
+	}
+
+	if !truncated {
+		if inline == nil {
+			err = struct_errors.NewErrNotFound("Output is empty", nil)
+
+			return "", errors.Wrap(err, "SolutionOutput solution manager")
+		}
+
+		return *inline, nil
+	}
+
+	output, err := m.services.SolutionOutput.Get(ctx, dto.SolutionID, dto.TestCaseID)
+	if err != nil {
+		return "", errors.Wrap(err, "SolutionOutput solution manager")
+	}
+
+	full := output.Stdout
+	if dto.Stream == domain.OutputStreamStderr {
+		full = output.Stderr
+	}
+
+	if full == nil {
+		err = struct_errors.NewErrNotFound("Output is empty", nil)
+
+		return "", errors.Wrap(err, "SolutionOutput solution manager")
+	}
+
+	return *full, nil
+}
+
+func (m *Manager) runOutputPruner() {
+	defer close(m.prunerDone)
+
+	ticker := time.NewTicker(m.cfg.OutputConfig.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.dispatchCtx.Done():
+			return
+		case <-ticker.C:
+			err := m.pruneOutputs(m.dispatchCtx)
+			if err != nil && m.dispatchCtx.Err() == nil {
+				m.logger.Error("can not prune solution outputs", slog.String("err", err.Error()))
+			}
+		}
+	}
+}
+
+// pruneOutputs deletes full outputs which expired and outputs of results
+// older than Retention.
+func (m *Manager) pruneOutputs(ctx context.Context) error {
+	expired, err := m.services.SolutionOutput.DeleteExpired(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pruneOutputs solution manager")
+	}
+
+	pruned, err := m.services.SolutionResult.PruneOutputs(ctx, m.cfg.OutputConfig.Retention)
+	if err != nil {
+		return errors.Wrap(err, "pruneOutputs solution manager")
+	}
+
+	if expired != 0 || pruned != 0 {
+		m.logger.Info(
+			"solution outputs are pruned",
+			slog.Int64("full_outputs", expired),
+			slog.Int64("results", pruned),
+		)
+	}
+
+	return nil
+}
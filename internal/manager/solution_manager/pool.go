@@ -0,0 +1,250 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	workerStateIdle     = "idle"
+	workerStateBusy     = "busy"
+	workerStateStopping = "stopping"
+)
+
+func newWorkerPool(ctx context.Context, size int, handle func(context.Context, workerItem)) *workerPool {
+	p := &workerPool{
+		ctx:     ctx,
+		idle:    make(chan *reservation),
+		workers: make(map[int]*worker),
+		handle:  handle,
+	}
+
+	p.Resize(size)
+
+	return p
+}
+
+type worker struct {
+	id         int
+	jobs       chan *workerItem
+	stop       chan struct{}
+	stopping   bool
+	solutionID string
+	startedAt  time.Time
+	cancel     context.CancelCauseFunc
+}
+
+// reservation is an idle worker held by the dispatcher while it claims a
+// solution, so solutions are claimed only when a worker can judge them at once.
+// The worker waits until the item is assigned or the reservation is freed.
+type reservation struct {
+	jobs chan *workerItem
+	done bool
+}
+
+// Assign hands the item over to the reserved worker.
+func (r *reservation) Assign(item workerItem) {
+	r.done = true
+	r.jobs <- &item
+}
+
+// Free gives the worker back to the pool unless an item was assigned to it.
+func (r *reservation) Free() {
+	if r.done {
+		return
+	}
+
+	r.done = true
+	r.jobs <- nil
+}
+
+// workerPool runs solution workers which take items one by one from
+// reservations. The pool can be resized at runtime, stopped workers finish
+// their current item. A pool of size 0 pauses judging: nothing can be reserved.
+// Every item is handled with its own context derived from ctx, so judging of
+// a single solution can be abandoned.
+type workerPool struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	ctx     context.Context
+	idle    chan *reservation
+	workers map[int]*worker
+	nextID  int
+	size    int
+	handle  func(context.Context, workerItem)
+}
+
+// Reserve blocks until a worker is idle or ctx is done. The reservation must
+// be assigned an item or freed.
+func (p *workerPool) Reserve(ctx context.Context) (*reservation, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "Reserve worker pool")
+	case r := <-p.idle:
+		return r, nil
+	}
+}
+
+// Wait blocks until every stopped worker has finished its current item or ctx
+// is done.
+func (p *workerPool) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "Wait worker pool")
+	case <-done:
+		return nil
+	}
+}
+
+func (p *workerPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.size
+}
+
+func (p *workerPool) Resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for ; p.size < size; p.size++ {
+		p.nextID++
+
+		w := &worker{id: p.nextID, jobs: make(chan *workerItem), stop: make(chan struct{})}
+		p.workers[w.id] = w
+
+		p.wg.Add(1)
+		go p.run(w)
+	}
+
+	if p.size <= size {
+		return
+	}
+
+	// stop idle workers first, busy ones will stop after current item
+	active := make([]*worker, 0, len(p.workers))
+	for _, w := range p.workers {
+		if !w.stopping {
+			active = append(active, w)
+		}
+	}
+
+	slices.SortFunc(active, func(a, b *worker) int {
+		if (a.solutionID == "") != (b.solutionID == "") {
+			if a.solutionID == "" {
+				return -1
+			}
+
+			return 1
+		}
+
+		return b.id - a.id
+	})
+
+	for i := 0; p.size > size; i++ {
+		active[i].stopping = true
+		close(active[i].stop)
+		p.size--
+	}
+}
+
+func (p *workerPool) States() []domain.JudgeWorkerState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := make([]domain.JudgeWorkerState, 0, len(p.workers))
+
+	for _, w := range p.workers {
+		state := domain.JudgeWorkerState{ID: w.id, State: workerStateIdle}
+
+		if w.solutionID != "" {
+			solutionID := w.solutionID
+			startedAt := domain.IntTime(w.startedAt)
+
+			state.State = workerStateBusy
+			state.SolutionID = &solutionID
+			state.StartedAt = &startedAt
+		}
+
+		if w.stopping {
+			state.State = workerStateStopping
+		}
+
+		states = append(states, state)
+	}
+
+	slices.SortFunc(states, func(a, b domain.JudgeWorkerState) int {
+		return a.ID - b.ID
+	})
+
+	return states
+}
+
+func (p *workerPool) run(w *worker) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.workers, w.id)
+		p.mu.Unlock()
+
+		p.wg.Done()
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case p.idle <- &reservation{jobs: w.jobs}:
+		}
+
+		item := <-w.jobs
+		if item == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithCancelCause(p.ctx)
+
+		p.setSolution(w, item.solution.Id, cancel)
+		p.handle(ctx, *item)
+		p.setSolution(w, "", nil)
+
+		cancel(nil)
+	}
+}
+
+func (p *workerPool) setSolution(w *worker, solutionID string, cancel context.CancelCauseFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w.solutionID = solutionID
+	w.startedAt = time.Now()
+	w.cancel = cancel
+}
+
+// Abandon cancels judging of the solution with the cause and reports whether
+// a worker was judging it. The worker becomes free as soon as its requests to
+// the judge are cancelled.
+func (p *workerPool) Abandon(solutionID string, cause error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range p.workers {
+		if w.solutionID == solutionID && w.cancel != nil {
+			w.cancel(cause)
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"log/slog"
+)
+
+// RejudgeTask pushes solutions of the task matching the filter to the rejudge
+// lane. Solutions keep their verdicts until they are judged again, then their
+// results are replaced and the new verdicts are reported in the rejudge summary.
+func (m *Manager) RejudgeTask(ctx context.Context, dto domain.RejudgeTaskDTO) (rej domain.Rejudge, err error) {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	rej, err = m.services.Rejudge.Create(ctx, domain.CreateRejudgeEntity{
+		TaskID:   dto.TaskID,
+		AuthorID: dto.User.ID,
+		Filter:   dto.Filter,
+	})
+	if err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+
+	_, err = m.services.Rejudge.AddSolutions(ctx, rej)
+	if err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+
+	pushed, err := m.services.SolutionQueue.PushRejudge(ctx, rej.ID)
+	if err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+
+	rej.Summary, err = m.services.Rejudge.Summary(ctx, rej.ID)
+	if err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+
+	tx.AfterSuccess(ctx, m.wake)
+
+	if err = tx.Commit(ctx); err != nil {
+		return rej, errors.Wrap(err, "RejudgeTask solution manager")
+	}
+
+	m.logger.Info(
+		"task rejudge started",
+		slog.String("task_id", rej.TaskID),
+		slog.String("rejudge_id", rej.ID),
+		slog.Int64("solutions", pushed),
+	)
+
+	return rej, nil
+}
+
+func (m *Manager) Rejudge(ctx context.Context, dto domain.GetRejudgeDTO) (rej domain.Rejudge, err error) {
+	rej, err = m.services.Rejudge.GetByID(ctx, dto)
+	if err != nil {
+		return rej, errors.Wrap(err, "Rejudge solution manager")
+	}
+
+	rej.Summary, err = m.services.Rejudge.Summary(ctx, rej.ID)
+	if err != nil {
+		return rej, errors.Wrap(err, "Rejudge solution manager")
+	}
+
+	return rej, nil
+}
@@ -0,0 +1,77 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/struct_errors"
+)
+
+// RunSolution executes the code against custom inputs without creating a
+// solution. Runs have their own rate limit and are executed in a separate lane,
+// so they never occupy solution workers.
+func (m *Manager) RunSolution(ctx context.Context, dto domain.RunSolutionDTO) ([]domain.RunResult, error) {
+	if err := m.judgeReady(ctx); err != nil {
+		return nil, errors.Wrap(err, "RunSolution solution manager")
+	}
+
+	if !m.runLimiter.Allow(dto.User.ID) {
+		return nil, errors.Wrap(domain.NewRunRateLimitError(), "RunSolution solution manager")
+	}
+
+	problem, err := m.services.ProblemManager.FullProblemByTaskID(ctx, dto.TaskID)
+	if err != nil {
+		return nil, errors.Wrap(err, "RunSolution solution manager")
+	}
+
+	tmpl := findTemplate(problem.TaskTemplates, dto.LanguageID)
+	if tmpl == nil {
+		err = struct_errors.NewBaseErr("Language is not supported by the task", nil)
+
+		return nil, errors.Wrap(err, "RunSolution solution manager")
+	}
+
+	lang, err := m.languageOptions(ctx, dto.LanguageID)
+	if err != nil {
+		return nil, errors.Wrap(err, "RunSolution solution manager")
+	}
+
+	select {
+	case m.runSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "RunSolution solution manager")
+	}
+	defer func() { <-m.runSlots }()
+
+	srcCode := dto.Code + tmpl.Wrapper
+	results := make([]domain.RunResult, 0, len(dto.Inputs))
+
+	for i := range dto.Inputs {
+		data := domain.CreateJudgeSubmission{
+			SourceCode:           srcCode,
+			LanguageID:           dto.LanguageID,
+			Stdin:                dto.Inputs[i],
+			CpuTimeLimit:         tmpl.Limits.RuntimeLimit,
+			MemoryLimit:          tmpl.Limits.MemoryLimit,
+			CompilerOptions:      lang.CompilerOptions,
+			CommandLineArguments: lang.CommandLineArguments,
+		}
+
+		info, err := m.services.Judge.CreateSubmission(ctx, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "RunSolution solution manager")
+		}
+
+		results = append(results, domain.RunResult{
+			Stdin:         dto.Inputs[i],
+			Stdout:        info.Stdout,
+			Stderr:        info.Stderr,
+			CompileOutput: info.CompileOutput,
+			Time:          info.Time,
+			Memory:        info.Memory,
+			Status:        info.Status,
+		})
+	}
+
+	return results, nil
+}
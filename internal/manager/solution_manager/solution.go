@@ -3,20 +3,35 @@ package solution_manager
 import (
 	"cmp"
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"lcode/config"
 	"lcode/internal/domain"
+	"lcode/internal/service/language"
+	"lcode/internal/service/rejudge"
 	"lcode/internal/service/solution"
+	solutionEvent "lcode/internal/service/solution_event"
+	solutionOutput "lcode/internal/service/solution_output"
+	solutionQueue "lcode/internal/service/solution_queue"
 	solutionResult "lcode/internal/service/solution_result"
 	"lcode/pkg/postgres"
+	"lcode/pkg/struct_errors"
 	"log"
 	"log/slog"
 	"slices"
+	"sync"
 	"time"
 )
 
 const (
-	workersCount = 8
+	queuePollInterval   = time.Second
+	maxSolutionAttempts = 3
+	maxWorkersCount     = 256
+	// releaseTimeout bounds waiting for workers interrupted on shutdown
+	releaseTimeout = time.Second * 5
+	// eventsListenRetryInterval is waited before listening to solution events
+	// again after the connection failed
+	eventsListenRetryInterval = time.Second * 5
 )
 
 type (
@@ -24,7 +39,13 @@ type (
 		ProblemManager ProblemManager
 		Solution       solution.Solution
 		SolutionResult solutionResult.SolutionResult
+		SolutionOutput solutionOutput.SolutionOutput
+		SolutionEvent  solutionEvent.SolutionEvent
+		SolutionQueue  solutionQueue.SolutionQueue
+		Rejudge        rejudge.Rejudge
+		Language       language.Language
 		Judge          Judge
+		JudgeNodes     JudgeNodes
 	}
 
 	Manager struct {
@@ -32,10 +53,26 @@ type (
 		logger             *slog.Logger
 		transactionManager *postgres.TransactionProvider
 		services           *Services
-		solutionQueue      *solutionQueue
-		workerCh           chan workerItem
-
-		availableStatuses []domain.JudgeStatusInfo
+		pool               *workerPool
+		wakeCh             chan struct{}
+		events             *eventBroker
+		runSlots           chan struct{}
+		runLimiter         *rateLimiter
+
+		// dispatchCtx is cancelled when shutdown starts, judgeCtx when
+		// shutdown deadline is exceeded and in-flight judging is abandoned
+		dispatchCtx    context.Context
+		stopDispatch   context.CancelFunc
+		dispatcherDone chan struct{}
+		watchdogDone   chan struct{}
+		prunerDone     chan struct{}
+		listenerDone   chan struct{}
+		judgeCtx       context.Context
+		cancelJudging  context.CancelFunc
+		// followUps are submissions of next test cases started by callbacks
+		followUps sync.WaitGroup
+
+		watchdog watchdogCounters
 	}
 )
 
@@ -45,141 +82,447 @@ func New(
 	transactionManager *postgres.TransactionProvider,
 	services *Services,
 ) *Manager {
-	statuses, err := services.Judge.GetAvailableStatuses(context.Background())
-	if err != nil {
-		log.Fatal("can not access judge api:", err.Error())
-	}
-
 	m := &Manager{
 		cfg:                cfg,
 		logger:             logger,
 		transactionManager: transactionManager,
 		services:           services,
-		solutionQueue:      newSolutionQueue(1000),
-		workerCh:           make(chan workerItem, workersCount), // todo: config value
-		availableStatuses:  statuses,
+		wakeCh:             make(chan struct{}, 1),
+		events:             newEventBroker(),
+		runSlots:           make(chan struct{}, cfg.JudgeConfig.RunWorkersCount),
+		runLimiter:         newRateLimiter(cfg.JudgeConfig.RunRateLimit, cfg.JudgeConfig.RunRateInterval),
+	}
+
+	err := m.recoverQueue(context.Background())
+	if err != nil {
+		log.Fatal("can not recover solution queue:", err.Error())
 	}
 
+	m.dispatchCtx, m.stopDispatch = context.WithCancel(context.Background())
+	m.judgeCtx, m.cancelJudging = context.WithCancel(context.Background())
+	m.dispatcherDone = make(chan struct{})
+	m.watchdogDone = make(chan struct{})
+	m.prunerDone = make(chan struct{})
+	m.listenerDone = make(chan struct{})
+
+	m.pool = newWorkerPool(m.judgeCtx, cfg.JudgeConfig.WorkersCount, m.judgeSolution)
+
 	go m.runWorkerManager()
+	go m.runWatchdog()
+	go m.runOutputPruner()
+	go m.runEventsListener()
+
+	return m
+}
 
-	for _ = range workersCount {
-		go func() {
-			for item := range m.workerCh {
-				m.solutionWorker(item)
-			}
-		}()
+// recoverQueue brings the persistent queue back to a consistent state after
+// restart: unclaimed solutions and solutions of this instance which exhausted
+// their attempts are marked as errored, claims left by a crash of this
+// instance are released and solutions stuck in testing status without a queue
+// item are enqueued again. Claims of other instances and solutions waiting for
+// judge callbacks are left to their owners and to the watchdog.
+func (m *Manager) recoverQueue(ctx context.Context) error {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "recoverQueue solution manager")
 	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
 
-	return m
+	exhausted, err := m.services.SolutionQueue.DeleteExhausted(ctx, m.cfg.JudgeConfig.InstanceID, maxSolutionAttempts)
+	if err != nil {
+		return errors.Wrap(err, "recoverQueue solution manager")
+	}
+
+	s := domain.SolutionStatusError
+	reason := domain.SolutionErrorReasonAttemptsExceeded
+	for i := range exhausted {
+		sol, err := m.services.Solution.Update(ctx, domain.UpdateSolutionDTO{
+			ID:          exhausted[i],
+			Status:      &s,
+			ErrorReason: &reason,
+		})
+		if err != nil {
+			return errors.Wrap(err, "recoverQueue solution manager")
+		}
+
+		err = m.services.Rejudge.FinishSolution(ctx, sol)
+		if err != nil {
+			return errors.Wrap(err, "recoverQueue solution manager")
+		}
+	}
+
+	// the previous process of this instance did not finish these solutions,
+	// so the attempts are counted
+	released, err := m.services.SolutionQueue.ReleaseOwned(ctx, m.cfg.JudgeConfig.InstanceID, false)
+	if err != nil {
+		return errors.Wrap(err, "recoverQueue solution manager")
+	}
+
+	orphaned, err := m.services.SolutionQueue.EnqueueOrphaned(ctx)
+	if err != nil {
+		return errors.Wrap(err, "recoverQueue solution manager")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "recoverQueue solution manager")
+	}
+
+	m.logger.Info(
+		"solution queue recovered",
+		slog.Int("exhausted", len(exhausted)),
+		slog.Int64("released", released),
+		slog.Int64("orphaned", orphaned),
+	)
+
+	return nil
+}
+
+func (m *Manager) wake() {
+	select {
+	case m.wakeCh <- struct{}{}:
+	default:
+	}
 }
 
 func (m *Manager) runWorkerManager() {
-	ticker := time.NewTicker(time.Millisecond * 10)
+	defer close(m.dispatcherDone)
+
+	ticker := time.NewTicker(queuePollInterval)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
-
-		sol, ok := m.solutionQueue.PopFront()
-		if !ok {
-			continue
+		for m.dispatchNext(m.dispatchCtx) {
 		}
 
-		ctx := context.Background()
+		select {
+		case <-m.dispatchCtx.Done():
+			return
+		case <-ticker.C:
+		case <-m.wakeCh:
+		}
+	}
+}
 
-		problem, err := m.services.ProblemManager.FullProblemByTaskID(ctx, sol.TaskID)
-		if err != nil {
-			m.logger.Error("can not find problem by task_id", slog.String("err", err.Error()))
-			continue
+// Shutdown stops taking solutions from the queue and waits for workers to
+// finish judging until ctx is done. Solutions which are still being judged
+// after that are released back to the queue and judged again after restart.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.stopDispatch()
+	m.events.Close()
+
+	for _, done := range []chan struct{}{m.dispatcherDone, m.watchdogDone, m.prunerDone, m.listenerDone} {
+		select {
+		case <-ctx.Done():
+		case <-done:
 		}
+	}
 
-		var tmpl *domain.TaskTemplate
+	followUpsDone := make(chan struct{})
+	go func() {
+		m.followUps.Wait()
+		close(followUpsDone)
+	}()
 
-		for i := range problem.TaskTemplates {
-			if problem.TaskTemplates[i].LanguageID == sol.LanguageID {
-				tmpl = &problem.TaskTemplates[i]
-				break
-			}
-		}
+	select {
+	case <-ctx.Done():
+	case <-followUpsDone:
+	}
 
-		if tmpl == nil {
-			m.logger.Error(
-				"template for user solution was not found in the task",
-				slog.String("solution_id", sol.Id),
-			)
+	m.pool.Resize(0)
 
-			s := domain.SolutionStatusError
-			_, err = m.services.Solution.Update(ctx, domain.UpdateSolutionDTO{
-				ID:     sol.Id,
-				Status: &s,
-			})
+	err := m.pool.Wait(ctx)
+	if err != nil {
+		m.logger.Warn("solution workers did not finish in time, judging is interrupted")
 
-			if err != nil {
-				m.logger.Error("can not update solution status to error", slog.String("err", err.Error()))
-			}
+		m.cancelJudging()
 
-			continue
-		}
+		releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+
+		_ = m.pool.Wait(releaseCtx)
+	}
+
+	// claims of workers which did not release them in time are released
+	// without counting the attempt, judging was interrupted rather than failed
+	released, err := m.services.SolutionQueue.ReleaseOwned(context.Background(), m.cfg.JudgeConfig.InstanceID, true)
+	if err != nil {
+		return errors.Wrap(err, "Shutdown solution manager")
+	}
+
+	m.logger.Info("solution workers stopped", slog.Int64("released", released))
+
+	return nil
+}
+
+// dispatchNext waits for an idle worker, claims the next queued solution and
+// hands it over to the worker. Solutions are claimed only when a worker can
+// take them, so claims do not age while workers are busy or the pool is
+// paused. It reports whether the queue should be polled again right away.
+func (m *Manager) dispatchNext(ctx context.Context) bool {
+	w, err := m.pool.Reserve(ctx)
+	if err != nil {
+		return false
+	}
+	// the worker is given back unless the solution is handed over to it
+	defer w.Free()
+
+	items, err := m.services.SolutionQueue.Claim(ctx, m.cfg.JudgeConfig.InstanceID, 1)
+	if err != nil {
+		m.logger.Error("can not claim solution from queue", slog.String("err", err.Error()))
+
+		return false
+	}
+
+	if len(items) == 0 {
+		return false
+	}
+
+	solutionID := items[0].SolutionID
+
+	if items[0].Attempts > maxSolutionAttempts {
+		m.logger.Error("solution exceeded judge attempts", slog.String("solution_id", solutionID))
+
+		m.failQueuedSolution(ctx, solutionID, domain.SolutionErrorReasonAttemptsExceeded)
+
+		return true
+	}
+
+	sol, err := m.services.Solution.SolutionByID(ctx, solutionID)
+	if err != nil {
+		m.logger.Error("can not find queued solution", slog.String("err", err.Error()))
+
+		m.releaseQueuedSolution(ctx, solutionID, false)
+
+		return false
+	}
+
+	problem, err := m.services.ProblemManager.FullProblemByTaskID(ctx, sol.TaskID)
+	if err != nil {
+		m.logger.Error("can not find problem by task_id", slog.String("err", err.Error()))
+
+		m.releaseQueuedSolution(ctx, solutionID, false)
+
+		return false
+	}
+
+	tmpl := findTemplate(problem.TaskTemplates, sol.LanguageID)
+	if tmpl == nil {
+		m.logger.Error(
+			"template for user solution was not found in the task",
+			slog.String("solution_id", sol.Id),
+		)
+
+		m.failQueuedSolution(ctx, solutionID, domain.SolutionErrorReasonNoTemplate)
 
-		item := workerItem{
-			solution:  sol,
-			task:      problem.Task,
-			template:  *tmpl,
-			testCases: problem.TestCases,
+		return true
+	}
+
+	lang, err := m.languageOptions(ctx, sol.LanguageID)
+	if err != nil {
+		m.logger.Error("can not find language of solution", slog.String("err", err.Error()))
+
+		m.releaseQueuedSolution(ctx, solutionID, false)
+
+		return false
+	}
+
+	testCases, err := m.pinnedTestCases(ctx, items[0], &problem)
+	if err != nil {
+		m.logger.Error("can not find test cases of solution", slog.String("err", err.Error()))
+
+		m.releaseQueuedSolution(ctx, solutionID, false)
+
+		return false
+	}
+
+	item := workerItem{
+		solution:  sol,
+		task:      problem.Task,
+		template:  *tmpl,
+		language:  lang,
+		testCases: testCases,
+	}
+
+	w.Assign(item)
+
+	return true
+}
+
+// languageOptions returns the language from the registry to take compiler
+// options and command line arguments from. Languages missing in the registry
+// are judged without them.
+func (m *Manager) languageOptions(ctx context.Context, id domain.LanguageType) (domain.Language, error) {
+	l, err := m.services.Language.GetByID(ctx, id)
+	if err == nil {
+		return l, nil
+	}
+
+	var notFoundErr *struct_errors.ErrNotFound
+	if errors.As(err, &notFoundErr) {
+		return domain.Language{ID: id}, nil
+	}
+
+	return l, errors.Wrap(err, "languageOptions solution manager")
+}
+
+func findTemplate(templates []domain.TaskTemplate, languageID domain.LanguageType) *domain.TaskTemplate {
+	for i := range templates {
+		if templates[i].LanguageID == languageID {
+			return &templates[i]
 		}
+	}
+
+	return nil
+}
+
+// pinnedTestCases returns test cases pinned to the queue item when the solution
+// was enqueued, so replacing test cases of the task does not change the test
+// set of solutions being judged. Items enqueued before pinning are judged on
+// current test cases of the problem.
+func (m *Manager) pinnedTestCases(
+	ctx context.Context,
+	item domain.SolutionQueueItem,
+	problem *domain.Problem,
+) ([]domain.TestCase, error) {
+	if item.TestCaseIDs == nil {
+		return problem.TestCases, nil
+	}
+
+	testCases, err := m.services.ProblemManager.TestCasesByIDs(ctx, item.TestCaseIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "pinnedTestCases solution manager")
+	}
+
+	return testCases, nil
+}
+
+// releaseQueuedSolution puts the solution back to the queue, interrupted
+// judging is not counted as an attempt.
+func (m *Manager) releaseQueuedSolution(ctx context.Context, solutionID string, interrupted bool) {
+	err := m.services.SolutionQueue.Release(ctx, solutionID, interrupted)
+	if err != nil {
+		m.logger.Error("can not release queued solution", slog.String("err", err.Error()))
+	}
+}
+
+// failQueuedSolution sets error status with the reason to the solution and
+// removes it from the queue.
+func (m *Manager) failQueuedSolution(ctx context.Context, solutionID string, reason domain.SolutionErrorReason) {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		m.logger.Error("can not create transaction", slog.String("err", err.Error()))
+
+		return
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	sol, err := m.failSolution(ctx, solutionID, reason)
+	if err != nil {
+		m.logger.Error("can not fail solution", slog.String("err", err.Error()))
+
+		return
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		m.logger.Error("can not commit transaction", slog.String("err", err.Error()))
+
+		return
+	}
+
+	m.publishFinished(sol)
+}
+
+// failSolution sets error status with the reason to the solution and removes
+// it from the queue, it is called in a transaction.
+func (m *Manager) failSolution(
+	ctx context.Context,
+	solutionID string,
+	reason domain.SolutionErrorReason,
+) (domain.Solution, error) {
+	s := domain.SolutionStatusError
+	sol, err := m.services.Solution.Update(ctx, domain.UpdateSolutionDTO{
+		ID:          solutionID,
+		Status:      &s,
+		ErrorReason: &reason,
+	})
+	if err != nil {
+		return sol, errors.Wrap(err, "failSolution solution manager")
+	}
+
+	err = m.services.Rejudge.FinishSolution(ctx, sol)
+	if err != nil {
+		return sol, errors.Wrap(err, "failSolution solution manager")
+	}
 
-		m.workerCh <- item
+	err = m.services.SolutionQueue.Delete(ctx, solutionID)
+	if err != nil {
+		return sol, errors.Wrap(err, "failSolution solution manager")
 	}
 
+	return sol, nil
 }
 
-func (m *Manager) solutionWorker(item workerItem) {
+func (m *Manager) solutionWorker(judgeCtx context.Context, item workerItem) {
 	baseCtx := context.Background()
 	solUpdateStatus := domain.SolutionStatusCompleted
+	var errorReason domain.SolutionErrorReason
 	sol := item.solution
-	task := &item.task
 	template := &item.template
+	lang := &item.language
 	testCases := item.testCases
 
-	solResults := make([]domain.SolutionResult, 0, len(testCases))
 	srcCode := sol.Code + template.Wrapper
 
-	for i := range testCases {
-		data := domain.CreateJudgeSubmission{
-			SourceCode:     srcCode,
-			LanguageID:     sol.LanguageID,
-			Stdin:          testCases[i].Input,
-			ExpectedOutput: testCases[i].Output,
-			CpuTimeLimit:   task.RuntimeLimit,
-			MemoryLimit:    task.MemoryLimit,
-		}
+	var solResults []domain.SolutionResult
+	var compileOutput string
 
-		info, err := m.createSubmission(baseCtx, data)
-		if err != nil {
-			solUpdateStatus = domain.SolutionStatusError
+	output, compiled, err := m.compile(judgeCtx, srcCode, sol.LanguageID, template, lang)
 
-			m.logger.Error("can not create submission", slog.String("err", err.Error()))
+	switch {
+	case err != nil:
+		// the judge failed, the error is handled below with judging errors
+	case !compiled:
+		solUpdateStatus = domain.SolutionStatusCompilationError
 
-			break
+		if output != nil {
+			compileOutput = *output
 		}
-
-		result := domain.SolutionResult{
-			SolutionID:      sol.Id,
-			TestCaseID:      testCases[i].ID,
-			SubmissionToken: info.Token,
-			Status:          info.Status,
-			Runtime:         info.Time,
-			Memory:          info.Memory,
-			Stdout:          info.Stdout,
-			Stderr:          info.Stderr,
+	case domain.JudgeSubmissionMode(m.cfg.JudgeConfig.SubmissionMode) == domain.JudgeSubmissionModeCallback &&
+		len(testCases) != 0:
+		err = m.judgeWithCallback(judgeCtx, &item)
+		if err == nil {
+			// results are sent by the judge to the callback
+			return
 		}
+	case domain.JudgeSubmissionMode(m.cfg.JudgeConfig.SubmissionMode) == domain.JudgeSubmissionModeBatch:
+		solResults, err = m.judgeBatch(judgeCtx, &item)
+	default:
+		solResults, err = m.judgeSequentially(judgeCtx, &item)
+	}
 
-		solResults = append(solResults, result)
+	// the solution is already released or failed by the watchdog
+	if errors.Is(context.Cause(judgeCtx), errSolutionStuck) {
+		m.logger.Warn("stuck solution judging abandoned", slog.String("solution_id", sol.Id))
 
-		if info.Status != domain.Accepted {
-			solUpdateStatus = domain.SolutionStatusError
-			break
-		}
+		return
+	}
+
+	// judging was interrupted by shutdown, the solution stays in the queue
+	if judgeCtx.Err() != nil {
+		m.logger.Warn("solution judging interrupted", slog.String("solution_id", sol.Id))
+
+		m.releaseQueuedSolution(baseCtx, sol.Id, true)
+
+		return
+	}
+
+	if err != nil {
+		solUpdateStatus = domain.SolutionStatusError
+		errorReason = judgeErrorReason(err)
+
+		m.logger.Error("can not judge solution", slog.String("err", err.Error()))
 	}
 
 	tx, err := m.transactionManager.NewTx(baseCtx, nil)
@@ -193,33 +536,53 @@ func (m *Manager) solutionWorker(item workerItem) {
 
 	// если не получилось, то пропускаем и меняем статус у solution на error
 
+	// results of previous judging are replaced when the solution is rejudged
+	err = m.services.SolutionResult.DeleteBySolutionID(ctx, sol.Id)
+	if err != nil {
+		m.logger.Error("can not delete previous solution results", slog.String("err", err.Error()))
+
+		return
+	}
+
 	if len(solResults) != 0 {
-		err = m.services.SolutionResult.CreateBatch(ctx, solResults...)
+		err = m.saveResults(ctx, solResults...)
 		if err != nil {
 			solUpdateStatus = domain.SolutionStatusError
+			errorReason = domain.SolutionErrorReasonInternal
 
 			m.logger.Error("can not create solution results", slog.String("err", err.Error()))
 		}
 	}
 
-	var maxRuntimeSolResult domain.SolutionResult
+	updateSolutionDTO := judgedSolution(sol.Id, solUpdateStatus, solResults, len(testCases))
+	updateSolutionDTO.ErrorReason = &errorReason
+	updateSolutionDTO.CompileOutput = &compileOutput
 
-	if len(solResults) != 0 {
-		maxRuntimeSolResult = slices.MaxFunc(solResults, func(a, b domain.SolutionResult) int {
-			return cmp.Compare(a.Runtime, b.Runtime)
-		})
+	updatedSol, err := m.services.Solution.Update(ctx, updateSolutionDTO)
+	if err != nil {
+		m.logger.Error("can not set status to solution", slog.String("err", err.Error()))
+
+		return
 	}
 
-	updateSolutionDTO := domain.UpdateSolutionDTO{
-		ID:      sol.Id,
-		Status:  &solUpdateStatus,
-		Runtime: &maxRuntimeSolResult.Runtime,
-		Memory:  &maxRuntimeSolResult.Memory,
+	err = m.services.Rejudge.FinishSolution(ctx, updatedSol)
+	if err != nil {
+		m.logger.Error("can not finish rejudge of solution", slog.String("err", err.Error()))
+
+		return
 	}
 
-	_, err = m.services.Solution.Update(ctx, updateSolutionDTO)
+	err = m.services.SolutionQueue.Delete(ctx, sol.Id)
 	if err != nil {
-		m.logger.Error("can not set status to solution", slog.String("err", err.Error()))
+		m.logger.Error("can not delete solution from queue", slog.String("err", err.Error()))
+
+		return
+	}
+
+	// the queue item is locked by the watchdog until it commits, so judging
+	// abandoned meanwhile is noticed here
+	if errors.Is(context.Cause(judgeCtx), errSolutionStuck) {
+		m.logger.Warn("stuck solution judging abandoned", slog.String("solution_id", sol.Id))
 
 		return
 	}
@@ -229,31 +592,110 @@ func (m *Manager) solutionWorker(item workerItem) {
 
 		return
 	}
+
+	m.publishFinished(updatedSol)
 }
 
-func (m *Manager) createSubmission(
+// newSubmissions returns submissions of the solution for the test cases.
+// Inputs of file backed test cases are read only here, for the few test cases
+// which are about to be submitted, and are dropped with the submissions.
+func (m *Manager) newSubmissions(
 	ctx context.Context,
-	data domain.CreateJudgeSubmission,
-) (info domain.JudgeSubmissionInfo, err error) {
-	for {
-		info, err = m.services.Judge.CreateSubmission(ctx, data)
-		var queueIsFullError *domain.JudgeQueueIsFullError
+	item *workerItem,
+	testCases []domain.TestCase,
+) ([]domain.CreateJudgeSubmission, error) {
+	loaded := slices.Clone(testCases)
 
-		if errors.As(err, &queueIsFullError) {
-			time.Sleep(time.Millisecond * 100)
-			continue
-		} else if err != nil {
-			return domain.JudgeSubmissionInfo{}, errors.Wrap(err, "createSubmission solution manager")
-		}
+	err := m.services.ProblemManager.LoadTestCaseInputs(ctx, loaded)
+	if err != nil {
+		return nil, errors.Wrap(err, "newSubmissions solution manager")
+	}
+
+	submissions := make([]domain.CreateJudgeSubmission, 0, len(loaded))
+
+	for i := range loaded {
+		submissions = append(submissions, newSubmission(&item.solution, &item.template, &item.language, &loaded[i]))
+	}
+
+	return submissions, nil
+}
+
+// newSubmission returns submission of the solution code wrapped by the
+// template for the test case.
+func newSubmission(
+	sol *domain.Solution,
+	template *domain.TaskTemplate,
+	lang *domain.Language,
+	testCase *domain.TestCase,
+) domain.CreateJudgeSubmission {
+	return domain.CreateJudgeSubmission{
+		SourceCode:           sol.Code + template.Wrapper,
+		LanguageID:           sol.LanguageID,
+		Stdin:                testCase.Input,
+		CpuTimeLimit:         template.Limits.RuntimeLimit,
+		MemoryLimit:          template.Limits.MemoryLimit,
+		CompilerOptions:      lang.CompilerOptions,
+		CommandLineArguments: lang.CommandLineArguments,
+	}
+}
+
+// judgedSolution returns update of the solution judged with the results:
+// score and runtime and memory of the slowest test case. The solution gets
+// error status unless every result is accepted.
+func judgedSolution(
+	solutionID string,
+	status domain.SolutionStatus,
+	results []domain.SolutionResult,
+	total int,
+) domain.UpdateSolutionDTO {
+	passedCount, score := solutionScore(results, total)
+
+	if passedCount != len(results) {
+		status = domain.SolutionStatusError
+	}
+
+	var maxRuntimeResult domain.SolutionResult
+
+	if len(results) != 0 {
+		maxRuntimeResult = slices.MaxFunc(results, func(a, b domain.SolutionResult) int {
+			return cmp.Compare(a.Runtime, b.Runtime)
+		})
+	}
 
-		return info, nil
+	return domain.UpdateSolutionDTO{
+		ID:          solutionID,
+		Status:      &status,
+		Runtime:     &maxRuntimeResult.Runtime,
+		Memory:      &maxRuntimeResult.Memory,
+		PassedCount: &passedCount,
+		TotalCount:  &total,
+		Score:       &score,
 	}
 }
 
+// judgeErrorReason tells why the solution could not be judged.
+func judgeErrorReason(err error) domain.SolutionErrorReason {
+	var unavailableErr *domain.JudgeUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return domain.SolutionErrorReasonJudgeUnavailable
+	}
+
+	var queueIsFullErr *domain.JudgeQueueIsFullError
+	if errors.As(err, &queueIsFullErr) {
+		return domain.SolutionErrorReasonJudgeOverloaded
+	}
+
+	return domain.SolutionErrorReasonInternal
+}
+
 func (m *Manager) CreateSolution(
 	ctx context.Context,
 	dto domain.CreateSolutionDTO,
 ) (sol domain.Solution, err error) {
+	if err = m.judgeReady(ctx); err != nil {
+		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
+	}
+
 	tx, err := m.transactionManager.NewTx(ctx, nil)
 	if err != nil {
 		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
@@ -266,6 +708,7 @@ func (m *Manager) CreateSolution(
 		LanguageID: dto.LanguageID,
 		Code:       dto.Code,
 		Status:     domain.SolutionStatusTesting,
+		Validation: dto.Validation,
 		User:       dto.User,
 	}
 
@@ -274,11 +717,52 @@ func (m *Manager) CreateSolution(
 		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
 	}
 
-	err = m.solutionQueue.PushBack(sol)
+	// concurrent submissions wait here till this one is pushed or rolled back
+	err = m.services.SolutionQueue.LockLimits(ctx)
+	if err != nil {
+		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
+	}
+
+	queueSize, err := m.services.SolutionQueue.Count(ctx)
+	if err != nil {
+		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
+	}
+
+	if queueSize >= m.cfg.JudgeConfig.QueueMaxSize {
+		return domain.Solution{}, errors.Wrap(domain.NewSolutionQueueIsFullError(), "CreateSolution solution manager")
+	}
+
+	// validation runs are checks of tasks, they are not limited and go apart
+	// from users traffic, other solutions of admins are judged as any user's
+	lane := domain.SolutionQueueLaneUser
+	if dto.Validation {
+		lane = domain.SolutionQueueLaneValidation
+	}
+
+	if lane == domain.SolutionQueueLaneUser {
+		userQueueSize, err := m.services.SolutionQueue.CountByUser(ctx, dto.User.ID, lane)
+		if err != nil {
+			return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
+		}
+
+		if userQueueSize >= m.cfg.JudgeConfig.UserQueueMaxSize {
+			err = domain.NewSolutionUserQueueLimitError(m.cfg.JudgeConfig.UserQueueMaxSize)
+
+			return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
+		}
+	}
+
+	err = m.services.SolutionQueue.Push(ctx, domain.PushSolutionQueueEntity{
+		SolutionID: sol.Id,
+		UserID:     dto.User.ID,
+		Lane:       lane,
+	})
 	if err != nil {
 		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
 	}
 
+	tx.AfterSuccess(ctx, m.wake)
+
 	if err = tx.Commit(ctx); err != nil {
 		return domain.Solution{}, errors.Wrap(err, "CreateSolution solution manager")
 	}
@@ -286,6 +770,247 @@ func (m *Manager) CreateSolution(
 	return sol, nil
 }
 
-func (m *Manager) GetAvailableSolutionStatuses() ([]domain.JudgeStatusInfo, error) {
-	return m.availableStatuses, nil
+// SubscribeSolutionEvents returns channel with judging progress of the solution
+// and function which must be called when the subscriber is gone.
+func (m *Manager) SubscribeSolutionEvents(solutionID string) (<-chan domain.SolutionEvent, func()) {
+	return m.events.Subscribe(solutionID)
+}
+
+func (m *Manager) publishTestCaseResult(number int, result domain.SolutionResult) {
+	m.publish(result.SolutionID, domain.SolutionEvent{
+		Type: domain.SolutionEventTestCase,
+		TestCase: &domain.SolutionTestCaseEvent{
+			Number:     number,
+			TestCaseID: result.TestCaseID,
+			Status:     result.Status,
+			Runtime:    result.Runtime,
+			Memory:     result.Memory,
+		},
+	})
+}
+
+// publishFinished announces that the solution is judged, subscribers read the
+// solution themselves.
+func (m *Manager) publishFinished(sol domain.Solution) {
+	m.publish(sol.Id, domain.SolutionEvent{
+		Type: domain.SolutionEventFinished,
+	})
+}
+
+// publish sends the event to subscribers connected to every instance. When the
+// event can not be sent through the database, subscribers of this instance get
+// it directly, others see the final status on keepalive.
+func (m *Manager) publish(solutionID string, event domain.SolutionEvent) {
+	err := m.services.SolutionEvent.Publish(context.Background(), domain.SolutionEventNotification{
+		SolutionID: solutionID,
+		Event:      event,
+	})
+	if err != nil {
+		m.logger.Error("can not publish solution event", slog.String("err", err.Error()))
+
+		m.events.Publish(solutionID, event)
+	}
+}
+
+// runEventsListener passes events published by every instance to subscribers
+// of this one till shutdown.
+func (m *Manager) runEventsListener() {
+	defer close(m.listenerDone)
+
+	for {
+		err := m.services.SolutionEvent.Listen(m.dispatchCtx, func(n domain.SolutionEventNotification) {
+			m.events.Publish(n.SolutionID, n.Event)
+		})
+		if m.dispatchCtx.Err() != nil {
+			return
+		}
+
+		m.logger.Error("can not listen to solution events", slog.String("err", err.Error()))
+
+		select {
+		case <-m.dispatchCtx.Done():
+			return
+		case <-time.After(eventsListenRetryInterval):
+		}
+	}
+}
+
+// SolutionResults returns results of the solution, data of hidden test cases
+// is redacted for users other than admins.
+func (m *Manager) SolutionResults(
+	ctx context.Context,
+	dto domain.GetSolutionResultsDTO,
+) ([]domain.SolutionResult, error) {
+	results, err := m.services.SolutionResult.ResultsBySolutionID(ctx, dto.SolutionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "SolutionResults solution manager")
+	}
+
+	if !dto.User.IsAdmin {
+		for i := range results {
+			results[i].Redact()
+		}
+	}
+
+	err = m.loadResultInputs(ctx, results, dto.User.IsAdmin)
+	if err != nil {
+		return nil, errors.Wrap(err, "SolutionResults solution manager")
+	}
+
+	return results, nil
+}
+
+// loadResultInputs reads inputs of file backed test cases which are shown
+// with the results, they are cut like samples on the problem page.
+func (m *Manager) loadResultInputs(ctx context.Context, results []domain.SolutionResult, isAdmin bool) error {
+	testCases := make([]domain.TestCase, 0, len(results))
+	positions := make([]int, 0, len(results))
+
+	for i := range results {
+		if !results[i].FileBacked || (!isAdmin && results[i].Visibility != domain.TestCaseVisibilitySample) {
+			continue
+		}
+
+		testCases = append(testCases, domain.TestCase{
+			ID:         results[i].TestCaseID,
+			TaskID:     results[i].TaskID,
+			FileBacked: true,
+		})
+		positions = append(positions, i)
+	}
+
+	err := m.services.ProblemManager.LoadTestCaseSamples(ctx, testCases)
+	if err != nil {
+		return errors.Wrap(err, "loadResultInputs solution manager")
+	}
+
+	for i := range testCases {
+		results[positions[i]].Input = &testCases[i].Input
+	}
+
+	return nil
+}
+
+func (m *Manager) QueueStats(ctx context.Context) (domain.SolutionQueueStats, error) {
+	counts, err := m.services.SolutionQueue.Counts(ctx)
+	if err != nil {
+		return domain.SolutionQueueStats{}, errors.Wrap(err, "QueueStats solution manager")
+	}
+
+	workers := m.pool.States()
+	inFlight := make([]string, 0, len(workers))
+
+	for i := range workers {
+		if workers[i].SolutionID != nil {
+			inFlight = append(inFlight, *workers[i].SolutionID)
+		}
+	}
+
+	stats := domain.SolutionQueueStats{
+		Depth:        counts.Waiting,
+		Claimed:      counts.Claimed,
+		InFlight:     inFlight,
+		WorkersCount: m.pool.Size(),
+		Workers:      workers,
+		Watchdog:     m.watchdog.Stats(),
+		Judges:       m.services.JudgeNodes.Nodes(),
+	}
+
+	return stats, nil
+}
+
+func (m *Manager) QueuedSolutions(
+	ctx context.Context,
+	dto domain.GetQueuedSolutionsDTO,
+) (list domain.QueuedSolutionList, err error) {
+	list.Solutions, err = m.services.SolutionQueue.List(ctx, dto.Pagination)
+	if err != nil {
+		return list, errors.Wrap(err, "QueuedSolutions solution manager")
+	}
+
+	if len(list.Solutions) != 0 {
+		list.Pagination.AfterID = list.Solutions[len(list.Solutions)-1].SolutionID
+	}
+
+	return list, nil
+}
+
+// CancelQueuedSolution removes the solution which is not taken by a worker yet
+// from the queue and sets cancelled status to it.
+func (m *Manager) CancelQueuedSolution(
+	ctx context.Context,
+	dto domain.CancelQueuedSolutionDTO,
+) (sol domain.Solution, err error) {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return sol, errors.Wrap(err, "CancelQueuedSolution solution manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	err = m.services.SolutionQueue.DeleteWaiting(ctx, dto.SolutionID)
+	if err != nil {
+		return sol, errors.Wrap(err, "CancelQueuedSolution solution manager")
+	}
+
+	status := domain.SolutionStatusCancelled
+	sol, err = m.services.Solution.Update(ctx, domain.UpdateSolutionDTO{ID: dto.SolutionID, Status: &status})
+	if err != nil {
+		return sol, errors.Wrap(err, "CancelQueuedSolution solution manager")
+	}
+
+	err = m.services.Rejudge.FinishSolution(ctx, sol)
+	if err != nil {
+		return sol, errors.Wrap(err, "CancelQueuedSolution solution manager")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return sol, errors.Wrap(err, "CancelQueuedSolution solution manager")
+	}
+
+	m.publishFinished(sol)
+
+	return sol, nil
+}
+
+// ResizeWorkerPool changes count of solution workers, count 0 pauses judging:
+// solutions are claimed only by idle workers, so they stay queued meanwhile.
+func (m *Manager) ResizeWorkerPool(ctx context.Context, dto domain.ResizeWorkerPoolDTO) (domain.SolutionQueueStats, error) {
+	if dto.Count < 0 || dto.Count > maxWorkersCount {
+		err := struct_errors.NewBaseErr(fmt.Sprintf("Workers count must be from 0 to %d", maxWorkersCount), nil)
+
+		return domain.SolutionQueueStats{}, errors.Wrap(err, "ResizeWorkerPool solution manager")
+	}
+
+	m.pool.Resize(dto.Count)
+
+	m.logger.Info("solution workers pool resized", slog.Int("count", dto.Count))
+
+	stats, err := m.QueueStats(ctx)
+	if err != nil {
+		return stats, errors.Wrap(err, "ResizeWorkerPool solution manager")
+	}
+
+	return stats, nil
+}
+
+// GetAvailableSolutionStatuses returns statuses discovered from the judge, they
+// are fetched on first use so the API starts while the judge is down.
+func (m *Manager) GetAvailableSolutionStatuses(ctx context.Context) ([]domain.JudgeStatusInfo, error) {
+	ss, err := m.services.Judge.GetAvailableStatuses(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailableSolutionStatuses solution manager")
+	}
+
+	return ss, nil
+}
+
+// judgeReady refuses submissions while the API works in degraded mode: the
+// judge capabilities were never discovered, so it is unreachable since start.
+func (m *Manager) judgeReady(ctx context.Context) error {
+	if _, err := m.services.Judge.GetAvailableStatuses(ctx); err != nil {
+		return errors.Wrap(err, "judgeReady solution manager")
+	}
+
+	return nil
 }
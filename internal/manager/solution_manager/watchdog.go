@@ -0,0 +1,154 @@
+package solution_manager
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+	"lcode/pkg/postgres"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// errSolutionStuck is the cause of cancellation of judging abandoned by the
+// watchdog.
+var errSolutionStuck = errors.New("solution judging is stuck")
+
+type watchdogCounters struct {
+	panics   atomic.Int64
+	retried  atomic.Int64
+	timedOut atomic.Int64
+	orphaned atomic.Int64
+}
+
+func (c *watchdogCounters) Stats() domain.WatchdogStats {
+	return domain.WatchdogStats{
+		Panics:   c.panics.Load(),
+		Retried:  c.retried.Load(),
+		TimedOut: c.timedOut.Load(),
+		Orphaned: c.orphaned.Load(),
+	}
+}
+
+// judgeSolution runs the solution worker and recovers its panic. The solution
+// gets error status then, judging it again would most likely crash again.
+func (m *Manager) judgeSolution(ctx context.Context, item workerItem) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		m.watchdog.panics.Add(1)
+
+		m.logger.Error(
+			"solution worker panicked",
+			slog.String("solution_id", item.solution.Id),
+			slog.Any("panic", r),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		m.failQueuedSolution(context.Background(), item.solution.Id, domain.SolutionErrorReasonWorkerPanic)
+	}()
+
+	m.solutionWorker(ctx, item)
+}
+
+func (m *Manager) runWatchdog() {
+	defer close(m.watchdogDone)
+
+	ticker := time.NewTicker(m.cfg.JudgeConfig.WatchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.dispatchCtx.Done():
+			return
+		case <-ticker.C:
+			err := m.checkStuckSolutions(m.dispatchCtx)
+			if err != nil && m.dispatchCtx.Err() == nil {
+				m.logger.Error("can not check stuck solutions", slog.String("err", err.Error()))
+			}
+		}
+	}
+}
+
+// checkStuckSolutions abandons judging of solutions claimed longer than
+// StuckDeadline ago. They are judged again while they have attempts left and
+// get error status otherwise. Solutions in testing status which were lost by
+// the queue are pushed back to it.
+func (m *Manager) checkStuckSolutions(ctx context.Context) error {
+	tx, err := m.transactionManager.NewTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "checkStuckSolutions solution manager")
+	}
+	ctx = context.WithValue(ctx, postgres.TxKey{}, tx)
+	defer tx.Rollback(ctx)
+
+	stuck, err := m.services.SolutionQueue.ReleaseStuck(ctx, m.cfg.JudgeConfig.StuckDeadline)
+	if err != nil {
+		return errors.Wrap(err, "checkStuckSolutions solution manager")
+	}
+
+	retried := 0
+	failed := make([]domain.Solution, 0, len(stuck))
+
+	for _, item := range stuck {
+		abandoned := m.pool.Abandon(item.SolutionID, errSolutionStuck)
+
+		if item.Attempts < maxSolutionAttempts {
+			retried++
+
+			m.logger.Warn(
+				"stuck solution is released to be judged again",
+				slog.String("solution_id", item.SolutionID),
+				slog.Int("attempts", item.Attempts),
+				slog.Bool("abandoned", abandoned),
+			)
+
+			continue
+		}
+
+		sol, err := m.failSolution(ctx, item.SolutionID, domain.SolutionErrorReasonJudgingTimeout)
+		if err != nil {
+			return errors.Wrap(err, "checkStuckSolutions solution manager")
+		}
+
+		m.logger.Warn(
+			"stuck solution exceeded judge attempts",
+			slog.String("solution_id", item.SolutionID),
+			slog.Int("attempts", item.Attempts),
+			slog.Bool("abandoned", abandoned),
+		)
+
+		failed = append(failed, sol)
+	}
+
+	orphaned, err := m.services.SolutionQueue.EnqueueOrphaned(ctx)
+	if err != nil {
+		return errors.Wrap(err, "checkStuckSolutions solution manager")
+	}
+
+	if retried > 0 || orphaned > 0 {
+		tx.AfterSuccess(ctx, m.wake)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "checkStuckSolutions solution manager")
+	}
+
+	m.watchdog.retried.Add(int64(retried))
+	m.watchdog.timedOut.Add(int64(len(failed)))
+	m.watchdog.orphaned.Add(orphaned)
+
+	if orphaned > 0 {
+		m.logger.Warn("orphaned solutions are pushed back to the queue", slog.Int64("solutions", orphaned))
+	}
+
+	for i := range failed {
+		m.publishFinished(failed[i])
+	}
+
+	return nil
+}
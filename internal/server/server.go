@@ -10,6 +10,7 @@ import (
 	"lcode/internal/handler/http/article"
 	"lcode/internal/handler/http/auth"
 	"lcode/internal/handler/http/comment"
+	"lcode/internal/handler/http/language"
 	"lcode/internal/handler/http/problem"
 	"lcode/internal/handler/http/solution"
 	userProgress "lcode/internal/handler/http/user_progress"
@@ -88,6 +89,8 @@ func NewServer(
 	})
 
 	// http handlers
+	h.HTTP.Health.Register(router)
+
 	h.HTTP.Auth.Register(
 		&auth.Middlewares{
 			Access: middlewares.Access,
@@ -125,6 +128,7 @@ func NewServer(
 	h.HTTP.Solution.Register(
 		&solution.Middlewares{
 			Access:   middlewares.Access,
+			Auth:     middlewares.Auth,
 			Solution: middlewares.Solution,
 		},
 		router,
@@ -138,6 +142,15 @@ func NewServer(
 		router,
 	)
 
+	h.HTTP.Language.Register(
+		&language.Middlewares{
+			Access:   middlewares.Access,
+			Auth:     middlewares.Auth,
+			Language: middlewares.Language,
+		},
+		router,
+	)
+
 	return &Server{
 		config:    config,
 		GinRouter: router,
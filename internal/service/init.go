@@ -6,11 +6,19 @@ import (
 	"lcode/internal/service/article"
 	"lcode/internal/service/auth"
 	"lcode/internal/service/comment"
+	"lcode/internal/service/language"
+	"lcode/internal/service/plagiarism"
+	"lcode/internal/service/rejudge"
 	"lcode/internal/service/solution"
+	solutionEvent "lcode/internal/service/solution_event"
+	solutionOutput "lcode/internal/service/solution_output"
+	solutionQueue "lcode/internal/service/solution_queue"
 	solutionResult "lcode/internal/service/solution_result"
+	solutionStats "lcode/internal/service/solution_stats"
 	"lcode/internal/service/task"
 	taskTemplate "lcode/internal/service/task_template"
 	testCase "lcode/internal/service/test_case"
+	"lcode/internal/service/test_case_fs"
 	"lcode/internal/service/thumbnails"
 	"lcode/internal/service/user_fs"
 	userProgress "lcode/internal/service/user_progress"
@@ -27,6 +35,7 @@ type (
 
 	Services struct {
 		UserFS         user_fs.UserFS
+		TestCaseFS     test_case_fs.TestCaseFS
 		Thumbnails     thumbnails.Thumbnails
 		Auth           auth.Authorization
 		Task           task.Task
@@ -34,9 +43,16 @@ type (
 		TestCase       testCase.TestCase
 		Solution       solution.Solution
 		SolutionResult solutionResult.SolutionResult
+		SolutionOutput solutionOutput.SolutionOutput
+		SolutionEvent  solutionEvent.SolutionEvent
+		SolutionQueue  solutionQueue.SolutionQueue
 		UserProgress   userProgress.UserProgress
 		Article        article.Article
 		Comment        comment.Comment
+		Rejudge        rejudge.Rejudge
+		Language       language.Language
+		SolutionStats  solutionStats.SolutionStats
+		Plagiarism     plagiarism.Plagiarism
 	}
 )
 
@@ -46,26 +62,42 @@ func New(p *InitParams, repos *repository.Repositories) *Services {
 	taskTemplateService := taskTemplate.New(p.Logger, repos.TaskTemplate)
 	testCaseService := testCase.New(p.Logger, repos.TestCase)
 	solutionResultService := solutionResult.New(p.Config, repos.SolutionResult)
+	solutionOutputService := solutionOutput.New(p.Config, repos.SolutionOutput)
+	solutionEventService := solutionEvent.New(repos.SolutionEvent)
 	solutionService := solution.New(p.Config, repos.Solution)
+	solutionQueueService := solutionQueue.New(p.Config, repos.SolutionQueue)
 	userProgressService := userProgress.New(p.Logger, repos.UserProgress)
 	articleService := article.New(p.Logger, p.TransactionManager, repos.Article)
 	commentService := comment.New(p.Logger, p.TransactionManager, repos.Comment)
+	rejudgeService := rejudge.New(p.Config, repos.Rejudge)
+	languageService := language.New(p.Config, repos.Language)
+	solutionStatsService := solutionStats.New(p.Config, repos.SolutionStats)
+	plagiarismService := plagiarism.New(p.Config, repos.Plagiarism)
 	thumbnailsService := thumbnails.New(p.Config, p.Logger)
 	userFsService := user_fs.New(p.Config, p.Logger, &user_fs.Services{
 		Thumbnails: thumbnailsService,
 	})
+	testCaseFsService := test_case_fs.New(p.Config, p.Logger)
 
 	return &Services{
 		Thumbnails:     thumbnailsService,
 		UserFS:         userFsService,
+		TestCaseFS:     testCaseFsService,
 		Auth:           authService,
 		Task:           taskService,
 		TaskTemplate:   taskTemplateService,
 		TestCase:       testCaseService,
 		Solution:       solutionService,
 		SolutionResult: solutionResultService,
+		SolutionOutput: solutionOutputService,
+		SolutionEvent:  solutionEventService,
+		SolutionQueue:  solutionQueueService,
 		UserProgress:   userProgressService,
 		Article:        articleService,
 		Comment:        commentService,
+		Rejudge:        rejudgeService,
+		Language:       languageService,
+		SolutionStats:  solutionStatsService,
+		Plagiarism:     plagiarismService,
 	}
 }
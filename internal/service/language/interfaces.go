@@ -0,0 +1,22 @@
+package language
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	Language interface {
+		Create(ctx context.Context, entity domain.CreateLanguageEntity) (domain.Language, error)
+		Update(ctx context.Context, dto domain.LanguageUpdateDTO) (domain.Language, error)
+		GetByID(ctx context.Context, id domain.LanguageType) (domain.Language, error)
+		GetAll(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error)
+	}
+
+	LanguageRepo interface {
+		Create(ctx context.Context, entity domain.CreateLanguageEntity) (domain.Language, error)
+		Update(ctx context.Context, dto domain.LanguageUpdateDTO) (domain.Language, error)
+		GetByID(ctx context.Context, id domain.LanguageType) (domain.Language, error)
+		GetAll(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error)
+	}
+)
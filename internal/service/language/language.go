@@ -0,0 +1,58 @@
+package language
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository LanguageRepo
+	}
+)
+
+func New(conf *config.Config, repository LanguageRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, entity domain.CreateLanguageEntity) (domain.Language, error) {
+	l, err := s.repository.Create(ctx, entity)
+	if err != nil {
+		return l, errors.Wrap(err, "Create language service")
+	}
+
+	return l, nil
+}
+
+func (s *Service) Update(ctx context.Context, dto domain.LanguageUpdateDTO) (domain.Language, error) {
+	l, err := s.repository.Update(ctx, dto)
+	if err != nil {
+		return l, errors.Wrap(err, "Update language service")
+	}
+
+	return l, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id domain.LanguageType) (domain.Language, error) {
+	l, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return l, errors.Wrap(err, "GetByID language service")
+	}
+
+	return l, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, dto domain.LanguagesDTO) ([]domain.Language, error) {
+	ls, err := s.repository.GetAll(ctx, dto)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAll language service")
+	}
+
+	return ls, nil
+}
@@ -0,0 +1,29 @@
+package plagiarism
+
+import (
+	"context"
+	"lcode/internal/domain"
+	"time"
+)
+
+type (
+	Plagiarism interface {
+		Create(ctx context.Context, entity domain.CreatePlagiarismCheckEntity) (domain.PlagiarismCheck, error)
+		Finish(ctx context.Context, entity domain.FinishPlagiarismCheckEntity) error
+		Heartbeat(ctx context.Context, id string) error
+		FailRunning(ctx context.Context, reason string, staleAfter time.Duration) (int64, error)
+		AddPairs(ctx context.Context, checkID string, pairs []domain.PlagiarismPair) error
+		GetByID(ctx context.Context, dto domain.GetPlagiarismCheckDTO) (domain.PlagiarismCheck, error)
+		Pairs(ctx context.Context, checkID string) ([]domain.PlagiarismPair, error)
+	}
+
+	PlagiarismRepo interface {
+		Create(ctx context.Context, entity domain.CreatePlagiarismCheckEntity) (domain.PlagiarismCheck, error)
+		Finish(ctx context.Context, entity domain.FinishPlagiarismCheckEntity) error
+		Heartbeat(ctx context.Context, id string) error
+		FailRunning(ctx context.Context, reason string, staleAfter time.Duration) (int64, error)
+		AddPairs(ctx context.Context, checkID string, pairs []domain.PlagiarismPair) error
+		GetByID(ctx context.Context, dto domain.GetPlagiarismCheckDTO) (domain.PlagiarismCheck, error)
+		Pairs(ctx context.Context, checkID string) ([]domain.PlagiarismPair, error)
+	}
+)
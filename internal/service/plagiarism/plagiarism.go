@@ -0,0 +1,89 @@
+package plagiarism
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"time"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository PlagiarismRepo
+	}
+)
+
+func New(conf *config.Config, repository PlagiarismRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+func (s *Service) Create(
+	ctx context.Context,
+	entity domain.CreatePlagiarismCheckEntity,
+) (domain.PlagiarismCheck, error) {
+	check, err := s.repository.Create(ctx, entity)
+	if err != nil {
+		return check, errors.Wrap(err, "Create plagiarism service")
+	}
+
+	return check, nil
+}
+
+func (s *Service) Finish(ctx context.Context, entity domain.FinishPlagiarismCheckEntity) error {
+	err := s.repository.Finish(ctx, entity)
+	if err != nil {
+		return errors.Wrap(err, "Finish plagiarism service")
+	}
+
+	return nil
+}
+
+func (s *Service) Heartbeat(ctx context.Context, id string) error {
+	err := s.repository.Heartbeat(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "Heartbeat plagiarism service")
+	}
+
+	return nil
+}
+
+func (s *Service) FailRunning(ctx context.Context, reason string, staleAfter time.Duration) (int64, error) {
+	count, err := s.repository.FailRunning(ctx, reason, staleAfter)
+	if err != nil {
+		return 0, errors.Wrap(err, "FailRunning plagiarism service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) AddPairs(ctx context.Context, checkID string, pairs []domain.PlagiarismPair) error {
+	err := s.repository.AddPairs(ctx, checkID, pairs)
+	if err != nil {
+		return errors.Wrap(err, "AddPairs plagiarism service")
+	}
+
+	return nil
+}
+
+func (s *Service) GetByID(ctx context.Context, dto domain.GetPlagiarismCheckDTO) (domain.PlagiarismCheck, error) {
+	check, err := s.repository.GetByID(ctx, dto)
+	if err != nil {
+		return check, errors.Wrap(err, "GetByID plagiarism service")
+	}
+
+	return check, nil
+}
+
+func (s *Service) Pairs(ctx context.Context, checkID string) ([]domain.PlagiarismPair, error) {
+	pairs, err := s.repository.Pairs(ctx, checkID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Pairs plagiarism service")
+	}
+
+	return pairs, nil
+}
@@ -0,0 +1,25 @@
+package rejudge
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	Rejudge interface {
+		Create(ctx context.Context, entity domain.CreateRejudgeEntity) (domain.Rejudge, error)
+		AddSolutions(ctx context.Context, rej domain.Rejudge) (int64, error)
+		GetByID(ctx context.Context, dto domain.GetRejudgeDTO) (domain.Rejudge, error)
+		Summary(ctx context.Context, rejudgeID string) (domain.RejudgeSummary, error)
+		FinishSolution(ctx context.Context, sol domain.Solution) error
+	}
+
+	RejudgeRepo interface {
+		Create(ctx context.Context, entity domain.CreateRejudgeEntity) (domain.Rejudge, error)
+		AddSolutions(ctx context.Context, rej domain.Rejudge) (int64, error)
+		GetByID(ctx context.Context, dto domain.GetRejudgeDTO) (domain.Rejudge, error)
+		Summary(ctx context.Context, rejudgeID string) (domain.RejudgeSummary, error)
+		Transitions(ctx context.Context, rejudgeID string) ([]domain.RejudgeTransition, error)
+		FinishSolution(ctx context.Context, sol domain.Solution) error
+	}
+)
@@ -0,0 +1,76 @@
+package rejudge
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository RejudgeRepo
+	}
+)
+
+func New(conf *config.Config, repository RejudgeRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, entity domain.CreateRejudgeEntity) (domain.Rejudge, error) {
+	rej, err := s.repository.Create(ctx, entity)
+	if err != nil {
+		return rej, errors.Wrap(err, "Create rejudge service")
+	}
+
+	return rej, nil
+}
+
+func (s *Service) AddSolutions(ctx context.Context, rej domain.Rejudge) (int64, error) {
+	count, err := s.repository.AddSolutions(ctx, rej)
+	if err != nil {
+		return 0, errors.Wrap(err, "AddSolutions rejudge service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) GetByID(ctx context.Context, dto domain.GetRejudgeDTO) (domain.Rejudge, error) {
+	rej, err := s.repository.GetByID(ctx, dto)
+	if err != nil {
+		return rej, errors.Wrap(err, "GetByID rejudge service")
+	}
+
+	return rej, nil
+}
+
+// Summary returns progress of the rejudge together with transitions of
+// statuses of finished solutions.
+func (s *Service) Summary(ctx context.Context, rejudgeID string) (domain.RejudgeSummary, error) {
+	summary, err := s.repository.Summary(ctx, rejudgeID)
+	if err != nil {
+		return summary, errors.Wrap(err, "Summary rejudge service")
+	}
+
+	summary.Transitions, err = s.repository.Transitions(ctx, rejudgeID)
+	if err != nil {
+		return summary, errors.Wrap(err, "Summary rejudge service")
+	}
+
+	summary.Done = summary.Finished == summary.Total
+
+	return summary, nil
+}
+
+func (s *Service) FinishSolution(ctx context.Context, sol domain.Solution) error {
+	err := s.repository.FinishSolution(ctx, sol)
+	if err != nil {
+		return errors.Wrap(err, "FinishSolution rejudge service")
+	}
+
+	return nil
+}
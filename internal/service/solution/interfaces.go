@@ -10,13 +10,17 @@ type (
 		Create(ctx context.Context, entity domain.CreateSolutionEntity) (sol domain.Solution, err error)
 		Update(ctx context.Context, entity domain.UpdateSolutionDTO) (sol domain.Solution, err error)
 		SolutionsByUserAndTask(ctx context.Context, dto domain.GetSolutionsDTO) ([]domain.Solution, error)
+		SolutionList(ctx context.Context, params domain.SolutionParams) (domain.SolutionList, error)
 		SolutionByID(ctx context.Context, id string) (sol domain.Solution, err error)
+		LatestAcceptedByTask(ctx context.Context, taskID string) ([]domain.Solution, error)
 	}
 
 	SolutionRepo interface {
 		Create(ctx context.Context, entity domain.CreateSolutionEntity) (sol domain.Solution, err error)
 		Update(ctx context.Context, entity domain.UpdateSolutionDTO) (sol domain.Solution, err error)
 		SolutionsByUserAndTask(ctx context.Context, dto domain.GetSolutionsDTO) ([]domain.Solution, error)
+		List(ctx context.Context, params domain.SolutionParams) ([]domain.Solution, error)
 		SolutionByID(ctx context.Context, id string) (sol domain.Solution, err error)
+		LatestAcceptedByTask(ctx context.Context, taskID string) ([]domain.Solution, error)
 	}
 )
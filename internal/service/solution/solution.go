@@ -48,6 +48,19 @@ func (s *Service) SolutionsByUserAndTask(ctx context.Context, dto domain.GetSolu
 	return solutions, nil
 }
 
+func (s *Service) SolutionList(ctx context.Context, params domain.SolutionParams) (list domain.SolutionList, err error) {
+	list.Solutions, err = s.repository.List(ctx, params)
+	if err != nil {
+		return list, errors.Wrap(err, "SolutionList solution service")
+	}
+
+	if len(list.Solutions) != 0 {
+		list.Pagination.AfterID = list.Solutions[len(list.Solutions)-1].Id
+	}
+
+	return list, nil
+}
+
 func (s *Service) SolutionByID(ctx context.Context, id string) (sol domain.Solution, err error) {
 	sol, err = s.repository.SolutionByID(ctx, id)
 	if err != nil {
@@ -56,3 +69,12 @@ func (s *Service) SolutionByID(ctx context.Context, id string) (sol domain.Solut
 
 	return sol, nil
 }
+
+func (s *Service) LatestAcceptedByTask(ctx context.Context, taskID string) ([]domain.Solution, error) {
+	solutions, err := s.repository.LatestAcceptedByTask(ctx, taskID)
+	if err != nil {
+		return nil, errors.Wrap(err, "LatestAcceptedByTask solution service")
+	}
+
+	return solutions, nil
+}
@@ -0,0 +1,18 @@
+package solution_event
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	SolutionEvent interface {
+		Publish(ctx context.Context, n domain.SolutionEventNotification) error
+		Listen(ctx context.Context, handle func(domain.SolutionEventNotification)) error
+	}
+
+	SolutionEventRepo interface {
+		Publish(ctx context.Context, n domain.SolutionEventNotification) error
+		Listen(ctx context.Context, handle func(domain.SolutionEventNotification)) error
+	}
+)
@@ -0,0 +1,37 @@
+package solution_event
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/internal/domain"
+)
+
+type (
+	Service struct {
+		repository SolutionEventRepo
+	}
+)
+
+func New(repository SolutionEventRepo) *Service {
+	return &Service{
+		repository: repository,
+	}
+}
+
+func (s *Service) Publish(ctx context.Context, n domain.SolutionEventNotification) error {
+	err := s.repository.Publish(ctx, n)
+	if err != nil {
+		return errors.Wrap(err, "Publish solution_event service")
+	}
+
+	return nil
+}
+
+func (s *Service) Listen(ctx context.Context, handle func(domain.SolutionEventNotification)) error {
+	err := s.repository.Listen(ctx, handle)
+	if err != nil {
+		return errors.Wrap(err, "Listen solution_event service")
+	}
+
+	return nil
+}
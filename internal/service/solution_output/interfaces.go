@@ -0,0 +1,21 @@
+package solution_output
+
+import (
+	"context"
+	"lcode/internal/domain"
+	"time"
+)
+
+type (
+	SolutionOutput interface {
+		CreateBatch(ctx context.Context, outputs ...domain.SolutionOutput) error
+		Get(ctx context.Context, solutionID, testCaseID string) (domain.SolutionOutput, error)
+		DeleteExpired(ctx context.Context) (int64, error)
+	}
+
+	SolutionOutputRepo interface {
+		CreateBatch(ctx context.Context, entities ...domain.SolutionOutputEntity) error
+		Get(ctx context.Context, solutionID, testCaseID string) (domain.SolutionOutputEntity, error)
+		DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error)
+	}
+)
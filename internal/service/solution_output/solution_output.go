@@ -0,0 +1,144 @@
+package solution_output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"lcode/config"
+	"lcode/internal/domain"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository SolutionOutputRepo
+	}
+)
+
+func New(conf *config.Config, repository SolutionOutputRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+// CreateBatch stores full outputs, they are gzipped when compression is on.
+func (s *Service) CreateBatch(ctx context.Context, outputs ...domain.SolutionOutput) error {
+	entities := make([]domain.SolutionOutputEntity, 0, len(outputs))
+
+	for i := range outputs {
+		stdout, err := s.encode(outputs[i].Stdout)
+		if err != nil {
+			return errors.Wrap(err, "CreateBatch solution_output service")
+		}
+
+		stderr, err := s.encode(outputs[i].Stderr)
+		if err != nil {
+			return errors.Wrap(err, "CreateBatch solution_output service")
+		}
+
+		entities = append(entities, domain.SolutionOutputEntity{
+			SolutionID: outputs[i].SolutionID,
+			TestCaseID: outputs[i].TestCaseID,
+			Stdout:     stdout,
+			Stderr:     stderr,
+			Compressed: s.config.OutputConfig.Compress,
+		})
+	}
+
+	err := s.repository.CreateBatch(ctx, entities...)
+	if err != nil {
+		return errors.Wrap(err, "CreateBatch solution_output service")
+	}
+
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, solutionID, testCaseID string) (domain.SolutionOutput, error) {
+	entity, err := s.repository.Get(ctx, solutionID, testCaseID)
+	if err != nil {
+		return domain.SolutionOutput{}, errors.Wrap(err, "Get solution_output service")
+	}
+
+	stdout, err := decode(entity.Stdout, entity.Compressed)
+	if err != nil {
+		return domain.SolutionOutput{}, errors.Wrap(err, "Get solution_output service")
+	}
+
+	stderr, err := decode(entity.Stderr, entity.Compressed)
+	if err != nil {
+		return domain.SolutionOutput{}, errors.Wrap(err, "Get solution_output service")
+	}
+
+	output := domain.SolutionOutput{
+		SolutionID: entity.SolutionID,
+		TestCaseID: entity.TestCaseID,
+		Stdout:     stdout,
+		Stderr:     stderr,
+	}
+
+	return output, nil
+}
+
+// DeleteExpired deletes outputs which can not be downloaded anymore.
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.repository.DeleteExpired(ctx, s.config.OutputConfig.FullTTL)
+	if err != nil {
+		return 0, errors.Wrap(err, "DeleteExpired solution_output service")
+	}
+
+	return deleted, nil
+}
+
+func (s *Service) encode(output *string) ([]byte, error) {
+	if output == nil {
+		return nil, nil
+	}
+
+	if !s.config.OutputConfig.Compress {
+		return []byte(*output), nil
+	}
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+
+	if _, err := io.WriteString(w, *output); err != nil {
+		return nil, errors.Wrap(err, "encode solution_output service")
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "encode solution_output service")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte, compressed bool) (*string, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	if !compressed {
+		output := string(data)
+
+		return &output, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode solution_output service")
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode solution_output service")
+	}
+
+	output := string(b)
+
+	return &output, nil
+}
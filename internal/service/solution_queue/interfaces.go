@@ -0,0 +1,49 @@
+package solution_queue
+
+import (
+	"context"
+	"lcode/internal/domain"
+	"time"
+)
+
+type (
+	SolutionQueue interface {
+		Push(ctx context.Context, entity domain.PushSolutionQueueEntity) error
+		PushRejudge(ctx context.Context, rejudgeID string) (int64, error)
+		Claim(ctx context.Context, owner string, limit int) ([]domain.SolutionQueueItem, error)
+		Release(ctx context.Context, solutionID string, interrupted bool) error
+		ReleaseOwned(ctx context.Context, owner string, interrupted bool) (int64, error)
+		Disown(ctx context.Context, solutionID string) error
+		ReleaseStuck(ctx context.Context, deadline time.Duration) ([]domain.SolutionQueueItem, error)
+		Lock(ctx context.Context, solutionID string) (domain.SolutionQueueItem, error)
+		Delete(ctx context.Context, solutionID string) error
+		DeleteWaiting(ctx context.Context, solutionID string) error
+		List(ctx context.Context, params domain.IdPaginationParams) ([]domain.QueuedSolution, error)
+		Counts(ctx context.Context) (domain.SolutionQueueCounts, error)
+		Count(ctx context.Context) (int, error)
+		LockLimits(ctx context.Context) error
+		CountByUser(ctx context.Context, userID string, lane domain.SolutionQueueLane) (int, error)
+		EnqueueOrphaned(ctx context.Context) (int64, error)
+		DeleteExhausted(ctx context.Context, owner string, maxAttempts int) ([]string, error)
+	}
+
+	SolutionQueueRepo interface {
+		Push(ctx context.Context, entity domain.PushSolutionQueueEntity) error
+		PushRejudge(ctx context.Context, rejudgeID string) (int64, error)
+		Claim(ctx context.Context, owner string, limit int) ([]domain.SolutionQueueItem, error)
+		Release(ctx context.Context, solutionID string, interrupted bool) error
+		ReleaseOwned(ctx context.Context, owner string, interrupted bool) (int64, error)
+		Disown(ctx context.Context, solutionID string) error
+		ReleaseStuck(ctx context.Context, deadline time.Duration) ([]domain.SolutionQueueItem, error)
+		Lock(ctx context.Context, solutionID string) (domain.SolutionQueueItem, error)
+		Delete(ctx context.Context, solutionID string) error
+		DeleteWaiting(ctx context.Context, solutionID string) error
+		List(ctx context.Context, params domain.IdPaginationParams) ([]domain.QueuedSolution, error)
+		Counts(ctx context.Context) (domain.SolutionQueueCounts, error)
+		Count(ctx context.Context) (int, error)
+		LockLimits(ctx context.Context) error
+		CountByUser(ctx context.Context, userID string, lane domain.SolutionQueueLane) (int, error)
+		EnqueueOrphaned(ctx context.Context) (int64, error)
+		DeleteExhausted(ctx context.Context, owner string, maxAttempts int) ([]string, error)
+	}
+)
@@ -0,0 +1,176 @@
+package solution_queue
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+	"time"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository SolutionQueueRepo
+	}
+)
+
+func New(conf *config.Config, repository SolutionQueueRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+func (s *Service) Push(ctx context.Context, entity domain.PushSolutionQueueEntity) error {
+	err := s.repository.Push(ctx, entity)
+	if err != nil {
+		return errors.Wrap(err, "Push solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) PushRejudge(ctx context.Context, rejudgeID string) (int64, error) {
+	count, err := s.repository.PushRejudge(ctx, rejudgeID)
+	if err != nil {
+		return 0, errors.Wrap(err, "PushRejudge solution_queue service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) Claim(ctx context.Context, owner string, limit int) ([]domain.SolutionQueueItem, error) {
+	items, err := s.repository.Claim(ctx, owner, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "Claim solution_queue service")
+	}
+
+	return items, nil
+}
+
+func (s *Service) Release(ctx context.Context, solutionID string, interrupted bool) error {
+	err := s.repository.Release(ctx, solutionID, interrupted)
+	if err != nil {
+		return errors.Wrap(err, "Release solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) ReleaseOwned(ctx context.Context, owner string, interrupted bool) (int64, error) {
+	count, err := s.repository.ReleaseOwned(ctx, owner, interrupted)
+	if err != nil {
+		return 0, errors.Wrap(err, "ReleaseOwned solution_queue service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) Disown(ctx context.Context, solutionID string) error {
+	err := s.repository.Disown(ctx, solutionID)
+	if err != nil {
+		return errors.Wrap(err, "Disown solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) ReleaseStuck(ctx context.Context, deadline time.Duration) ([]domain.SolutionQueueItem, error) {
+	items, err := s.repository.ReleaseStuck(ctx, deadline)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReleaseStuck solution_queue service")
+	}
+
+	return items, nil
+}
+
+func (s *Service) Lock(ctx context.Context, solutionID string) (domain.SolutionQueueItem, error) {
+	item, err := s.repository.Lock(ctx, solutionID)
+	if err != nil {
+		return item, errors.Wrap(err, "Lock solution_queue service")
+	}
+
+	return item, nil
+}
+
+func (s *Service) Delete(ctx context.Context, solutionID string) error {
+	err := s.repository.Delete(ctx, solutionID)
+	if err != nil {
+		return errors.Wrap(err, "Delete solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteWaiting(ctx context.Context, solutionID string) error {
+	err := s.repository.DeleteWaiting(ctx, solutionID)
+	if err != nil {
+		return errors.Wrap(err, "DeleteWaiting solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) List(ctx context.Context, params domain.IdPaginationParams) ([]domain.QueuedSolution, error) {
+	items, err := s.repository.List(ctx, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "List solution_queue service")
+	}
+
+	return items, nil
+}
+
+func (s *Service) Counts(ctx context.Context) (domain.SolutionQueueCounts, error) {
+	c, err := s.repository.Counts(ctx)
+	if err != nil {
+		return c, errors.Wrap(err, "Counts solution_queue service")
+	}
+
+	return c, nil
+}
+
+func (s *Service) Count(ctx context.Context) (int, error) {
+	count, err := s.repository.Count(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "Count solution_queue service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) LockLimits(ctx context.Context) error {
+	err := s.repository.LockLimits(ctx)
+	if err != nil {
+		return errors.Wrap(err, "LockLimits solution_queue service")
+	}
+
+	return nil
+}
+
+func (s *Service) CountByUser(ctx context.Context, userID string, lane domain.SolutionQueueLane) (int, error) {
+	count, err := s.repository.CountByUser(ctx, userID, lane)
+	if err != nil {
+		return 0, errors.Wrap(err, "CountByUser solution_queue service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) EnqueueOrphaned(ctx context.Context) (int64, error) {
+	count, err := s.repository.EnqueueOrphaned(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "EnqueueOrphaned solution_queue service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) DeleteExhausted(ctx context.Context, owner string, maxAttempts int) ([]string, error) {
+	ids, err := s.repository.DeleteExhausted(ctx, owner, maxAttempts)
+	if err != nil {
+		return nil, errors.Wrap(err, "DeleteExhausted solution_queue service")
+	}
+
+	return ids, nil
+}
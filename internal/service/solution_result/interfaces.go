@@ -3,16 +3,25 @@ package solution_result
 import (
 	"context"
 	"lcode/internal/domain"
+	"time"
 )
 
 type (
 	SolutionResult interface {
 		CreateBatch(ctx context.Context, results ...domain.SolutionResult) error
+		Update(ctx context.Context, result domain.SolutionResult) error
+		SetSubmissionToken(ctx context.Context, solutionID string, key string, token string) error
+		DeleteBySolutionID(ctx context.Context, solutionID string) error
 		ResultsBySolutionID(ctx context.Context, solutionID string) ([]domain.SolutionResult, error)
+		PruneOutputs(ctx context.Context, retention time.Duration) (int64, error)
 	}
 
 	SolutionResultRepo interface {
 		CreateBatch(ctx context.Context, results ...domain.SolutionResult) error
+		Update(ctx context.Context, result domain.SolutionResult) error
+		SetSubmissionToken(ctx context.Context, solutionID string, key string, token string) error
+		DeleteBySolutionID(ctx context.Context, solutionID string) error
 		ResultsBySolutionID(ctx context.Context, solutionID string) ([]domain.SolutionResult, error)
+		PruneOutputs(ctx context.Context, retention time.Duration) (int64, error)
 	}
 )
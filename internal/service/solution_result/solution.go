@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"lcode/config"
 	"lcode/internal/domain"
+	"time"
 )
 
 type (
@@ -30,6 +31,33 @@ func (s *Service) CreateBatch(ctx context.Context, results ...domain.SolutionRes
 	return nil
 }
 
+func (s *Service) Update(ctx context.Context, result domain.SolutionResult) error {
+	err := s.repository.Update(ctx, result)
+	if err != nil {
+		return errors.Wrap(err, "Update solution_result service")
+	}
+
+	return nil
+}
+
+func (s *Service) SetSubmissionToken(ctx context.Context, solutionID string, key string, token string) error {
+	err := s.repository.SetSubmissionToken(ctx, solutionID, key, token)
+	if err != nil {
+		return errors.Wrap(err, "SetSubmissionToken solution_result service")
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBySolutionID(ctx context.Context, solutionID string) error {
+	err := s.repository.DeleteBySolutionID(ctx, solutionID)
+	if err != nil {
+		return errors.Wrap(err, "DeleteBySolutionID solution_result service")
+	}
+
+	return nil
+}
+
 func (s *Service) ResultsBySolutionID(ctx context.Context, solutionID string) ([]domain.SolutionResult, error) {
 	results, err := s.repository.ResultsBySolutionID(ctx, solutionID)
 	if err != nil {
@@ -38,3 +66,12 @@ func (s *Service) ResultsBySolutionID(ctx context.Context, solutionID string) ([
 
 	return results, nil
 }
+
+func (s *Service) PruneOutputs(ctx context.Context, retention time.Duration) (int64, error) {
+	pruned, err := s.repository.PruneOutputs(ctx, retention)
+	if err != nil {
+		return 0, errors.Wrap(err, "PruneOutputs solution_result service")
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,30 @@
+package solution_stats
+
+import (
+	"context"
+	"lcode/internal/domain"
+)
+
+type (
+	SolutionStats interface {
+		Refresh(ctx context.Context, buckets int) (int64, error)
+		DeleteStale(ctx context.Context) (int64, error)
+		UpdatePercentiles(ctx context.Context) (int64, error)
+		GetByTaskAndLanguage(
+			ctx context.Context,
+			taskID string,
+			languageID domain.LanguageType,
+		) ([]domain.SolutionStats, error)
+	}
+
+	SolutionStatsRepo interface {
+		Refresh(ctx context.Context, buckets int) (int64, error)
+		DeleteStale(ctx context.Context) (int64, error)
+		UpdatePercentiles(ctx context.Context) (int64, error)
+		GetByTaskAndLanguage(
+			ctx context.Context,
+			taskID string,
+			languageID domain.LanguageType,
+		) ([]domain.SolutionStats, error)
+	}
+)
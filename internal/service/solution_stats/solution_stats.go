@@ -0,0 +1,62 @@
+package solution_stats
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"lcode/config"
+	"lcode/internal/domain"
+)
+
+type (
+	Service struct {
+		config     *config.Config
+		repository SolutionStatsRepo
+	}
+)
+
+func New(conf *config.Config, repository SolutionStatsRepo) *Service {
+	return &Service{
+		config:     conf,
+		repository: repository,
+	}
+}
+
+func (s *Service) Refresh(ctx context.Context, buckets int) (int64, error) {
+	count, err := s.repository.Refresh(ctx, buckets)
+	if err != nil {
+		return 0, errors.Wrap(err, "Refresh solution stats service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) DeleteStale(ctx context.Context) (int64, error) {
+	count, err := s.repository.DeleteStale(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "DeleteStale solution stats service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) UpdatePercentiles(ctx context.Context) (int64, error) {
+	count, err := s.repository.UpdatePercentiles(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "UpdatePercentiles solution stats service")
+	}
+
+	return count, nil
+}
+
+func (s *Service) GetByTaskAndLanguage(
+	ctx context.Context,
+	taskID string,
+	languageID domain.LanguageType,
+) ([]domain.SolutionStats, error) {
+	stats, err := s.repository.GetByTaskAndLanguage(ctx, taskID, languageID)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetByTaskAndLanguage solution stats service")
+	}
+
+	return stats, nil
+}
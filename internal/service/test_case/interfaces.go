@@ -7,16 +7,24 @@ import (
 
 type TestCase interface {
 	Create(ctx context.Context, taskID string, dto domain.TestCaseCreateInput) error
+	CreateFileBacked(ctx context.Context, taskID string, dto domain.TestCaseFileCreateInput) (string, error)
 	Update(ctx context.Context, id string, dto domain.TestCaseUpdateInput) error
 	Delete(ctx context.Context, id string) error
+	ArchiveByTaskID(ctx context.Context, taskID string) error
 
+	GetByID(ctx context.Context, id string) (domain.TestCase, error)
 	GetAllByTaskID(ctx context.Context, id string) ([]domain.TestCase, error)
+	GetByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error)
 }
 
 type TestCaseRepo interface {
 	Create(ctx context.Context, taskID string, dto domain.TestCaseCreateInput) error
+	CreateFileBacked(ctx context.Context, taskID string, dto domain.TestCaseFileCreateInput) (string, error)
 	Update(ctx context.Context, id string, dto domain.TestCaseUpdateInput) error
 	Delete(ctx context.Context, id string) error
+	ArchiveByTaskID(ctx context.Context, taskID string) error
 
+	GetByID(ctx context.Context, id string) (domain.TestCase, error)
 	GetAllByTaskID(ctx context.Context, id string) ([]domain.TestCase, error)
+	GetByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error)
 }
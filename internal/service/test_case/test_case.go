@@ -28,6 +28,19 @@ func (s *Service) Create(ctx context.Context, taskID string, dto domain.TestCase
 	return nil
 }
 
+func (s *Service) CreateFileBacked(
+	ctx context.Context,
+	taskID string,
+	dto domain.TestCaseFileCreateInput,
+) (string, error) {
+	id, err := s.repository.CreateFileBacked(ctx, taskID, dto)
+	if err != nil {
+		return "", errors.Wrap(err, "CreateFileBacked TestCase service:")
+	}
+
+	return id, nil
+}
+
 func (s *Service) Update(ctx context.Context, id string, dto domain.TestCaseUpdateInput) error {
 	err := s.repository.Update(ctx, id, dto)
 	if err != nil {
@@ -46,6 +59,24 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (s *Service) ArchiveByTaskID(ctx context.Context, taskID string) error {
+	err := s.repository.ArchiveByTaskID(ctx, taskID)
+	if err != nil {
+		return errors.Wrap(err, "ArchiveByTaskID TestCase service:")
+	}
+
+	return nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id string) (domain.TestCase, error) {
+	tc, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return domain.TestCase{}, errors.Wrap(err, "GetByID TestCase service:")
+	}
+
+	return tc, nil
+}
+
 func (s *Service) GetAllByTaskID(ctx context.Context, id string) ([]domain.TestCase, error) {
 	tcs, err := s.repository.GetAllByTaskID(ctx, id)
 	if err != nil {
@@ -54,3 +85,12 @@ func (s *Service) GetAllByTaskID(ctx context.Context, id string) ([]domain.TestC
 
 	return tcs, nil
 }
+
+func (s *Service) GetByIDs(ctx context.Context, ids []string) ([]domain.TestCase, error) {
+	tcs, err := s.repository.GetByIDs(ctx, ids)
+	if err != nil {
+		return []domain.TestCase{}, errors.Wrap(err, "GetByIDs TestCase service:")
+	}
+
+	return tcs, nil
+}
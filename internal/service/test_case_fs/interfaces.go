@@ -0,0 +1,19 @@
+package test_case_fs
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"lcode/internal/domain"
+)
+
+type TestCaseFS interface {
+	OpenArchive(ctx context.Context, taskID string, archive io.Reader) (*zip.Reader, func(), error)
+	Create(ctx context.Context, files domain.TestCaseFiles) error
+	LoadInputs(ctx context.Context, testCases []domain.TestCase) error
+	LoadOutputs(ctx context.Context, testCases []domain.TestCase) error
+	LoadSamples(ctx context.Context, testCases []domain.TestCase) error
+	OpenOutput(ctx context.Context, testCase domain.TestCase) (io.ReadCloser, error)
+	Delete(ctx context.Context, testCases ...domain.TestCase) error
+	DeleteTaskDir(ctx context.Context, taskID string) error
+}
@@ -0,0 +1,295 @@
+package test_case_fs
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"lcode/config"
+	"lcode/internal/domain"
+	"lcode/pkg/filesystem"
+	"lcode/pkg/struct_errors"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+)
+
+const dirPerm = os.ModeDir | 0o755
+
+type Service struct {
+	cfg        *config.Config
+	logger     *slog.Logger
+	fileSystem *filesystem.FileSystem
+}
+
+func New(cfg *config.Config, logger *slog.Logger) *Service {
+	return &Service{
+		cfg:        cfg,
+		logger:     logger,
+		fileSystem: &filesystem.FileSystem{},
+	}
+}
+
+// OpenArchive stores the uploaded zip archive in the task dir, zip archives
+// are read only from files, and opens it. The returned func closes and
+// removes the archive.
+func (s *Service) OpenArchive(ctx context.Context, taskID string, archive io.Reader) (*zip.Reader, func(), error) {
+	taskDir := s.taskDir(taskID)
+
+	err := os.MkdirAll(taskDir, dirPerm)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "OpenArchive test_case_fs service")
+	}
+
+	file, err := os.CreateTemp(taskDir, "archive-*.zip")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "OpenArchive test_case_fs service")
+	}
+
+	archivePath := file.Name()
+
+	_ = file.Close()
+
+	err = s.createFile(archive, archivePath, s.cfg.Files.TestArchiveMaxSize)
+	if err != nil {
+		_ = os.Remove(archivePath)
+
+		return nil, nil, errors.Wrap(err, "OpenArchive test_case_fs service")
+	}
+
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		_ = os.Remove(archivePath)
+
+		s.logger.Warn("cannot open test case archive", slog.String("err", err.Error()))
+
+		err = struct_errors.NewBaseErr("Archive is not a valid zip file", nil)
+
+		return nil, nil, errors.Wrap(err, "OpenArchive test_case_fs service")
+	}
+
+	closeArchive := func() {
+		_ = zipReader.Close()
+
+		err := os.Remove(archivePath)
+		if err != nil {
+			s.logger.Error("cannot remove test case archive", slog.String("err", err.Error()))
+		}
+	}
+
+	return &zipReader.Reader, closeArchive, nil
+}
+
+// Create writes input and output files of the test case. Files larger than
+// TestCaseMaxSize and inputs larger than JudgeInputMaxSize are rejected.
+func (s *Service) Create(ctx context.Context, files domain.TestCaseFiles) error {
+	err := os.MkdirAll(s.testCasesDir(files.TaskID), dirPerm)
+	if err != nil {
+		return errors.Wrap(err, "Create test_case_fs service")
+	}
+
+	inputPath, outputPath := s.testCasePaths(files.TaskID, files.CaseID)
+
+	err = s.createFile(files.Input, inputPath, min(s.cfg.Files.TestCaseMaxSize, s.cfg.Files.JudgeInputMaxSize))
+	if err != nil {
+		return errors.Wrap(err, "Create test_case_fs service")
+	}
+
+	err = s.createFile(files.Output, outputPath, s.cfg.Files.TestCaseMaxSize)
+	if err != nil {
+		_ = os.Remove(inputPath)
+
+		return errors.Wrap(err, "Create test_case_fs service")
+	}
+
+	return nil
+}
+
+// LoadInputs reads inputs of file backed test cases, the other test cases
+// are left as is. Only test cases which are about to be judged are passed, so
+// test data of the whole task is never held in memory. The judge gets the
+// whole input, so inputs larger than JudgeInputMaxSize fail rather than being
+// cut, they are rejected on upload since the limit was added.
+func (s *Service) LoadInputs(ctx context.Context, testCases []domain.TestCase) error {
+	for i := range testCases {
+		if testCases[i].FileBacked && testCases[i].InputSize > s.cfg.Files.JudgeInputMaxSize {
+			err := fmt.Errorf("input of test case %s exceeds limit of %d bytes", testCases[i].ID, s.cfg.Files.JudgeInputMaxSize)
+
+			return errors.Wrap(err, "LoadInputs test_case_fs service")
+		}
+	}
+
+	err := s.load(testCases, true, false, s.cfg.Files.JudgeInputMaxSize)
+	if err != nil {
+		return errors.Wrap(err, "LoadInputs test_case_fs service")
+	}
+
+	return nil
+}
+
+// LoadOutputs reads expected outputs of file backed test cases.
+func (s *Service) LoadOutputs(ctx context.Context, testCases []domain.TestCase) error {
+	err := s.load(testCases, false, true, s.cfg.Files.TestCaseMaxSize)
+	if err != nil {
+		return errors.Wrap(err, "LoadOutputs test_case_fs service")
+	}
+
+	return nil
+}
+
+// OpenOutput opens expected output of the test case to be read
+// incrementally. Output of test cases stored in the database is read from
+// memory.
+func (s *Service) OpenOutput(ctx context.Context, testCase domain.TestCase) (io.ReadCloser, error) {
+	if !testCase.FileBacked {
+		return io.NopCloser(strings.NewReader(testCase.Output)), nil
+	}
+
+	_, outputPath := s.testCasePaths(testCase.TaskID, testCase.ID)
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenOutput test_case_fs service")
+	}
+
+	return file, nil
+}
+
+// LoadSamples reads input and output of file backed test cases cut to
+// SampleMaxSize bytes, sizes of the test cases tell whether they were cut.
+func (s *Service) LoadSamples(ctx context.Context, testCases []domain.TestCase) error {
+	err := s.load(testCases, true, true, s.cfg.Files.SampleMaxSize)
+	if err != nil {
+		return errors.Wrap(err, "LoadSamples test_case_fs service")
+	}
+
+	return nil
+}
+
+func (s *Service) load(testCases []domain.TestCase, input, output bool, maxSize int64) error {
+	for i := range testCases {
+		if !testCases[i].FileBacked {
+			continue
+		}
+
+		inputPath, outputPath := s.testCasePaths(testCases[i].TaskID, testCases[i].ID)
+
+		if input {
+			data, err := readFile(inputPath, maxSize)
+			if err != nil {
+				return err
+			}
+
+			testCases[i].Input = data
+		}
+
+		if output {
+			data, err := readFile(outputPath, maxSize)
+			if err != nil {
+				return err
+			}
+
+			testCases[i].Output = data
+		}
+	}
+
+	return nil
+}
+
+// Delete removes files of file backed test cases.
+func (s *Service) Delete(ctx context.Context, testCases ...domain.TestCase) error {
+	for i := range testCases {
+		if !testCases[i].FileBacked {
+			continue
+		}
+
+		inputPath, outputPath := s.testCasePaths(testCases[i].TaskID, testCases[i].ID)
+
+		for _, filePath := range []string{inputPath, outputPath} {
+			err := s.fileSystem.DeleteFile(filePath)
+			if err != nil && !os.IsNotExist(errors.Cause(err)) {
+				s.logger.Error("cannot remove test case file", slog.String("err", err.Error()))
+
+				return errors.Wrap(err, "Delete test_case_fs service")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteTaskDir(ctx context.Context, taskID string) error {
+	err := os.RemoveAll(s.taskDir(taskID))
+	if err != nil {
+		return errors.Wrap(err, "DeleteTaskDir test_case_fs service")
+	}
+
+	return nil
+}
+
+// createFile copies the reader to the file failing when more than maxSize
+// bytes are read. The file is removed when it can not be written completely.
+func (s *Service) createFile(reader io.Reader, fullPath string, maxSize int64) error {
+	err := s.fileSystem.CreateFileFromReader(io.LimitReader(reader, maxSize+1), fullPath)
+	if err != nil {
+		_ = os.Remove(fullPath)
+
+		return err
+	}
+
+	size, err := fileSize(fullPath)
+	if err != nil {
+		return err
+	}
+
+	if size > maxSize {
+		_ = os.Remove(fullPath)
+
+		return struct_errors.NewBaseErr(fmt.Sprintf("File size exceeds limit of %d bytes", maxSize), nil)
+	}
+
+	return nil
+}
+
+// readFile reads at most maxSize bytes of the file.
+func readFile(fullPath string, maxSize int64) (string, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (s *Service) taskDir(taskID string) string {
+	return path.Join(s.cfg.Files.MainFolder, domain.TasksFolder, taskID)
+}
+
+func (s *Service) testCasesDir(taskID string) string {
+	return path.Join(s.taskDir(taskID), domain.TestCasesFolder)
+}
+
+func (s *Service) testCasePaths(taskID, caseID string) (inputPath, outputPath string) {
+	casesDir := s.testCasesDir(taskID)
+	inputPath = path.Join(casesDir, fmt.Sprintf("%s.%s", caseID, domain.TestCaseInputExtension))
+	outputPath = path.Join(casesDir, fmt.Sprintf("%s.%s", caseID, domain.TestCaseOutputExtension))
+
+	return inputPath, outputPath
+}
+
+func fileSize(fullPath string) (int64, error) {
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
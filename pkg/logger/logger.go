@@ -3,12 +3,11 @@ package logger
 
 import (
 	"bufio"
-	"fmt"
 	"github.com/pkg/errors"
 	"io"
-	"log"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -35,7 +34,10 @@ func getLogLevel(isDebug bool) slog.Level {
 	}
 }
 
-func New(opts *Options) (*os.File, *slog.Logger, error) {
+// New returns logger which writes to stdout and to the buffered log file.
+// The buffer is flushed every BufferFlushTimeout, the returned closer flushes
+// the rest of the buffer and closes the file.
+func New(opts *Options) (io.Closer, *slog.Logger, error) {
 	if opts == nil {
 		opts = getDefaultConfig()
 	}
@@ -44,13 +46,13 @@ func New(opts *Options) (*os.File, *slog.Logger, error) {
 
 	f, err := os.OpenFile(opts.LogFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		log.Fatalf("error opening file: %v", err)
+		return nil, nil, errors.Wrap(err, "New logger")
 	}
 
-	writer := bufio.NewWriterSize(f, opts.BufferSize)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	writer := &bufferedFile{
+		f:    f,
+		buf:  bufio.NewWriterSize(f, opts.BufferSize),
+		done: make(chan struct{}),
 	}
 
 	multiWriter := io.MultiWriter(os.Stdout, writer)
@@ -63,8 +65,16 @@ func New(opts *Options) (*os.File, *slog.Logger, error) {
 	slog.SetDefault(logger)
 
 	go func() {
+		ticker := time.NewTicker(opts.BufferFlushTimeout)
+		defer ticker.Stop()
+
 		for {
-			time.Sleep(opts.BufferFlushTimeout)
+			select {
+			case <-writer.done:
+				return
+			case <-ticker.C:
+			}
+
 			err := writer.Flush()
 			if err != nil && !errors.Is(err, io.ErrShortWrite) {
 				logger.Error(err.Error())
@@ -72,5 +82,52 @@ func New(opts *Options) (*os.File, *slog.Logger, error) {
 		}
 	}()
 
-	return f, logger, nil
+	return writer, logger, nil
+}
+
+// bufferedFile guards the buffer, it is written by the handler and flushed by
+// the background goroutine at the same time.
+type bufferedFile struct {
+	mu     sync.Mutex
+	f      *os.File
+	buf    *bufio.Writer
+	done   chan struct{}
+	closed bool
+}
+
+func (b *bufferedFile) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// records after close still reach stdout
+	if b.closed {
+		return len(p), nil
+	}
+
+	return b.buf.Write(p)
+}
+
+func (b *bufferedFile) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Flush()
+}
+
+func (b *bufferedFile) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	b.closed = true
+	close(b.done)
+
+	if err := b.buf.Flush(); err != nil {
+		return errors.Wrap(err, "Close logger")
+	}
+
+	return b.f.Close()
 }
@@ -37,6 +37,14 @@ func (d *DbManager) GetDb() *pgxpool.Pool {
 	return d.db
 }
 
+func (d *DbManager) Ping(ctx context.Context) error {
+	if err := d.db.Ping(ctx); err != nil {
+		return errors.Wrap(err, "Ping postgres pkg")
+	}
+
+	return nil
+}
+
 func NewTransactionProvider(db *pgxpool.Pool) *TransactionProvider {
 	t := &TransactionProvider{db: db, txQueue: make(chan *Tx, 2000)}
 
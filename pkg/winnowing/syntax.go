@@ -0,0 +1,128 @@
+package winnowing
+
+// Syntax is the part of the language grammar which matters for tokenizing:
+// comments are dropped, literals in Quotes are strings and Keywords are kept
+// while other identifiers are replaced, so renaming of variables does not
+// change fingerprints.
+type Syntax struct {
+	LineComments []string
+	// BlockComment holds opening and closing delimiters, it is empty when the
+	// language has no block comments
+	BlockComment [2]string
+	Quotes       string
+	Keywords     map[string]struct{}
+}
+
+// CLike is used for languages without own syntax: C-style comments and
+// quotes, no keywords.
+var CLike = &Syntax{
+	LineComments: []string{"//"},
+	BlockComment: [2]string{"/*", "*/"},
+	Quotes:       "\"'`",
+}
+
+var syntaxes = map[string]*Syntax{
+	"javascript": javaScript,
+	"typescript": javaScript,
+	"python":     python,
+	"go":         golang,
+	"c":          cpp,
+	"cpp":        cpp,
+	"java":       java,
+}
+
+// SyntaxFor returns syntax of the language by its editor mode, CLike when
+// the language is unknown.
+func SyntaxFor(editorMode string) *Syntax {
+	if s, ok := syntaxes[editorMode]; ok {
+		return s
+	}
+
+	return CLike
+}
+
+var (
+	javaScript = &Syntax{
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'`",
+		Keywords: keywords(
+			"abstract", "any", "as", "async", "await", "boolean", "break", "case", "catch", "class", "const",
+			"continue", "debugger", "default", "delete", "do", "else", "enum", "export", "extends", "false",
+			"finally", "for", "from", "function", "get", "if", "implements", "import", "in", "instanceof",
+			"interface", "let", "new", "null", "number", "of", "private", "protected", "public", "readonly",
+			"return", "set", "static", "string", "super", "switch", "this", "throw", "true", "try", "type",
+			"typeof", "undefined", "var", "void", "while", "yield",
+		),
+	}
+
+	python = &Syntax{
+		LineComments: []string{"#"},
+		Quotes:       "\"'",
+		Keywords: keywords(
+			"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue",
+			"def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+			"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+		),
+	}
+
+	golang = &Syntax{
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'`",
+		Keywords: keywords(
+			"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for",
+			"func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+			"struct", "switch", "type", "var", "nil", "true", "false",
+		),
+	}
+
+	cpp = &Syntax{
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+		Keywords: keywords(
+			"auto", "bool", "break", "case", "catch", "char", "class", "const", "continue", "default", "delete",
+			"do", "double", "else", "enum", "extern", "false", "float", "for", "goto", "if", "include", "inline",
+			"int", "long", "namespace", "new", "nullptr", "private", "protected", "public", "return", "short",
+			"signed", "sizeof", "static", "struct", "switch", "template", "this", "throw", "true", "try",
+			"typedef", "typename", "union", "unsigned", "using", "virtual", "void", "while",
+		),
+	}
+
+	java = &Syntax{
+		LineComments: []string{"//"},
+		BlockComment: [2]string{"/*", "*/"},
+		Quotes:       "\"'",
+		Keywords: keywords(
+			"abstract", "boolean", "break", "byte", "case", "catch", "char", "class", "continue", "default",
+			"do", "double", "else", "enum", "extends", "false", "final", "finally", "float", "for", "if",
+			"implements", "import", "instanceof", "int", "interface", "long", "new", "null", "package",
+			"private", "protected", "public", "return", "short", "static", "super", "switch", "this", "throw",
+			"throws", "true", "try", "var", "void", "while",
+		),
+	}
+)
+
+func keywords(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+
+	return m
+}
+
+func (s *Syntax) lineComment(src []rune, i int) bool {
+	for _, c := range s.LineComments {
+		if hasPrefix(src, i, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Syntax) blockComment(src []rune, i int) bool {
+	return s.BlockComment[0] != "" && hasPrefix(src, i, s.BlockComment[0])
+}
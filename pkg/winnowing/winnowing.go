@@ -0,0 +1,179 @@
+// Package winnowing fingerprints source code to find copies which differ in
+// names, literals, formatting and comments. Code is reduced to normalized
+// tokens, k-grams of tokens are hashed and the minimal hash of every window
+// of w consecutive hashes is selected as a fingerprint.
+package winnowing
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const (
+	identifierToken = "V"
+	numberToken     = "N"
+	stringToken     = "S"
+)
+
+type Token struct {
+	Text string
+	Line int
+}
+
+// Fingerprint is a selected hash of the k-gram starting at token Pos, the
+// k-gram spans lines from StartLine to EndLine.
+type Fingerprint struct {
+	Hash      uint64
+	Pos       int
+	StartLine int
+	EndLine   int
+}
+
+// Tokenize splits code into normalized tokens by the syntax of its language:
+// whitespace and comments are dropped, identifiers, numbers and string
+// literals are replaced by their kind, keywords are kept. Lines are counted
+// from 1.
+func Tokenize(code string, syntax *Syntax) []Token {
+	src := []rune(code)
+	tokens := make([]Token, 0, len(src)/4)
+	line := 1
+
+	for i := 0; i < len(src); {
+		r := src[i]
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case syntax.lineComment(src, i):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case syntax.blockComment(src, i):
+			i += len(syntax.BlockComment[0])
+			for i < len(src) && !hasPrefix(src, i, syntax.BlockComment[1]) {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += len(syntax.BlockComment[1])
+		case strings.ContainsRune(syntax.Quotes, r):
+			tokens = append(tokens, Token{Text: stringToken, Line: line})
+
+			i++
+			for i < len(src) && src[i] != r {
+				if src[i] == '\\' {
+					i++
+				}
+				if i < len(src) && src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i++
+		case unicode.IsDigit(r):
+			tokens = append(tokens, Token{Text: numberToken, Line: line})
+
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '.' || src[i] == '_') {
+				i++
+			}
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_' || src[i] == '$') {
+				i++
+			}
+
+			text := string(src[start:i])
+			if _, ok := syntax.Keywords[text]; !ok {
+				text = identifierToken
+			}
+
+			tokens = append(tokens, Token{Text: text, Line: line})
+		default:
+			tokens = append(tokens, Token{Text: string(r), Line: line})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func hasPrefix(src []rune, i int, prefix string) bool {
+	for _, r := range prefix {
+		if i >= len(src) || src[i] != r {
+			return false
+		}
+		i++
+	}
+
+	return true
+}
+
+// Fingerprints selects fingerprints of k-grams of the tokens by winnowing
+// with window of w hashes: the minimal hash of every window is selected, the
+// rightmost one on ties, and it is recorded once while it stays minimal.
+// Every copied fragment of at least w+k-1 tokens shares a fingerprint.
+func Fingerprints(tokens []Token, k, w int) []Fingerprint {
+	if k < 1 || w < 1 || len(tokens) < k {
+		return nil
+	}
+
+	hashes := make([]uint64, len(tokens)-k+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+k] {
+			_, _ = h.Write([]byte(t.Text))
+			_, _ = h.Write([]byte{0})
+		}
+
+		hashes[i] = h.Sum64()
+	}
+
+	fingerprint := func(pos int) Fingerprint {
+		return Fingerprint{
+			Hash:      hashes[pos],
+			Pos:       pos,
+			StartLine: tokens[pos].Line,
+			EndLine:   tokens[pos+k-1].Line,
+		}
+	}
+
+	if len(hashes) <= w {
+		minPos := 0
+		for i := range hashes {
+			if hashes[i] <= hashes[minPos] {
+				minPos = i
+			}
+		}
+
+		return []Fingerprint{fingerprint(minPos)}
+	}
+
+	fingerprints := make([]Fingerprint, 0, 2*len(hashes)/(w+1)+1)
+	selected := -1
+
+	for start := 0; start+w <= len(hashes); start++ {
+		minPos := selected
+		if minPos < start {
+			minPos = start
+			for i := start; i < start+w; i++ {
+				if hashes[i] <= hashes[minPos] {
+					minPos = i
+				}
+			}
+		} else if last := start + w - 1; hashes[last] <= hashes[minPos] {
+			minPos = last
+		}
+
+		if minPos != selected {
+			selected = minPos
+			fingerprints = append(fingerprints, fingerprint(selected))
+		}
+	}
+
+	return fingerprints
+}